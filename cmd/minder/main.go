@@ -1,12 +1,17 @@
 package main
 
 import (
+	"flag"
+
 	"github.com/leeineian/minder/internal/bot"
 	"github.com/leeineian/minder/internal/config"
 	"github.com/leeineian/minder/internal/logger"
 )
 
 func main() {
+	dryRun := flag.Bool("dry-run", false, "log the command sync plan and exit without applying it")
+	flag.Parse()
+
 	// 1. Load Config
 	cfg, err := config.Load()
 	if err != nil {
@@ -19,6 +24,13 @@ func main() {
 	logger.Init(cfg.LogLevel)
 	logger.Info("Minder bot starting", "logLevel", cfg.LogLevel, "environment", cfg.Environment)
 
+	if *dryRun {
+		if err := bot.DryRunSync(cfg); err != nil {
+			logger.Error("Command sync dry run failed", "error", err)
+		}
+		return
+	}
+
 	// 3. Start Bot
 	if err := bot.Start(cfg); err != nil {
 		logger.Error("Bot crashed", "error", err)
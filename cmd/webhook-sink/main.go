@@ -0,0 +1,57 @@
+package main
+
+import (
+	"flag"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/leeineian/minder/internal/daemons/looper/sink"
+	"github.com/leeineian/minder/internal/logger"
+)
+
+// webhook-sink runs a local server that mimics Discord's webhook endpoint.
+// Point the looper at it with LOOPER_BASE_URL=http://localhost:8089
+func main() {
+	addr := flag.String("addr", ":8089", "listen address")
+	latency := flag.Duration("latency", 0, "fixed response latency")
+	jitter := flag.Duration("jitter", 0, "random extra latency")
+	errorRate := flag.Float64("error-rate", 0, "fraction of requests answered with 500")
+	rateLimitRate := flag.Float64("ratelimit-rate", 0, "fraction of requests answered with a random 429")
+	bucketLimit := flag.Int("bucket-limit", 5, "requests per webhook bucket window (0 disables buckets)")
+	bucketWindow := flag.Duration("bucket-window", 2*time.Second, "webhook bucket window")
+	retryAfter := flag.Duration("retry-after", time.Second, "Retry-After for random 429s")
+	flag.Parse()
+
+	logger.Init(os.Getenv("LOG_LEVEL"))
+
+	s := sink.New(sink.Options{
+		Latency:       *latency,
+		Jitter:        *jitter,
+		ErrorRate:     *errorRate,
+		RateLimitRate: *rateLimitRate,
+		BucketLimit:   *bucketLimit,
+		BucketWindow:  *bucketWindow,
+		RetryAfter:    *retryAfter,
+	})
+
+	go func() {
+		ticker := time.NewTicker(10 * time.Second)
+		defer ticker.Stop()
+		for range ticker.C {
+			st := s.Stats()
+			logger.Info("Sink stats",
+				"requests", st.Requests,
+				"success", st.Success,
+				"rateLimited", st.RateLimited,
+				"errors", st.Errors,
+				"webhooks", len(st.ByWebhook))
+		}
+	}()
+
+	logger.Info("Webhook sink listening", "addr", *addr)
+	if err := http.ListenAndServe(*addr, s); err != nil {
+		logger.Error("Webhook sink stopped", "error", err)
+		os.Exit(1)
+	}
+}
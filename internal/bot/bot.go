@@ -1,17 +1,23 @@
 package bot
 
 import (
+	"context"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
+	"time"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/leeineian/minder/internal/commands"
 	_ "github.com/leeineian/minder/internal/commands/ai"       // Register AI commands
 	_ "github.com/leeineian/minder/internal/commands/cat"      // Register cat commands
-	_ "github.com/leeineian/minder/internal/commands/debug"    // Register debug commands
+	"github.com/leeineian/minder/internal/commands/debug"      // Register debug commands; hooks up /shutdown
+	_ "github.com/leeineian/minder/internal/commands/help"     // Register help command
 	_ "github.com/leeineian/minder/internal/commands/reminder" // Register reminder commands
+	_ "github.com/leeineian/minder/internal/commands/status"   // Register status command
 	"github.com/leeineian/minder/internal/config"
+	"github.com/leeineian/minder/internal/daemons"
 	"github.com/leeineian/minder/internal/daemons/aichat"
 	"github.com/leeineian/minder/internal/daemons/looper"
 	"github.com/leeineian/minder/internal/daemons/rolecolor"
@@ -34,11 +40,6 @@ func Start(cfg *config.Config) error {
 		logger.Warn("Database migration warning", "error", err)
 	}
 
-	// 0.5 Load Daemons
-	if err := looper.GlobalManager.LoadFromDB(); err != nil {
-		logger.Warn("Failed to load loops from database", "error", err)
-	}
-
 	// 1. Create Session
 	logger.Info("Creating Discord session")
 	s, err := discordgo.New("Bot " + cfg.Token)
@@ -47,7 +48,42 @@ func Start(cfg *config.Config) error {
 		return err
 	}
 
+	// 1.5 Load Daemons
+	// Resumed thread-targeted loops may need the session's REST API to create threads
+	looper.GlobalManager.Threads = s
+	looper.GlobalManager.Webhooks = s
+	looper.GlobalManager.Secret = cfg.LooperSecret
+	if cfg.LooperSecret == "" {
+		// Keeps tokens encrypted, but rotating the bot token then invalidates stored loops
+		logger.Warn("LOOPER_SECRET is not set; encrypting webhook tokens with a key derived from the bot token")
+		looper.GlobalManager.Secret = cfg.Token
+	}
+	if cfg.LooperBaseURL != "" {
+		logger.Info("Webhook looper targeting custom base URL", "baseURL", cfg.LooperBaseURL)
+		looper.GlobalManager.BaseURL = cfg.LooperBaseURL
+	}
+	if err := looper.GlobalManager.LoadFromDB(); err != nil {
+		logger.Warn("Failed to load loops from database", "error", err)
+	}
+
 	// 2. Register Handlers
+	// Components carry state signed with a key that must outlive restarts
+	if err := commands.LoadComponentSecret(); err != nil {
+		logger.Warn("Failed to load component signing key; buttons will stop working after a restart", "error", err)
+	}
+	commands.SetOwnerID(cfg.OwnerId)
+	if cfg.OwnerId == "" {
+		logger.Warn("OWNER_ID is not set; owner-only commands are disabled")
+	}
+	// Defer well before Discord's three second response deadline
+	commands.Use(
+		commands.Recover(),
+		commands.Logging(),
+		commands.Permissions(),
+		commands.Cooldowns(commands.PersistCooldowns(), commands.BypassCooldowns(commands.OwnerOnly())),
+		commands.AutoDefer(2*time.Second),
+	)
+
 	s.AddHandler(func(s *discordgo.Session, r *discordgo.Ready) {
 		logger.Info("Bot logged in successfully",
 			"username", s.State.User.Username,
@@ -63,7 +99,6 @@ func Start(cfg *config.Config) error {
 		logger.Error("Failed to open Discord connection", "error", err)
 		return err
 	}
-	defer s.Close()
 
 	// 4. Register Commands
 	logger.Info("Syncing Discord commands")
@@ -73,19 +108,82 @@ func Start(cfg *config.Config) error {
 
 	// 5. Start Daemons
 	logger.Info("Starting daemons")
-	status.Start(s)
-	aichat.Start(s)
-	rolecolor.Start(s)
+	status.GuildCount = func() int {
+		s.State.RLock()
+		defer s.State.RUnlock()
+		return len(s.State.Guilds)
+	}
+	rotator := status.New(s)
+	rotator.Interval = cfg.StatusInterval
+	rotator.Order = status.Order(cfg.StatusOrder)
+	daemons.GlobalManager.Add(rotator)
+	daemons.GlobalManager.Add(aichat.New(s))
+	daemons.GlobalManager.Add(rolecolor.New(s))
+	daemons.GlobalManager.Start(context.Background())
 	if err := scheduler.RestoreReminders(s); err != nil {
 		logger.Warn("Failed to restore reminders", "error", err)
 	}
 
-	// 6. Wait for Interrupt
+	// 6. Wait for Interrupt or /shutdown
+	requested := make(chan struct{})
+	var once sync.Once
+	debug.RequestShutdown = func() { once.Do(func() { close(requested) }) }
+
 	logger.Info("Bot is now running. Press Ctrl+C to exit")
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
-	<-stop
+	select {
+	case <-stop:
+	case <-requested:
+	}
+	signal.Stop(stop)
 
+	shutdown(s)
+	return nil
+}
+
+// shutdownTimeout bounds the whole shutdown; whatever is still running
+// afterwards is abandoned
+const shutdownTimeout = 15 * time.Second
+
+// shutdown stops the bot in dependency order: interactions first so no new
+// work starts, then the daemons, reminders and loops that use the session,
+// then the session itself. The database is closed by Start once it returns.
+func shutdown(s *discordgo.Session) {
 	logger.Info("Gracefully shutting down...")
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := commands.Drain(ctx); err != nil {
+		logger.Warn("Interactions still in flight at shutdown", "error", err)
+	}
+	if err := daemons.GlobalManager.Stop(ctx); err != nil {
+		logger.Warn("Daemons did not all stop cleanly", "error", err)
+	}
+	if err := scheduler.Shutdown(ctx); err != nil {
+		logger.Warn("Reminders still being delivered at shutdown", "error", err)
+	}
+	if err := looper.GlobalManager.Shutdown(ctx); err != nil {
+		logger.Warn("Webhook loops did not stop in time", "error", err)
+	}
+	if err := s.Close(); err != nil {
+		logger.Warn("Failed to close Discord connection", "error", err)
+	}
+	logger.Info("Shutdown complete")
+}
+
+// DryRunSync logs the changes syncing commands would make without applying
+// them or connecting to the gateway
+func DryRunSync(cfg *config.Config) error {
+	s, err := discordgo.New("Bot " + cfg.Token)
+	if err != nil {
+		return err
+	}
+	plan, err := commands.PlanSync(s, cfg)
+	if err != nil {
+		return err
+	}
+	plan.Log()
+	logger.Info("Dry run; no commands were changed", "changes", plan.Changes())
 	return nil
 }
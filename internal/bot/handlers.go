@@ -10,12 +10,16 @@ import (
 func InteractionHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	switch i.Type {
 	case discordgo.InteractionApplicationCommand:
-		if cmd, ok := commands.Registry[i.ApplicationCommandData().Name]; ok {
-			cmd.Handler(s, i)
-		} else {
+		if !commands.Dispatch(s, i) {
 			log.Printf("Unknown command: %s", i.ApplicationCommandData().Name)
 		}
-	case discordgo.InteractionMessageComponent:
-		log.Printf("Component interaction: %s", i.MessageComponentData().CustomID)
+	case discordgo.InteractionApplicationCommandAutocomplete:
+		if !commands.DispatchAutocomplete(s, i) {
+			log.Printf("Unknown command: %s", i.ApplicationCommandData().Name)
+		}
+	case discordgo.InteractionMessageComponent, discordgo.InteractionModalSubmit:
+		if !commands.DispatchComponent(s, i) {
+			log.Printf("Unknown component interaction: %s", commands.ComponentCustomID(i))
+		}
 	}
 }
@@ -2,16 +2,26 @@ package ai
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
-	"fmt"
+	"errors"
 	"io"
 	"net/http"
+	"time"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/leeineian/minder/internal/commands"
 	"github.com/leeineian/minder/internal/config"
+	"github.com/leeineian/minder/internal/discord"
+	"github.com/leeineian/minder/internal/i18n"
 )
 
+// defaultModel answers chats that do not pick a model
+const defaultModel = "gpt-3.5-turbo"
+
+// models are the chat models offered by model autocomplete
+var models = []string{defaultModel, "gpt-4o-mini", "gpt-4o", "gpt-4.1-mini", "gpt-4.1"}
+
 var AiCmd = &commands.Command{
 	Name:        "ai",
 	Description: "Talk to AI",
@@ -27,10 +37,34 @@ var AiCmd = &commands.Command{
 					Description: "Your message",
 					Required:    true,
 				},
+				{
+					Type:         discordgo.ApplicationCommandOptionString,
+					Name:         "model",
+					Description:  "Model to answer with (default " + defaultModel + ")",
+					Required:     false,
+					Autocomplete: true,
+				},
 			},
 		},
 	},
-	Handler: handleAI,
+	Handler: commands.Router{
+		"chat": commands.WithOptions(handleChat),
+	}.Handle,
+	Autocomplete: map[string]commands.AutocompleteFunc{
+		"model": modelChoices,
+	},
+	Examples: []string{
+		"/ai chat message:Explain Go channels in one paragraph",
+		"/ai chat message:Write a haiku about cats model:gpt-4o-mini",
+	},
+
+	Cooldowns: aiCooldowns,
+}
+
+// aiCooldowns are shared by every command that calls the paid AI API
+var aiCooldowns = []commands.Cooldown{
+	{Uses: 3, Window: time.Minute, Scope: commands.PerUser, Bucket: "ai"},
+	{Uses: 20, Window: time.Minute, Scope: commands.PerGuild, Bucket: "ai"},
 }
 
 type ChatRequest struct {
@@ -51,50 +85,56 @@ type ChatResponse struct {
 
 var cfg *config.Config
 
+var (
+	errNotConfigured = i18n.Errorf("ai.not_configured")
+	errNoResponse    = i18n.Errorf("ai.no_response")
+)
+
 func SetConfig(c *config.Config) {
 	cfg = c
 }
 
-func handleAI(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	options := i.ApplicationCommandData().Options
-	if len(options) == 0 {
-		return
-	}
-
-	subCmd := options[0].Name
-	if subCmd != "chat" {
-		return
-	}
-
-	userMsg := options[0].Options[0].StringValue()
+// chatOptions hold the message sent to the AI
+type chatOptions struct {
+	Message string `option:"message,required,minlen=1"`
+	Model   string `option:"model,maxlen=100"`
+}
 
+func handleChat(s discord.Session, i *discordgo.InteractionCreate, opts chatOptions) error {
 	// Defer to allow time for API call
-	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+	commands.Respond(s, i, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
 	})
 
-	// Call AI API
-	response, err := callAI(userMsg)
+	// Call AI API; the reply edits the deferred response
+	response, err := callAI(opts.Model, opts.Message)
 	if err != nil {
-		s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
-			Content: ptrString(fmt.Sprintf("❌ AI Error: %v", err)),
-		})
-		return
+		return aiError(err)
 	}
+	return commands.Reply(s, i, response)
+}
 
-	s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
-		Content: ptrString(response),
-	})
+// modelChoices suggests the known chat models
+func modelChoices(_ context.Context, _ *discordgo.InteractionCreate, value string) []*discordgo.ApplicationCommandOptionChoice {
+	choices := make([]*discordgo.ApplicationCommandOptionChoice, len(models))
+	for idx, m := range models {
+		choices[idx] = &discordgo.ApplicationCommandOptionChoice{Name: m, Value: m}
+	}
+	return commands.MatchChoices(value, choices)
 }
 
-func callAI(userMessage string) (string, error) {
+func callAI(model, userMessage string) (string, error) {
 	// Skip if no API key
 	if cfg == nil || cfg.TavilyKey == "" {
-		return "⚠️ AI feature not configured (missing API key)", nil
+		return "", errNotConfigured
+	}
+
+	if model == "" {
+		model = defaultModel
 	}
 
 	reqBody := ChatRequest{
-		Model: "gpt-3.5-turbo",
+		Model: model,
 		Messages: []Message{
 			{Role: "system", Content: "You are a helpful assistant."},
 			{Role: "user", Content: userMessage},
@@ -133,13 +173,18 @@ func callAI(userMessage string) (string, error) {
 		return chatResp.Choices[0].Message.Content, nil
 	}
 
-	return "No response from AI", nil
+	return "", errNoResponse
 }
 
-func ptrString(s string) *string {
-	return &s
+// aiError describes a failed AI call to the user
+func aiError(err error) error {
+	if errors.Is(err, errNotConfigured) || errors.Is(err, errNoResponse) {
+		return err
+	}
+	return i18n.Errorf("ai.error", err)
 }
 
 func init() {
 	commands.Register(AiCmd)
+	commands.Register(AskAICmd)
 }
@@ -0,0 +1,47 @@
+package ai_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/leeineian/minder/internal/commands"
+	"github.com/leeineian/minder/internal/commands/ai"
+	"github.com/leeineian/minder/internal/config"
+	"github.com/leeineian/minder/internal/discord/discordtest"
+	"github.com/leeineian/minder/internal/logger"
+)
+
+func TestMain(m *testing.M) {
+	logger.Init("error")
+	ai.SetConfig(&config.Config{})
+	os.Exit(m.Run())
+}
+
+func TestChatNotConfigured(t *testing.T) {
+	s := discordtest.NewSession()
+	commands.Dispatch(s, discordtest.Command("ai", discordtest.Args(
+		discordtest.Sub("chat", discordtest.String("message", "Hello")),
+	)))
+
+	calls := s.Calls()
+	if len(calls) != 2 || calls[0].Response.Type != discordgo.InteractionResponseDeferredChannelMessageWithSource {
+		t.Fatalf("Expected a deferred response and an edit, got %+v", calls)
+	}
+	if texts := s.Texts(); len(texts) != 1 || texts[0] != "❌ AI feature not configured (missing API key)" {
+		t.Errorf("Expected the missing key to be reported, got %q", texts)
+	}
+}
+
+func TestAskAboutEmptyMessage(t *testing.T) {
+	s := discordtest.NewSession()
+	target := &discordgo.Message{ID: "m1", ChannelID: discordtest.ChannelID, Author: &discordgo.User{ID: "u2"}}
+	commands.Dispatch(s, discordtest.MessageCommand("Ask AI about this", target))
+
+	if texts := s.Texts(); len(texts) != 1 || texts[0] != "❌ That message has no text to ask about" {
+		t.Errorf("Expected messages without text to be refused, got %q", texts)
+	}
+	if len(s.CallsTo("InteractionResponseEdit")) != 0 {
+		t.Error("Expected the refusal without deferring first")
+	}
+}
@@ -0,0 +1,48 @@
+package ai
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/leeineian/minder/internal/commands"
+	"github.com/leeineian/minder/internal/discord"
+	"github.com/leeineian/minder/internal/i18n"
+)
+
+// AskAICmd is the "Ask AI about this" message context menu command
+var AskAICmd = &commands.Command{
+	Type:    discordgo.MessageApplicationCommand,
+	Name:    "Ask AI about this",
+	Handler: commands.WithOptions(handleAskAbout),
+
+	// Shares the paid API with /ai chat
+	Cooldowns: aiCooldowns,
+}
+
+// handleAskAbout asks the default model to explain the target message
+func handleAskAbout(s discord.Session, i *discordgo.InteractionCreate, _ struct{}) error {
+	msg, err := commands.TargetMessage(i)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(msg.Content) == "" {
+		return i18n.Errorf("ai.no_text")
+	}
+
+	commands.Respond(s, i, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	})
+
+	author := "someone"
+	if msg.Author != nil {
+		author = msg.Author.Username
+	}
+	prompt := fmt.Sprintf("Explain or respond to this Discord message from %s:\n\n%s", author, msg.Content)
+
+	response, err := callAI(defaultModel, prompt)
+	if err != nil {
+		return aiError(err)
+	}
+	return commands.Reply(s, i, response)
+}
@@ -0,0 +1,136 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/leeineian/minder/internal/discord"
+	"github.com/leeineian/minder/internal/logger"
+)
+
+// AutocompleteFunc suggests choices for the option the user is typing, whose
+// current text is value. ctx is cancelled shortly before Discord stops
+// waiting; providers that outlive it are ignored.
+type AutocompleteFunc func(ctx context.Context, i *discordgo.InteractionCreate, value string) []*discordgo.ApplicationCommandOptionChoice
+
+// AutocompleteTimeout bounds how long providers run. Discord drops
+// autocomplete responses sent more than three seconds after the interaction.
+var AutocompleteTimeout = 2 * time.Second
+
+// maxChoices is the most choices Discord accepts in an autocomplete response
+const maxChoices = 25
+
+// Focused returns the subcommand path of an autocomplete interaction and the
+// option the user is typing in, or nil if no option is focused
+func Focused(i *discordgo.InteractionCreate) (string, *discordgo.ApplicationCommandInteractionDataOption) {
+	path, options := Subcommand(i)
+	for _, opt := range options {
+		if opt.Focused {
+			return path, opt
+		}
+	}
+	return path, nil
+}
+
+// DispatchAutocomplete answers an autocomplete interaction with the choices of
+// the focused option's provider. It reports false if the command is unknown.
+func DispatchAutocomplete(s discord.Session, i *discordgo.InteractionCreate) bool {
+	cmd, ok := Registry[i.ApplicationCommandData().Name]
+	if !ok {
+		return false
+	}
+	// Suggestions are not worth delaying shutdown for
+	if !acquire() {
+		return true
+	}
+	defer release()
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionApplicationCommandAutocompleteResult,
+		Data: &discordgo.InteractionResponseData{Choices: cmd.suggest(i)},
+	})
+	if err != nil {
+		logger.Debug("Failed to send autocomplete choices", "command", cmd.Name, "error", err)
+	}
+	return true
+}
+
+// suggest runs the provider of the focused option within AutocompleteTimeout
+func (c *Command) suggest(i *discordgo.InteractionCreate) []*discordgo.ApplicationCommandOptionChoice {
+	path, opt := Focused(i)
+	if opt == nil {
+		return nil
+	}
+	provider := c.Autocomplete[strings.TrimSpace(path+" "+opt.Name)]
+	if provider == nil {
+		provider = c.Autocomplete[opt.Name]
+	}
+	// Suggestions can reveal data, so they follow the command's policies
+	if provider == nil || c.Authorize(i) != nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), AutocompleteTimeout)
+	defer cancel()
+
+	result := make(chan []*discordgo.ApplicationCommandOptionChoice, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				logger.Error("Panic in autocomplete provider", "command", c.Name, "option", opt.Name, "panic", r)
+				result <- nil
+			}
+		}()
+		value, _ := opt.Value.(string)
+		if value == "" && opt.Value != nil {
+			value = fmt.Sprint(opt.Value)
+		}
+		result <- provider(ctx, i, value)
+	}()
+
+	select {
+	case choices := <-result:
+		return limitChoices(choices)
+	case <-ctx.Done():
+		logger.Warn("Autocomplete provider timed out", "command", c.Name, "option", opt.Name)
+		return nil
+	}
+}
+
+// limitChoices trims choices to what Discord accepts
+func limitChoices(choices []*discordgo.ApplicationCommandOptionChoice) []*discordgo.ApplicationCommandOptionChoice {
+	if len(choices) > maxChoices {
+		choices = choices[:maxChoices]
+	}
+	for _, c := range choices {
+		if name := []rune(c.Name); len(name) > 100 {
+			c.Name = string(name[:99]) + "…"
+		}
+	}
+	return choices
+}
+
+// MatchChoices returns the choices whose name or value contains query,
+// ignoring case, with choices starting with it first
+func MatchChoices(query string, choices []*discordgo.ApplicationCommandOptionChoice) []*discordgo.ApplicationCommandOptionChoice {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return choices
+	}
+
+	var prefix, contains []*discordgo.ApplicationCommandOptionChoice
+	for _, c := range choices {
+		name := strings.ToLower(c.Name)
+		value := strings.ToLower(fmt.Sprint(c.Value))
+		switch {
+		case strings.HasPrefix(name, query) || strings.HasPrefix(value, query):
+			prefix = append(prefix, c)
+		case strings.Contains(name, query) || strings.Contains(value, query):
+			contains = append(contains, c)
+		}
+	}
+	return append(prefix, contains...)
+}
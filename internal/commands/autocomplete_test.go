@@ -0,0 +1,153 @@
+package commands_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/leeineian/minder/internal/commands"
+	"github.com/leeineian/minder/internal/discord/discordtest"
+)
+
+func names(choices ...string) commands.AutocompleteFunc {
+	return func(_ context.Context, _ *discordgo.InteractionCreate, value string) []*discordgo.ApplicationCommandOptionChoice {
+		var out []*discordgo.ApplicationCommandOptionChoice
+		for _, c := range choices {
+			out = append(out, &discordgo.ApplicationCommandOptionChoice{Name: c, Value: c})
+		}
+		return commands.MatchChoices(value, out)
+	}
+}
+
+// sentChoices returns the choice names of the last autocomplete response
+func sentChoices(t *testing.T, s *discordtest.Session) []string {
+	t.Helper()
+	calls := s.CallsTo("InteractionRespond")
+	if len(calls) == 0 {
+		t.Fatal("No autocomplete response sent")
+	}
+	resp := calls[len(calls)-1].Response
+	if resp.Type != discordgo.InteractionApplicationCommandAutocompleteResult {
+		t.Fatalf("Expected an autocomplete result, got type %d", resp.Type)
+	}
+	var got []string
+	if resp.Data != nil {
+		for _, c := range resp.Data.Choices {
+			got = append(got, c.Name)
+		}
+	}
+	return got
+}
+
+func TestDispatchAutocomplete(t *testing.T) {
+	commands.SetOwnerID("owner")
+	defer commands.SetOwnerID("")
+
+	withRegistry(t, nil, &commands.Command{
+		Name: "test",
+		Autocomplete: map[string]commands.AutocompleteFunc{
+			"id":            names("alpha", "beta", "alphabet"),
+			"secret id":     names("hidden"),
+			"start preset":  names("burst", "soak"),
+			"start comment": nil,
+		},
+	}, &commands.Command{
+		Name:         "locked",
+		Policies:     []commands.Policy{commands.OwnerOnly()},
+		Autocomplete: map[string]commands.AutocompleteFunc{"id": names("loop")},
+	})
+
+	tests := []struct {
+		name string
+		cmd  string
+		user string
+		opts []*option
+		want string
+	}{
+		{"option name", "test", "u1", []*option{discordtest.Sub("stop", discordtest.Focused("id", "alp"))}, "alpha alphabet"},
+		{"subcommand path wins", "test", "u1", []*option{discordtest.Sub("secret", discordtest.Focused("id", ""))}, "hidden"},
+		{"focused among others", "test", "u1", []*option{discordtest.Sub("start", discordtest.String("id", "x"), discordtest.Focused("preset", "so"))}, "soak"},
+		{"no provider", "test", "u1", []*option{discordtest.Sub("start", discordtest.Focused("comment", "x"))}, ""},
+		{"allowed by policy", "locked", "owner", []*option{discordtest.Focused("id", "")}, "loop"},
+		{"denied by policy", "locked", "u1", []*option{discordtest.Focused("id", "")}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := discordtest.NewSession()
+			if !commands.DispatchAutocomplete(s, discordtest.Autocomplete(tt.cmd, tt.opts, discordtest.From(tt.user))) {
+				t.Fatal("Expected the command to be found")
+			}
+			if got := strings.Join(sentChoices(t, s), " "); got != tt.want {
+				t.Errorf("Choices = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAutocompleteDeadline(t *testing.T) {
+	saved := commands.AutocompleteTimeout
+	commands.AutocompleteTimeout = 20 * time.Millisecond
+	defer func() { commands.AutocompleteTimeout = saved }()
+
+	release := make(chan struct{})
+	defer close(release)
+	withRegistry(t, nil, &commands.Command{
+		Name: "test",
+		Autocomplete: map[string]commands.AutocompleteFunc{
+			"slow": func(ctx context.Context, _ *discordgo.InteractionCreate, _ string) []*discordgo.ApplicationCommandOptionChoice {
+				<-release
+				return nil
+			},
+			"many": func(context.Context, *discordgo.InteractionCreate, string) []*discordgo.ApplicationCommandOptionChoice {
+				var out []*discordgo.ApplicationCommandOptionChoice
+				for n := 0; n < 40; n++ {
+					out = append(out, &discordgo.ApplicationCommandOptionChoice{Name: fmt.Sprint(n) + strings.Repeat("x", 120), Value: n})
+				}
+				return out
+			},
+		},
+	})
+
+	s := discordtest.NewSession()
+	start := time.Now()
+	commands.DispatchAutocomplete(s, discordtest.Autocomplete("test", discordtest.Args(discordtest.Focused("slow", ""))))
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Slow provider held the response for %s", elapsed)
+	}
+	if got := sentChoices(t, s); len(got) != 0 {
+		t.Errorf("Expected no choices from a timed out provider, got %v", got)
+	}
+
+	commands.DispatchAutocomplete(s, discordtest.Autocomplete("test", discordtest.Args(discordtest.Focused("many", ""))))
+	got := sentChoices(t, s)
+	if len(got) != 25 {
+		t.Errorf("Expected choices capped at 25, got %d", len(got))
+	}
+	if n := len([]rune(got[0])); n != 100 {
+		t.Errorf("Expected names truncated to 100 characters, got %d", n)
+	}
+}
+
+func TestMatchChoices(t *testing.T) {
+	choices := []*discordgo.ApplicationCommandOptionChoice{
+		{Name: "Load test", Value: "c1"},
+		{Name: "Soak", Value: "c2"},
+		{Name: "Overload", Value: "c3"},
+	}
+	var got []string
+	for _, c := range commands.MatchChoices("LOAD", choices) {
+		got = append(got, c.Name)
+	}
+	if strings.Join(got, ",") != "Load test,Overload" {
+		t.Errorf("Expected prefix matches first, got %v", got)
+	}
+	if n := len(commands.MatchChoices("c2", choices)); n != 1 {
+		t.Errorf("Expected a match on value, got %d", n)
+	}
+	if n := len(commands.MatchChoices("  ", choices)); n != 3 {
+		t.Errorf("Expected an empty query to match everything, got %d", n)
+	}
+}
@@ -1,8 +1,12 @@
 package cat
 
 import (
+	"time"
+
 	"github.com/bwmarrin/discordgo"
 	"github.com/leeineian/minder/internal/commands"
+	"github.com/leeineian/minder/internal/discord"
+	"github.com/leeineian/minder/internal/i18n"
 )
 
 var SayCmd = &commands.Command{
@@ -23,77 +27,60 @@ var SayCmd = &commands.Command{
 			},
 		},
 	},
-	Handler: handleCat,
-}
-
-func handleCat(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	options := i.ApplicationCommandData().Options
-	if len(options) == 0 {
-		return
-	}
-
-	subCmd := options[0].Name
+	Handler: commands.Router{
+		"say": commands.WithOptions(handleSay),
+	}.Handle,
+	Examples: []string{
+		"/cat say message:Meow",
+	},
 
-	switch subCmd {
-	case "say":
-		if len(options[0].Options) == 0 {
-			return
-		}
+	Cooldowns: catCooldowns,
 
-		message := options[0].Options[0].StringValue()
+	// The bot speaks in the channel; the command itself stays invisible
+	Ephemeral: true,
+}
 
-		// Validate message
-		if len(message) == 0 {
-			s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-				Type: discordgo.InteractionResponseChannelMessageWithSource,
-				Data: &discordgo.InteractionResponseData{
-					Content: "❌ Message cannot be empty",
-					Flags:   discordgo.MessageFlagsEphemeral,
-				},
-			})
-			return
-		}
+// catCooldowns keep the bot from being used to flood a channel
+var catCooldowns = []commands.Cooldown{
+	{Uses: 3, Window: 10 * time.Second, Scope: commands.PerUser, Bucket: "cat"},
+	{Uses: 10, Window: time.Minute, Scope: commands.PerChannel, Bucket: "cat"},
+}
 
-		if len(message) > 2000 {
-			s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-				Type: discordgo.InteractionResponseChannelMessageWithSource,
-				Data: &discordgo.InteractionResponseData{
-					Content: "❌ Message too long (max 2000 characters)",
-					Flags:   discordgo.MessageFlagsEphemeral,
-				},
-			})
-			return
-		}
+// sayOptions hold the message to send
+type sayOptions struct {
+	Message string `option:"message,required,minlen=1,maxlen=2000"`
+}
 
-		// Respond to make the slash command invisible
-		err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-			Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
-			Data: &discordgo.InteractionResponseData{
-				Flags: discordgo.MessageFlagsEphemeral,
-			},
-		})
-		if err != nil {
-			return
-		}
+func handleSay(s discord.Session, i *discordgo.InteractionCreate, opts sayOptions) error {
+	return sayInChannel(s, i, &discordgo.MessageSend{Content: opts.Message})
+}
 
-		// Send the message
-		_, err = s.ChannelMessageSend(i.ChannelID, message)
-		if err != nil {
-			s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
-				Content: ptrString("❌ Failed to send message"),
-			})
-			return
-		}
+// sayInChannel sends msg as the bot in the interaction's channel, leaving no
+// visible response to the interaction itself
+func sayInChannel(s discord.Session, i *discordgo.InteractionCreate, msg *discordgo.MessageSend) error {
+	// Respond to make the slash command invisible
+	err := commands.Respond(s, i, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		return nil
+	}
 
-		// Delete the deferred response
-		s.InteractionResponseDelete(i.Interaction)
+	// Send the message
+	if _, err := s.ChannelMessageSendComplex(i.ChannelID, msg); err != nil {
+		return i18n.Errorf("cat.send_failed")
 	}
-}
 
-func ptrString(s string) *string {
-	return &s
+	// Delete the deferred response
+	s.InteractionResponseDelete(i.Interaction)
+	return nil
 }
 
 func init() {
 	commands.Register(SayCmd)
+	commands.Register(SayAsCatCmd)
+	commands.HandleComponent("cat", "reply", handleSayAsCatSubmit)
 }
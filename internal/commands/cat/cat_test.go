@@ -0,0 +1,78 @@
+package cat_test
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/leeineian/minder/internal/commands"
+	_ "github.com/leeineian/minder/internal/commands/cat"
+	"github.com/leeineian/minder/internal/discord/discordtest"
+	"github.com/leeineian/minder/internal/logger"
+)
+
+func TestMain(m *testing.M) {
+	logger.Init("error")
+	commands.SetComponentSecret([]byte("test"))
+	os.Exit(m.Run())
+}
+
+func TestSay(t *testing.T) {
+	s := discordtest.NewSession()
+	commands.Dispatch(s, discordtest.Command("cat", discordtest.Args(
+		discordtest.Sub("say", discordtest.String("message", "Meow")),
+	)))
+
+	calls := s.Calls()
+	if len(calls) != 3 {
+		t.Fatalf("Expected defer, send and delete, got %+v", calls)
+	}
+	if calls[0].Method != "InteractionRespond" || calls[0].Response.Type != discordgo.InteractionResponseDeferredChannelMessageWithSource {
+		t.Errorf("Expected a deferred response first, got %+v", calls[0])
+	}
+	if calls[1].Method != "ChannelMessageSendComplex" || calls[1].ChannelID != discordtest.ChannelID || calls[1].Message.Content != "Meow" {
+		t.Errorf("Expected Meow to be sent to the channel, got %+v", calls[1])
+	}
+	if calls[2].Method != "InteractionResponseDelete" {
+		t.Errorf("Expected the deferred response to be deleted, got %+v", calls[2])
+	}
+}
+
+func TestSayFailure(t *testing.T) {
+	s := discordtest.NewSession()
+	s.FailOn("ChannelMessageSendComplex", errors.New("missing access"))
+	commands.Dispatch(s, discordtest.Command("cat", discordtest.Args(
+		discordtest.Sub("say", discordtest.String("message", "Meow")),
+	)))
+
+	texts := s.Texts()
+	if len(texts) != 1 || texts[0] != "❌ Failed to send message" {
+		t.Errorf("Expected the failure to be reported, got %q", texts)
+	}
+	if len(s.CallsTo("InteractionResponseDelete")) != 0 {
+		t.Error("Expected the error response to be kept")
+	}
+}
+
+func TestSayAsCat(t *testing.T) {
+	s := discordtest.NewSession()
+	target := &discordgo.Message{ID: "m1", ChannelID: discordtest.ChannelID, Content: "Who's a good cat?"}
+	commands.Dispatch(s, discordtest.MessageCommand("Say as cat", target))
+
+	responses := s.CallsTo("InteractionRespond")
+	if len(responses) != 1 || responses[0].Response.Type != discordgo.InteractionResponseModal {
+		t.Fatalf("Expected a modal, got %+v", responses)
+	}
+
+	s.Reset()
+	commands.DispatchComponent(s, discordtest.ModalSubmit(responses[0].Response.Data.CustomID, map[string]string{"message": "Me!"}))
+
+	sent := s.CallsTo("ChannelMessageSendComplex")
+	if len(sent) != 1 {
+		t.Fatalf("Expected one message, got %+v", s.Calls())
+	}
+	if sent[0].Message.Content != "Me!" || sent[0].Message.Reference == nil || sent[0].Message.Reference.MessageID != "m1" {
+		t.Errorf("Expected a reply to m1, got %+v", sent[0].Message)
+	}
+}
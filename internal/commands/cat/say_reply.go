@@ -0,0 +1,61 @@
+package cat
+
+import (
+	"github.com/bwmarrin/discordgo"
+	"github.com/leeineian/minder/internal/commands"
+	"github.com/leeineian/minder/internal/discord"
+)
+
+// SayAsCatCmd is the "Say as cat" message context menu command, which makes
+// the bot reply to a message
+var SayAsCatCmd = &commands.Command{
+	Type:      discordgo.MessageApplicationCommand,
+	Name:      "Say as cat",
+	Handler:   commands.WithOptions(handleSayAsCat),
+	Ephemeral: true,
+	Cooldowns: catCooldowns,
+}
+
+// replyState identifies the message the bot replies to
+type replyState struct {
+	MessageID string `json:"m"`
+}
+
+// handleSayAsCat asks what the bot should reply with
+func handleSayAsCat(s discord.Session, i *discordgo.InteractionCreate, _ struct{}) error {
+	msg, err := commands.TargetMessage(i)
+	if err != nil {
+		return err
+	}
+
+	return commands.Respond(s, i, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseModal,
+		Data: &discordgo.InteractionResponseData{
+			CustomID: commands.MustCustomID("cat", "reply", replyState{MessageID: msg.ID}),
+			Title:    commands.Text(i, "cat.modal_title"),
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+					discordgo.TextInput{
+						CustomID:  "message",
+						Label:     commands.Text(i, "cat.modal_message"),
+						Style:     discordgo.TextInputParagraph,
+						Required:  true,
+						MaxLength: 2000,
+					},
+				}},
+			},
+		},
+	})
+}
+
+// handleSayAsCatSubmit sends the reply
+func handleSayAsCatSubmit(s discord.Session, i *discordgo.InteractionCreate, state replyState) error {
+	return sayInChannel(s, i, &discordgo.MessageSend{
+		Content: commands.ModalValues(i)["message"],
+		Reference: &discordgo.MessageReference{
+			MessageID: state.MessageID,
+			ChannelID: i.ChannelID,
+			GuildID:   i.GuildID,
+		},
+	})
+}
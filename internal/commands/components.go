@@ -0,0 +1,224 @@
+package commands
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/leeineian/minder/internal/database"
+	"github.com/leeineian/minder/internal/discord"
+	"github.com/leeineian/minder/internal/i18n"
+	"github.com/leeineian/minder/internal/logger"
+)
+
+// Component custom IDs have the form "<namespace>:<action>:<state>:<signature>".
+// The state is the handler's typed state as base64url JSON and the signature
+// an HMAC of everything before it, so users cannot forge or alter state.
+// Because the state travels in the ID and the key is stored in the database,
+// components keep working after a restart.
+
+// maxCustomID is the longest custom ID Discord accepts
+const maxCustomID = 100
+
+// signatureLen is the number of HMAC bytes kept in a custom ID
+const signatureLen = 12
+
+// ErrInvalidCustomID is returned for custom IDs that are malformed or not signed with the current key
+var ErrInvalidCustomID error = i18n.Errorf("error.invalid_component")
+
+// componentSecretKey is the kv_store key holding the hex encoded signing key
+const componentSecretKey = "component_secret"
+
+var (
+	secretMu        sync.Mutex
+	componentSecret []byte
+)
+
+// componentRoute handles one namespace and action
+type componentRoute struct {
+	policies []Policy
+	handle   func(s discord.Session, i *discordgo.InteractionCreate, state []byte) error
+}
+
+// componentRoutes maps "<namespace>:<action>" to its route
+var componentRoutes = make(map[string]*componentRoute)
+
+// HandleComponent routes component and modal submit interactions whose custom
+// ID was made by CustomID with the same namespace and action to handler, which
+// receives the decoded state. Interactions the policies deny never reach it,
+// and errors it returns are sent as ephemeral replies.
+func HandleComponent[T any](namespace, action string, handler func(s discord.Session, i *discordgo.InteractionCreate, state T) error, policies ...Policy) {
+	componentRoutes[namespace+":"+action] = &componentRoute{
+		policies: policies,
+		handle: func(s discord.Session, i *discordgo.InteractionCreate, raw []byte) error {
+			var state T
+			if len(raw) > 0 {
+				if err := json.Unmarshal(raw, &state); err != nil {
+					return fmt.Errorf("%w: %v", ErrInvalidCustomID, err)
+				}
+			}
+			return handler(s, i, state)
+		},
+	}
+}
+
+// CustomID builds a signed custom ID carrying state for the handler
+// registered with HandleComponent for namespace and action
+func CustomID(namespace, action string, state any) (string, error) {
+	if strings.Contains(namespace, ":") || strings.Contains(action, ":") {
+		return "", fmt.Errorf("custom ID namespace %q and action %q must not contain ':'", namespace, action)
+	}
+	raw, err := json.Marshal(state)
+	if err != nil {
+		return "", fmt.Errorf("encode component state: %w", err)
+	}
+	if state == nil {
+		raw = nil
+	}
+
+	unsigned := namespace + ":" + action + ":" + base64.RawURLEncoding.EncodeToString(raw)
+	id := unsigned + ":" + sign(unsigned)
+	if len(id) > maxCustomID {
+		return "", fmt.Errorf("custom ID for %s:%s is %d characters, the limit is %d", namespace, action, len(id), maxCustomID)
+	}
+	return id, nil
+}
+
+// MustCustomID is like CustomID but panics on error. Use it for state whose
+// size is known to fit, such as a few snowflake IDs.
+func MustCustomID(namespace, action string, state any) string {
+	id, err := CustomID(namespace, action, state)
+	if err != nil {
+		panic(err)
+	}
+	return id
+}
+
+// ComponentCustomID returns the custom ID of a component or modal submit interaction
+func ComponentCustomID(i *discordgo.InteractionCreate) string {
+	if i.Type == discordgo.InteractionModalSubmit {
+		return i.ModalSubmitData().CustomID
+	}
+	return i.MessageComponentData().CustomID
+}
+
+// ComponentNamespace returns the namespace part of a component custom ID
+func ComponentNamespace(customID string) string {
+	ns, _, _ := strings.Cut(customID, ":")
+	return ns
+}
+
+// DispatchComponent runs the handler registered for a component or modal
+// submit interaction through the global middleware, after checking the
+// signature of its custom ID. It reports false if no handler is registered.
+func DispatchComponent(s discord.Session, i *discordgo.InteractionCreate) bool {
+	customID := ComponentCustomID(i)
+	parts := strings.SplitN(customID, ":", 4)
+	if len(parts) != 4 {
+		return false
+	}
+	route, ok := componentRoutes[parts[0]+":"+parts[1]]
+	if !ok {
+		return false
+	}
+
+	cmd := &Command{Name: parts[0]}
+	run(cmd, func(s discord.Session, i *discordgo.InteractionCreate) {
+		if err := authorize(cmd.Name, route.policies, i); err != nil {
+			Deny(s, i, err)
+			return
+		}
+		state, err := verifyCustomID(parts)
+		if err == nil {
+			err = route.handle(s, i, state)
+		}
+		if err != nil {
+			if errors.Is(err, ErrInvalidCustomID) {
+				logger.Warn("Rejected component interaction", "customID", customID, "userID", InteractionUserID(i), "error", err)
+				err = ErrInvalidCustomID
+			}
+			ReplyError(s, i, err)
+		}
+	}, nil, s, i)
+	return true
+}
+
+// verifyCustomID checks the signature of a split custom ID and returns its state
+func verifyCustomID(parts []string) ([]byte, error) {
+	unsigned := strings.Join(parts[:3], ":")
+	if !hmac.Equal([]byte(parts[3]), []byte(sign(unsigned))) {
+		return nil, fmt.Errorf("%w: bad signature", ErrInvalidCustomID)
+	}
+	state, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCustomID, err)
+	}
+	return state, nil
+}
+
+func sign(unsigned string) string {
+	mac := hmac.New(sha256.New, signingKey())
+	mac.Write([]byte(unsigned))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:signatureLen])
+}
+
+// signingKey returns the component signing key, generating a temporary one
+// if LoadComponentSecret has not run
+func signingKey() []byte {
+	secretMu.Lock()
+	defer secretMu.Unlock()
+	if componentSecret == nil {
+		componentSecret = make([]byte, 32)
+		rand.Read(componentSecret)
+	}
+	return componentSecret
+}
+
+// SetComponentSecret sets the key that signs component custom IDs
+func SetComponentSecret(secret []byte) {
+	secretMu.Lock()
+	defer secretMu.Unlock()
+	componentSecret = append([]byte(nil), secret...)
+}
+
+// LoadComponentSecret reads the component signing key from the kv_store
+// table, creating it on first run, so components outlive restarts
+func LoadComponentSecret() error {
+	if database.DB == nil {
+		return errors.New("database not initialized")
+	}
+
+	var stored string
+	err := database.DB.QueryRow("SELECT value FROM kv_store WHERE key = ?", componentSecretKey).Scan(&stored)
+	if err == nil {
+		secret, err := hex.DecodeString(stored)
+		if err != nil {
+			return fmt.Errorf("decode component secret: %w", err)
+		}
+		SetComponentSecret(secret)
+		return nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("load component secret: %w", err)
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return err
+	}
+	if _, err := database.DB.Exec("INSERT INTO kv_store (key, value) VALUES (?, ?)", componentSecretKey, hex.EncodeToString(secret)); err != nil {
+		return fmt.Errorf("store component secret: %w", err)
+	}
+	SetComponentSecret(secret)
+	logger.Info("Generated component signing key")
+	return nil
+}
@@ -0,0 +1,157 @@
+package commands_test
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/leeineian/minder/internal/commands"
+	"github.com/leeineian/minder/internal/database/dbtest"
+	"github.com/leeineian/minder/internal/discord"
+	"github.com/leeineian/minder/internal/discord/discordtest"
+)
+
+type ticket struct {
+	ID    string `json:"i"`
+	Count int    `json:"n"`
+}
+
+// click presses the component with customID as userID
+func click(customID, userID string) *discordgo.InteractionCreate {
+	return discordtest.Button(customID, discordtest.From(userID))
+}
+
+func TestComponentRouting(t *testing.T) {
+	commands.SetComponentSecret([]byte("key one"))
+
+	var got []ticket
+	commands.HandleComponent("tickets", "open", func(s discord.Session, i *discordgo.InteractionCreate, state ticket) error {
+		got = append(got, state)
+		if state.Count < 0 {
+			return errors.New("negative count")
+		}
+		return nil
+	})
+
+	id, err := commands.CustomID("tickets", "open", ticket{ID: "123456789012345678", Count: 2})
+	if err != nil {
+		t.Fatalf("CustomID failed: %v", err)
+	}
+	if len(id) > 100 || !strings.HasPrefix(id, "tickets:open:") {
+		t.Fatalf("Unexpected custom ID %q", id)
+	}
+
+	s := discordtest.NewSession()
+	if !commands.DispatchComponent(s, click(id, "u1")) || !commands.DispatchComponent(s, discordtest.ModalSubmit(id, nil)) {
+		t.Fatal("Expected buttons and modals to be routed")
+	}
+	if len(got) != 2 || got[0] != (ticket{ID: "123456789012345678", Count: 2}) {
+		t.Errorf("Handler got %+v", got)
+	}
+
+	bad, _ := commands.CustomID("tickets", "open", ticket{Count: -1})
+	commands.DispatchComponent(s, click(bad, "u1"))
+	if r := s.Texts(); len(r) != 1 || r[0] != "❌ Negative count" {
+		t.Errorf("Expected the handler error as a reply, got %q", r)
+	}
+
+	if commands.DispatchComponent(s, click("tickets:close::sig", "u1")) || commands.DispatchComponent(s, click("legacy:refresh", "u1")) {
+		t.Error("Expected unregistered custom IDs not to be routed")
+	}
+}
+
+func TestComponentSignature(t *testing.T) {
+	commands.SetComponentSecret([]byte("key one"))
+	ran := 0
+	commands.HandleComponent("signed", "go", func(s discord.Session, i *discordgo.InteractionCreate, state ticket) error {
+		ran++
+		return nil
+	})
+
+	id := commands.MustCustomID("signed", "go", ticket{ID: "mine"})
+	parts := strings.Split(id, ":")
+	forged := base64.RawURLEncoding.EncodeToString([]byte(`{"i":"theirs"}`))
+
+	tests := []struct {
+		name     string
+		customID string
+	}{
+		{"forged state", strings.Join([]string{parts[0], parts[1], forged, parts[3]}, ":")},
+		{"other action", strings.Join([]string{parts[0], "go2", parts[2], parts[3]}, ":")},
+		{"missing signature", strings.Join(parts[:3], ":") + ":"},
+	}
+	commands.HandleComponent("signed", "go2", func(s discord.Session, i *discordgo.InteractionCreate, state ticket) error {
+		ran++
+		return nil
+	})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := discordtest.NewSession()
+			commands.DispatchComponent(s, click(tt.customID, "u1"))
+			if r := s.Texts(); len(r) != 1 || !strings.Contains(r[0], "invalid or has expired") {
+				t.Errorf("Expected a rejection, got %q", r)
+			}
+		})
+	}
+	if ran != 0 {
+		t.Errorf("Handler ran %d times for tampered IDs", ran)
+	}
+
+	// A new key invalidates components signed with the old one
+	commands.SetComponentSecret([]byte("key two"))
+	commands.DispatchComponent(discordtest.NewSession(), click(id, "u1"))
+	if ran != 0 {
+		t.Error("Handler ran for a custom ID signed with a previous key")
+	}
+}
+
+func TestComponentPolicies(t *testing.T) {
+	commands.SetOwnerID("owner")
+	defer commands.SetOwnerID("")
+
+	ran := false
+	commands.HandleComponent("admin", "wipe", func(s discord.Session, i *discordgo.InteractionCreate, _ struct{}) error {
+		ran = true
+		return nil
+	}, commands.OwnerOnly())
+	id := commands.MustCustomID("admin", "wipe", nil)
+
+	s := discordtest.NewSession()
+	commands.DispatchComponent(s, click(id, "someone"))
+	if r := s.Texts(); ran || len(r) != 1 || !strings.Contains(r[0], "⛔") {
+		t.Errorf("Expected a denial, ran=%v replies=%q", ran, r)
+	}
+	commands.DispatchComponent(s, click(id, "owner"))
+	if !ran {
+		t.Error("Expected the owner to be allowed")
+	}
+}
+
+func TestCustomIDLimits(t *testing.T) {
+	if _, err := commands.CustomID("ns", "act", ticket{ID: strings.Repeat("x", 80)}); err == nil {
+		t.Error("Expected state over the custom ID limit to fail")
+	}
+	if _, err := commands.CustomID("n:s", "act", nil); err == nil {
+		t.Error("Expected a namespace with ':' to fail")
+	}
+}
+
+func TestLoadComponentSecret(t *testing.T) {
+	dbtest.Setup(t)
+
+	if err := commands.LoadComponentSecret(); err != nil {
+		t.Fatalf("LoadComponentSecret failed: %v", err)
+	}
+	id := commands.MustCustomID("restart", "check", nil)
+
+	// Simulate a restart: a different key is in memory until the stored one is loaded
+	commands.SetComponentSecret([]byte("temporary"))
+	if err := commands.LoadComponentSecret(); err != nil {
+		t.Fatalf("LoadComponentSecret failed on restart: %v", err)
+	}
+	if again := commands.MustCustomID("restart", "check", nil); again != id {
+		t.Errorf("Expected the stored key to survive a restart, got %q and %q", id, again)
+	}
+}
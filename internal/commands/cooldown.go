@@ -0,0 +1,228 @@
+package commands
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/leeineian/minder/internal/database"
+	"github.com/leeineian/minder/internal/discord"
+	"github.com/leeineian/minder/internal/logger"
+)
+
+// CooldownScope selects whose uses of a command share a cooldown
+type CooldownScope int
+
+const (
+	PerUser CooldownScope = iota
+	PerChannel
+	// PerGuild falls back to the channel in DMs
+	PerGuild
+)
+
+func (sc CooldownScope) String() string {
+	switch sc {
+	case PerChannel:
+		return "channel"
+	case PerGuild:
+		return "guild"
+	default:
+		return "user"
+	}
+}
+
+// Cooldown allows Uses runs of a command per Window in each scope. Uses
+// refill one at a time over the window like a token bucket, so up to Uses
+// runs can come in a burst.
+type Cooldown struct {
+	Uses   int
+	Window time.Duration
+	Scope  CooldownScope
+	// Bucket lets commands with the same Bucket share their uses; it
+	// defaults to the command's name
+	Bucket string
+}
+
+// interval is how long one use takes to refill
+func (c Cooldown) interval() time.Duration {
+	return c.Window / time.Duration(max(c.Uses, 1))
+}
+
+// key identifies the bucket of the scope the interaction falls in
+func (c Cooldown) key(cmd string, i *discordgo.InteractionCreate) string {
+	id := InteractionUserID(i)
+	switch {
+	case c.Scope == PerChannel || (c.Scope == PerGuild && i.GuildID == ""):
+		id = i.ChannelID
+	case c.Scope == PerGuild:
+		id = i.GuildID
+	}
+	bucket := c.Bucket
+	if bucket == "" {
+		bucket = cmd
+	}
+	// The limit is part of the key so changing it starts fresh buckets
+	return fmt.Sprintf("%s/%s/%d-%s/%s", bucket, c.Scope, c.Uses, c.Window, id)
+}
+
+// CooldownOption configures Cooldowns
+type CooldownOption func(*limiter)
+
+// PersistCooldowns keeps cooldowns in the command_cooldowns table so a
+// restart does not reset them. Cooldowns stay in memory only if the stored
+// ones cannot be loaded.
+func PersistCooldowns() CooldownOption {
+	return func(l *limiter) {
+		if err := l.load(time.Now()); err != nil {
+			logger.Warn("Failed to load command cooldowns; they will reset on restart", "error", err)
+			return
+		}
+		l.persist = true
+	}
+}
+
+// BypassCooldowns exempts interactions any of the policies allow, e.g. OwnerOnly
+func BypassCooldowns(policies ...Policy) CooldownOption {
+	return func(l *limiter) {
+		l.bypass = append(l.bypass, policies...)
+	}
+}
+
+// Cooldowns rejects interactions that exceed any of their command's
+// Cooldowns with an ephemeral notice saying when to try again
+func Cooldowns(opts ...CooldownOption) Middleware {
+	l := &limiter{buckets: make(map[string]time.Time)}
+	for _, opt := range opts {
+		opt(l)
+	}
+
+	return func(cmd *Command, next HandlerFunc) HandlerFunc {
+		if len(cmd.Cooldowns) == 0 {
+			return next
+		}
+		return func(s discord.Session, i *discordgo.InteractionCreate) {
+			if l.exempt(i) {
+				next(s, i)
+				return
+			}
+			if wait := l.take(cmd, i, time.Now()); wait > 0 {
+				// Round up so users are never told to retry too early
+				wait = time.Duration(math.Ceil(wait.Seconds())) * time.Second
+				ReplyEphemeral(s, i, Text(i, "error.cooldown", cmd.Name, wait))
+				return
+			}
+			next(s, i)
+		}
+	}
+}
+
+// limiter holds a token bucket per cooldown key as the time it is full
+// again, which is all the state a bucket needs
+type limiter struct {
+	mu      sync.Mutex
+	buckets map[string]time.Time
+	persist bool
+	bypass  []Policy
+	takes   int
+}
+
+func (l *limiter) exempt(i *discordgo.InteractionCreate) bool {
+	for _, p := range l.bypass {
+		if p.Allow(i) {
+			return true
+		}
+	}
+	return false
+}
+
+// take uses one run from every cooldown of cmd, or none if any is
+// exhausted, and returns how long to wait in that case
+func (l *limiter) take(cmd *Command, i *discordgo.InteractionCreate, now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	keys := make([]string, len(cmd.Cooldowns))
+	var wait time.Duration
+	for idx, c := range cmd.Cooldowns {
+		keys[idx] = c.key(cmd.Name, i)
+		debt := max(l.buckets[keys[idx]].Sub(now), 0)
+		if over := debt + c.interval() - c.Window; over > wait {
+			wait = over
+		}
+	}
+	if wait > 0 {
+		return wait
+	}
+
+	for idx, c := range cmd.Cooldowns {
+		fullAt := maxTime(l.buckets[keys[idx]], now).Add(c.interval())
+		l.buckets[keys[idx]] = fullAt
+		if l.persist {
+			l.save(keys[idx], fullAt)
+		}
+	}
+
+	l.takes++
+	if l.takes%1000 == 0 {
+		l.prune(now)
+	}
+	return 0
+}
+
+// prune forgets buckets that have refilled
+func (l *limiter) prune(now time.Time) {
+	for key, fullAt := range l.buckets {
+		if !fullAt.After(now) {
+			delete(l.buckets, key)
+		}
+	}
+	if l.persist {
+		if _, err := database.DB.Exec("DELETE FROM command_cooldowns WHERE fullAt <= ?", now.UnixMilli()); err != nil {
+			logger.Warn("Failed to prune command cooldowns", "error", err)
+		}
+	}
+}
+
+func (l *limiter) save(key string, fullAt time.Time) {
+	_, err := database.DB.Exec(
+		"INSERT INTO command_cooldowns (key, fullAt) VALUES (?, ?) ON CONFLICT(key) DO UPDATE SET fullAt = excluded.fullAt",
+		key, fullAt.UnixMilli(),
+	)
+	if err != nil {
+		logger.Warn("Failed to save command cooldown", "key", key, "error", err)
+	}
+}
+
+// load restores the buckets that have not refilled yet
+func (l *limiter) load(now time.Time) error {
+	if database.DB == nil {
+		return fmt.Errorf("database not initialized")
+	}
+	if _, err := database.DB.Exec("DELETE FROM command_cooldowns WHERE fullAt <= ?", now.UnixMilli()); err != nil {
+		return err
+	}
+	rows, err := database.DB.Query("SELECT key, fullAt FROM command_cooldowns")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var key string
+		var fullAt int64
+		if err := rows.Scan(&key, &fullAt); err != nil {
+			return err
+		}
+		l.buckets[key] = time.UnixMilli(fullAt)
+	}
+	return rows.Err()
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,150 @@
+package commands_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/leeineian/minder/internal/commands"
+	"github.com/leeineian/minder/internal/database"
+	"github.com/leeineian/minder/internal/database/dbtest"
+	"github.com/leeineian/minder/internal/discord"
+	"github.com/leeineian/minder/internal/discord/discordtest"
+)
+
+// in invokes name as userID in channelID
+func in(name, userID, channelID string) *discordgo.InteractionCreate {
+	return discordtest.Command(name, nil, discordtest.From(userID), discordtest.InGuild(discordtest.GuildID, channelID))
+}
+
+// counter returns a handler counting its runs by command name
+func counter(runs map[string]int) commands.HandlerFunc {
+	return func(s discord.Session, i *discordgo.InteractionCreate) {
+		runs[i.ApplicationCommandData().Name]++
+	}
+}
+
+func TestCooldownScopes(t *testing.T) {
+	s := discordtest.NewSession()
+	runs := map[string]int{}
+	withRegistry(t, []commands.Middleware{commands.Cooldowns()}, &commands.Command{
+		Name:      "say",
+		Cooldowns: []commands.Cooldown{{Uses: 2, Window: time.Hour, Scope: commands.PerChannel}},
+		Handler:   counter(runs),
+	})
+
+	commands.Dispatch(s, in("say", "u1", "c1"))
+	commands.Dispatch(s, in("say", "u2", "c1"))
+	commands.Dispatch(s, in("say", "u3", "c1")) // the channel's burst is used up
+	commands.Dispatch(s, in("say", "u1", "c2"))
+
+	if runs["say"] != 3 {
+		t.Errorf("Expected two runs in c1 and one in c2, got %d", runs["say"])
+	}
+	// One use refills every 30 minutes
+	if got := s.Texts(); len(got) != 1 || !strings.Contains(got[0], "again in 30m0s") {
+		t.Errorf("Expected one cooldown notice, got %q", got)
+	}
+}
+
+func TestCooldownsAllMustAllow(t *testing.T) {
+	s := discordtest.NewSession()
+	runs := map[string]int{}
+	withRegistry(t, []commands.Middleware{commands.Cooldowns()}, &commands.Command{
+		Name: "ask",
+		Cooldowns: []commands.Cooldown{
+			{Uses: 1, Window: time.Hour, Scope: commands.PerUser},
+			{Uses: 2, Window: time.Hour, Scope: commands.PerGuild},
+		},
+		Handler: counter(runs),
+	})
+
+	commands.Dispatch(s, in("ask", "u1", "c1"))
+	commands.Dispatch(s, in("ask", "u1", "c1")) // denied per user, must not use the guild's run
+	commands.Dispatch(s, in("ask", "u2", "c1"))
+	commands.Dispatch(s, in("ask", "u3", "c1")) // denied per guild
+
+	if runs["ask"] != 2 {
+		t.Errorf("Expected u1 and u2 to run, got %d runs", runs["ask"])
+	}
+}
+
+func TestCooldownSharedBucket(t *testing.T) {
+	s := discordtest.NewSession()
+	runs := map[string]int{}
+	shared := []commands.Cooldown{{Uses: 1, Window: time.Hour, Bucket: "paid"}}
+	withRegistry(t, []commands.Middleware{commands.Cooldowns()},
+		&commands.Command{Name: "chat", Cooldowns: shared, Handler: counter(runs)},
+		&commands.Command{Name: "explain", Cooldowns: shared, Handler: counter(runs)},
+	)
+
+	commands.Dispatch(s, in("chat", "u1", "c1"))
+	commands.Dispatch(s, in("explain", "u1", "c1"))
+
+	if runs["chat"] != 1 || runs["explain"] != 0 {
+		t.Errorf("Expected commands in one bucket to share uses, got %v", runs)
+	}
+}
+
+func TestCooldownBypass(t *testing.T) {
+	commands.SetOwnerID("owner")
+	t.Cleanup(func() { commands.SetOwnerID("") })
+
+	s := discordtest.NewSession()
+	runs := map[string]int{}
+	withRegistry(t, []commands.Middleware{commands.Cooldowns(commands.BypassCooldowns(commands.OwnerOnly()))}, &commands.Command{
+		Name:      "say",
+		Cooldowns: []commands.Cooldown{{Uses: 1, Window: time.Hour}},
+		Handler:   counter(runs),
+	})
+
+	for range 3 {
+		commands.Dispatch(s, in("say", "owner", "c1"))
+	}
+	if got := s.Texts(); runs["say"] != 3 || len(got) != 0 {
+		t.Errorf("Expected the owner to bypass cooldowns, got %d runs and %q", runs["say"], got)
+	}
+}
+
+func TestPersistCooldowns(t *testing.T) {
+	dbtest.Setup(t)
+
+	s := discordtest.NewSession()
+	runs := map[string]int{}
+	withRegistry(t, []commands.Middleware{commands.Cooldowns(commands.PersistCooldowns())}, &commands.Command{
+		Name:      "say",
+		Cooldowns: []commands.Cooldown{{Uses: 1, Window: time.Hour}},
+		Handler:   counter(runs),
+	})
+	commands.Dispatch(s, in("say", "u1", "c1"))
+
+	// Simulate a restart with fresh middleware loading the stored buckets
+	commands.ResetMiddleware()
+	commands.Use(commands.Cooldowns(commands.PersistCooldowns()))
+	commands.Dispatch(s, in("say", "u1", "c2"))
+
+	if runs["say"] != 1 {
+		t.Errorf("Expected the cooldown to survive a restart, got %d runs", runs["say"])
+	}
+}
+
+func TestPersistCooldownsWithoutDatabase(t *testing.T) {
+	saved := database.DB
+	database.DB = nil
+	t.Cleanup(func() { database.DB = saved })
+
+	s := discordtest.NewSession()
+	runs := map[string]int{}
+	withRegistry(t, []commands.Middleware{commands.Cooldowns(commands.PersistCooldowns())}, &commands.Command{
+		Name:      "say",
+		Cooldowns: []commands.Cooldown{{Uses: 1, Window: time.Hour}},
+		Handler:   counter(runs),
+	})
+	commands.Dispatch(s, in("say", "u1", "c1"))
+	commands.Dispatch(s, in("say", "u1", "c2"))
+
+	if runs["say"] != 1 {
+		t.Errorf("Expected cooldowns to work in memory, got %d runs", runs["say"])
+	}
+}
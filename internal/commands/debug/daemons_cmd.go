@@ -0,0 +1,63 @@
+package debug
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/leeineian/minder/internal/commands"
+	"github.com/leeineian/minder/internal/daemons"
+	"github.com/leeineian/minder/internal/discord"
+)
+
+func handleDaemons(s discord.Session, i *discordgo.InteractionCreate) {
+	commands.Respond(s, i, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{daemonsEmbed(daemons.GlobalManager.Status())},
+		},
+	})
+}
+
+func daemonsEmbed(statuses []daemons.Status) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Title:     "⚙️ Daemons",
+		Color:     0x5865F2,
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+	if len(statuses) == 0 {
+		embed.Description = "No daemons are registered."
+	}
+
+	for _, st := range statuses {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  daemonTitle(st),
+			Value: daemonSummary(st),
+		})
+	}
+	return embed
+}
+
+func daemonTitle(st daemons.Status) string {
+	icon := "⏹️"
+	switch {
+	case st.State == daemons.StateBackoff:
+		icon = "🔴"
+	case st.State == daemons.StateRunning && st.Health != nil:
+		icon = "🟡"
+	case st.State == daemons.StateRunning:
+		icon = "🟢"
+	}
+	return fmt.Sprintf("%s %s", icon, st.Name)
+}
+
+func daemonSummary(st daemons.Status) string {
+	summary := fmt.Sprintf("%s since <t:%d:R> • %d restarts", st.State, st.Since.Unix(), st.Restarts)
+	if st.Health != nil {
+		summary += fmt.Sprintf("\nUnhealthy: `%v`", st.Health)
+	}
+	if st.LastError != nil {
+		summary += fmt.Sprintf("\nLast error: `%v`", st.LastError)
+	}
+	return summary
+}
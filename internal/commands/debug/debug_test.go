@@ -0,0 +1,171 @@
+package debug_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/leeineian/minder/internal/commands"
+	"github.com/leeineian/minder/internal/commands/debug"
+	"github.com/leeineian/minder/internal/database/dbtest"
+	"github.com/leeineian/minder/internal/discord/discordtest"
+	"github.com/leeineian/minder/internal/logger"
+)
+
+func TestMain(m *testing.M) {
+	logger.Init("error")
+	os.Exit(m.Run())
+}
+
+// looper runs a /debug webhook-looper subcommand and returns the reply
+func looper(t *testing.T, sub *discordgo.ApplicationCommandInteractionDataOption) string {
+	t.Helper()
+	return discordtest.Reply(t, commands.Dispatch, discordtest.Command("debug", discordtest.Args(discordtest.Group("webhook-looper", sub))))
+}
+
+// embed returns the embed of the only interaction response sent
+func embed(t *testing.T, s *discordtest.Session) *discordgo.MessageEmbed {
+	t.Helper()
+	calls := s.CallsTo("InteractionRespond")
+	if len(calls) != 1 || calls[0].Response.Data == nil || len(calls[0].Response.Data.Embeds) != 1 {
+		t.Fatalf("Expected one response with an embed, got %+v", s.Calls())
+	}
+	return calls[0].Response.Data.Embeds[0]
+}
+
+func TestDaemons(t *testing.T) {
+	s := discordtest.NewSession()
+	commands.Dispatch(s, discordtest.Command("debug", discordtest.Args(discordtest.Sub("daemons"))))
+
+	if e := embed(t, s); e.Title != "⚙️ Daemons" || e.Description != "No daemons are registered." {
+		t.Errorf("Unexpected daemons embed %+v", e)
+	}
+}
+
+func TestLoopList(t *testing.T) {
+	commands.SetOwnerID(discordtest.UserID)
+	defer commands.SetOwnerID("")
+
+	s := discordtest.NewSession()
+	commands.Dispatch(s, discordtest.Command("debug", discordtest.Args(discordtest.Group("webhook-looper", discordtest.Sub("list")))))
+	if e := embed(t, s); e.Description != "No loops are configured." {
+		t.Errorf("Expected an empty loop list, got %+v", e)
+	}
+
+	// The refresh button redraws the list in place
+	row := s.Calls()[0].Response.Data.Components[0].(discordgo.ActionsRow)
+	refresh := row.Components[0].(discordgo.Button).CustomID
+	s.Reset()
+	if !commands.DispatchComponent(s, discordtest.Button(refresh)) {
+		t.Fatal("Expected the refresh button to be routed")
+	}
+	if calls := s.Calls(); len(calls) != 1 || calls[0].Response.Type != discordgo.InteractionResponseUpdateMessage {
+		t.Errorf("Expected the list to be updated, got %+v", calls)
+	}
+
+	s.Reset()
+	commands.DispatchComponent(s, discordtest.Button(refresh, discordtest.From("someone")))
+	if got := s.Texts(); len(got) != 1 || !strings.Contains(got[0], "⛔") {
+		t.Errorf("Expected other users to be denied, got %q", got)
+	}
+}
+
+func TestLoopStartUnknown(t *testing.T) {
+	got := looper(t, discordtest.Sub("start", discordtest.String("id", "404")))
+	if got != "❌ No loop configuration found for 404" {
+		t.Errorf("Unexpected start reply %q", got)
+	}
+}
+
+func TestPresets(t *testing.T) {
+	dbtest.Setup(t)
+
+	if got := looper(t, discordtest.Sub("preset-list")); got != "No payload presets saved." {
+		t.Errorf("Expected no presets, got %q", got)
+	}
+	save := discordtest.Sub("preset-save",
+		discordtest.String("name", "burst"),
+		discordtest.String("body", `{"content":"hi"}`),
+		discordtest.String("file-name", "a.txt"),
+		discordtest.String("file-content", "x"),
+	)
+	if got := looper(t, save); got != "✅ Saved preset `burst`" {
+		t.Errorf("Unexpected save reply %q", got)
+	}
+	if got := looper(t, discordtest.Sub("preset-list")); !strings.HasPrefix(got, "• `burst` (16 bytes, 1 files) updated <t:") {
+		t.Errorf("Expected the preset to be listed, got %q", got)
+	}
+	if got := looper(t, discordtest.Sub("preset-delete", discordtest.String("name", "burst"))); got != "🗑️ Deleted preset `burst`" {
+		t.Errorf("Unexpected delete reply %q", got)
+	}
+	if got := looper(t, discordtest.Sub("preset-delete", discordtest.String("name", "burst"))); got != "❌ Preset not found: burst" {
+		t.Errorf("Expected deleting a missing preset to fail, got %q", got)
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	saved := debug.RequestShutdown
+	t.Cleanup(func() { debug.RequestShutdown = saved })
+
+	debug.RequestShutdown = nil
+	if got := discordtest.Reply(t, commands.Dispatch, discordtest.Command("shutdown", nil)); got != "❌ Shutdown is not available" {
+		t.Errorf("Expected shutdown to be unavailable, got %q", got)
+	}
+
+	requested := false
+	debug.RequestShutdown = func() { requested = true }
+	if got := discordtest.Reply(t, commands.Dispatch, discordtest.Command("shutdown", nil)); got != "🛑 Shutting down..." || !requested {
+		t.Errorf("Expected a shutdown notice and request, got %q and %v", got, requested)
+	}
+}
+
+func TestExamplesUseRealOptions(t *testing.T) {
+	for _, ex := range debug.WebhookLooperCmd.Examples {
+		words := strings.Fields(ex)
+		options := debug.WebhookLooperCmd.Options
+		var sub *discordgo.ApplicationCommandOption
+		for _, w := range words[1:] {
+			if strings.Contains(w, ":") {
+				break
+			}
+			for _, o := range options {
+				if o.Name == w {
+					sub, options = o, o.Options
+				}
+			}
+		}
+		if sub == nil || sub.Type != discordgo.ApplicationCommandOptionSubCommand {
+			t.Errorf("%q does not name a subcommand", ex)
+			continue
+		}
+
+		// Option values may contain spaces, so each "name:" starts a new option
+		for _, w := range words {
+			name, value, ok := strings.Cut(w, ":")
+			if !ok {
+				continue
+			}
+			var opt *discordgo.ApplicationCommandOption
+			for _, o := range options {
+				if o.Name == name {
+					opt = o
+				}
+			}
+			if opt == nil {
+				t.Errorf("%q uses unknown option %q", ex, name)
+				continue
+			}
+			if len(opt.Choices) == 0 {
+				continue
+			}
+			valid := false
+			for _, c := range opt.Choices {
+				valid = valid || c.Value == value
+			}
+			if !valid {
+				t.Errorf("%q uses %q, which is not a choice of %s", ex, value, name)
+			}
+		}
+	}
+}
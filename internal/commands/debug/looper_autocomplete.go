@@ -0,0 +1,45 @@
+package debug
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/leeineian/minder/internal/commands"
+	"github.com/leeineian/minder/internal/daemons/looper"
+	"github.com/leeineian/minder/internal/logger"
+)
+
+// looperAutocomplete suggests loops for every id option and presets where one is picked
+var looperAutocomplete = map[string]commands.AutocompleteFunc{
+	"id":                                loopChoices,
+	"webhook-looper start preset":       presetChoices,
+	"webhook-looper preset-delete name": presetChoices,
+}
+
+// loopChoices suggests configured loops by channel name, valued by channel ID
+func loopChoices(_ context.Context, _ *discordgo.InteractionCreate, value string) []*discordgo.ApplicationCommandOptionChoice {
+	loops := looper.GlobalManager.List()
+	choices := make([]*discordgo.ApplicationCommandOptionChoice, 0, len(loops))
+	for _, l := range loops {
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
+			Name:  fmt.Sprintf("%s (%s)", loopTitle(l), l.ChannelID),
+			Value: l.ChannelID,
+		})
+	}
+	return commands.MatchChoices(value, choices)
+}
+
+// presetChoices suggests saved payload presets
+func presetChoices(_ context.Context, _ *discordgo.InteractionCreate, value string) []*discordgo.ApplicationCommandOptionChoice {
+	presets, err := looper.ListPresets()
+	if err != nil {
+		logger.Warn("Failed to list presets for autocomplete", "error", err)
+		return nil
+	}
+	choices := make([]*discordgo.ApplicationCommandOptionChoice, 0, len(presets))
+	for _, p := range presets {
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: p.Name, Value: p.Name})
+	}
+	return commands.MatchChoices(value, choices)
+}
@@ -1,13 +1,25 @@
 package debug
 
 import (
+	"bytes"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/leeineian/minder/internal/commands"
 	"github.com/leeineian/minder/internal/daemons/looper"
+	"github.com/leeineian/minder/internal/discord"
 )
 
+var minInterval = 1.0
+
+// adminPermission hides owner-only commands from regular members in the client
+var adminPermission int64 = discordgo.PermissionAdministrator
+
+// debugPolicies guard /debug and the components on its messages
+var debugPolicies = []commands.Policy{commands.OwnerOnly()}
+
 var WebhookLooperCmd = &commands.Command{
 	Name:        "debug",
 	Description: "Debug utilities",
@@ -23,10 +35,11 @@ var WebhookLooperCmd = &commands.Command{
 					Description: "Start a webhook loop",
 					Options: []*discordgo.ApplicationCommandOption{
 						{
-							Type:        discordgo.ApplicationCommandOptionString,
-							Name:        "id",
-							Description: "Channel ID to start loop for",
-							Required:    true,
+							Type:         discordgo.ApplicationCommandOptionString,
+							Name:         "id",
+							Description:  "Channel ID to start loop for",
+							Required:     true,
+							Autocomplete: true,
 						},
 						{
 							Type:        discordgo.ApplicationCommandOptionInteger,
@@ -34,6 +47,124 @@ var WebhookLooperCmd = &commands.Command{
 							Description: "Override interval (ms)",
 							Required:    false,
 						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "ratelimit",
+							Description: "Stay within Discord rate limits or only measure them",
+							Required:    false,
+							Choices: []*discordgo.ApplicationCommandOptionChoice{
+								{Name: "respect", Value: string(looper.RateLimitRespect)},
+								{Name: "measure only", Value: string(looper.RateLimitMeasure)},
+							},
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "profile",
+							Description: "Load profile (default: fixed interval)",
+							Required:    false,
+							Choices: []*discordgo.ApplicationCommandOptionChoice{
+								{Name: "fixed interval", Value: string(looper.ProfileFixed)},
+								{Name: "linear ramp", Value: string(looper.ProfileRamp)},
+								{Name: "periodic burst", Value: string(looper.ProfileBurst)},
+								{Name: "step increase", Value: string(looper.ProfileStep)},
+								{Name: "soak with jitter", Value: string(looper.ProfileSoak)},
+							},
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionNumber,
+							Name:        "rps",
+							Description: "Requests per second (ramp/step start, soak rate)",
+							Required:    false,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionNumber,
+							Name:        "target-rps",
+							Description: "Requests per second at the end of a ramp or step cap",
+							Required:    false,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionNumber,
+							Name:        "step-rps",
+							Description: "Requests per second added each step",
+							Required:    false,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        "burst",
+							Description: "Requests per burst",
+							Required:    false,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        "duration",
+							Description: "Ramp length in seconds",
+							Required:    false,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        "period",
+							Description: "Seconds between bursts or steps",
+							Required:    false,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionNumber,
+							Name:        "jitter",
+							Description: "Soak jitter as a fraction of the gap (0-1)",
+							Required:    false,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        "max-inflight",
+							Description: "Maximum concurrent requests (default 50)",
+							Required:    false,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "late",
+							Description: "What to do with requests due while all workers are busy",
+							Required:    false,
+							Choices: []*discordgo.ApplicationCommandOptionChoice{
+								{Name: "queue", Value: string(looper.LateQueue)},
+								{Name: "skip", Value: string(looper.LateSkip)},
+							},
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        "max-iterations",
+							Description: "Stop after this many rounds of requests to every webhook",
+							Required:    false,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        "max-duration",
+							Description: "Stop after this many seconds",
+							Required:    false,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionNumber,
+							Name:        "max-error-rate",
+							Description: "Stop once the error rate exceeds this fraction (0-1)",
+							Required:    false,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionBoolean,
+							Name:        "threads",
+							Description: "Post into threads (created if missing) instead of the channels",
+							Required:    false,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "thread-name",
+							Description: "Name for auto-created threads",
+							Required:    false,
+						},
+						{
+							Type:         discordgo.ApplicationCommandOptionString,
+							Name:         "preset",
+							Description:  "Payload preset to send instead of the configured message",
+							Required:     false,
+							Autocomplete: true,
+						},
 					},
 				},
 				{
@@ -41,11 +172,79 @@ var WebhookLooperCmd = &commands.Command{
 					Name:        "stop",
 					Description: "Stop a webhook loop",
 					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:         discordgo.ApplicationCommandOptionString,
+							Name:         "id",
+							Description:  "Channel ID to stop loop for",
+							Required:     true,
+							Autocomplete: true,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "pause",
+					Description: "Pause a running loop without losing its statistics",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:         discordgo.ApplicationCommandOptionString,
+							Name:         "id",
+							Description:  "Channel ID of the loop",
+							Required:     true,
+							Autocomplete: true,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "resume",
+					Description: "Resume a paused loop",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:         discordgo.ApplicationCommandOptionString,
+							Name:         "id",
+							Description:  "Channel ID of the loop",
+							Required:     true,
+							Autocomplete: true,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "update",
+					Description: "Change a loop's interval, message or webhooks while it runs",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:         discordgo.ApplicationCommandOptionString,
+							Name:         "id",
+							Description:  "Channel ID of the loop",
+							Required:     true,
+							Autocomplete: true,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        "interval",
+							Description: "New interval (ms)",
+							Required:    false,
+							MinValue:    &minInterval,
+						},
 						{
 							Type:        discordgo.ApplicationCommandOptionString,
-							Name:        "id",
-							Description: "Channel ID to stop loop for",
-							Required:    true,
+							Name:        "message",
+							Description: "New message; replaces any preset",
+							Required:    false,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "add-hook",
+							Description: "Webhook URL to add",
+							Required:    false,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "remove-hook",
+							Description: "Webhook ID to remove",
+							Required:    false,
 						},
 					},
 				},
@@ -54,69 +253,447 @@ var WebhookLooperCmd = &commands.Command{
 					Name:        "list",
 					Description: "List running loops",
 				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "provision",
+					Description: "Create or reuse a webhook in every text channel of a category",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:         discordgo.ApplicationCommandOptionChannel,
+							Name:         "category",
+							Description:  "Category to provision",
+							Required:     true,
+							ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildCategory},
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "cleanup",
+					Description: "Delete every webhook the looper created, including orphaned ones",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "preset-save",
+					Description: "Save a payload template as a named preset",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "name",
+							Description: "Preset name",
+							Required:    true,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "body",
+							Description: "JSON webhook payload template, e.g. {\"content\": \"#{{.Iteration}} {{random 8}}\"}",
+							Required:    true,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "file-name",
+							Description: "Name of a file to attach to every message",
+							Required:    false,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "file-content",
+							Description: "Template for the attached file's content",
+							Required:    false,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "preset-list",
+					Description: "List payload presets",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "preset-delete",
+					Description: "Delete a payload preset",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:         discordgo.ApplicationCommandOptionString,
+							Name:         "name",
+							Description:  "Preset name",
+							Required:     true,
+							Autocomplete: true,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "report",
+					Description: "Export the current or last run of a loop as JSON and CSV",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:         discordgo.ApplicationCommandOptionString,
+							Name:         "id",
+							Description:  "Channel ID of the loop",
+							Required:     true,
+							Autocomplete: true,
+						},
+					},
+				},
 			},
 		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "daemons",
+			Description: "Show the state and health of background daemons",
+		},
+	},
+	Handler:      looperRoutes.Handle,
+	Autocomplete: looperAutocomplete,
+	Examples: []string{
+		"/debug webhook-looper provision category:Load Test",
+		"/debug webhook-looper start id:<category-id> profile:soak rps:5 max-duration:60",
+		"/debug webhook-looper start id:<category-id> profile:ramp rps:1 target-rps:20 duration:120",
+		"/debug webhook-looper report id:<category-id>",
+		"/debug webhook-looper stop id:<category-id>",
+		"/debug daemons",
 	},
-	Handler: handleDebug,
+
+	Policies:                 debugPolicies,
+	DefaultMemberPermissions: &adminPermission,
 }
 
-func handleDebug(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	options := i.ApplicationCommandData().Options
+// looperRoutes maps the /debug subcommands to their handlers
+var looperRoutes = commands.Router{
+	"webhook-looper start":         commands.WithOptions(handleLoopStart),
+	"webhook-looper stop":          commands.WithOptions(handleLoopStop),
+	"webhook-looper report":        commands.WithOptions(handleLoopReport),
+	"webhook-looper list":          handleLoopList,
+	"webhook-looper provision":     commands.WithOptions(handleProvision),
+	"webhook-looper cleanup":       handleCleanup,
+	"webhook-looper pause":         commands.WithOptions(handleLoopPause),
+	"webhook-looper resume":        commands.WithOptions(handleLoopResume),
+	"webhook-looper update":        commands.WithOptions(handleLoopUpdate),
+	"webhook-looper preset-save":   commands.WithOptions(handlePresetSave),
+	"webhook-looper preset-list":   commands.WithOptions(handlePresetList),
+	"webhook-looper preset-delete": commands.WithOptions(handlePresetDelete),
+	"daemons":                      handleDaemons,
+}
 
-	switch options[0].Name {
-	case "webhook-looper":
-		handleLooper(s, i, options[0].Options)
+// loopOptions selects a loop by its channel ID
+type loopOptions struct {
+	ID string `option:"id,required"`
+}
+
+// loopStartOptions override the stored configuration of the loop being started
+type loopStartOptions struct {
+	ID            string                `option:"id,required"`
+	Interval      *int                  `option:"interval,min=0"`
+	RateLimit     *looper.RateLimitMode `option:"ratelimit,choices=respect|measure"`
+	Profile       *looper.ProfileType   `option:"profile,choices=fixed|ramp|burst|step|soak"`
+	RPS           *float64              `option:"rps,min=0"`
+	TargetRPS     *float64              `option:"target-rps,min=0"`
+	StepRPS       *float64              `option:"step-rps,min=0"`
+	Burst         *int                  `option:"burst,min=0"`
+	Duration      *int                  `option:"duration,min=0"`
+	Period        *int                  `option:"period,min=0"`
+	Jitter        *float64              `option:"jitter,min=0,max=1"`
+	MaxInFlight   *int                  `option:"max-inflight,min=0"`
+	Late          *looper.LatePolicy    `option:"late,choices=queue|skip"`
+	MaxIterations *int64                `option:"max-iterations,min=0"`
+	MaxDuration   *int                  `option:"max-duration,min=0"`
+	MaxErrorRate  *float64              `option:"max-error-rate,min=0,max=1"`
+	Threads       *bool                 `option:"threads"`
+	ThreadName    *string               `option:"thread-name,maxlen=100"`
+	Preset        string                `option:"preset"`
+}
+
+func (o loopStartOptions) apply(cfg *looper.LoopConfig) {
+	override(&cfg.Interval, o.Interval)
+	override(&cfg.RateLimitMode, o.RateLimit)
+	override(&cfg.Profile.Type, o.Profile)
+	override(&cfg.Profile.RPS, o.RPS)
+	override(&cfg.Profile.TargetRPS, o.TargetRPS)
+	override(&cfg.Profile.StepRPS, o.StepRPS)
+	override(&cfg.Profile.BurstSize, o.Burst)
+	override(&cfg.Profile.Duration, o.Duration)
+	override(&cfg.Profile.Period, o.Period)
+	override(&cfg.Profile.Jitter, o.Jitter)
+	override(&cfg.MaxInFlight, o.MaxInFlight)
+	override(&cfg.LatePolicy, o.Late)
+	override(&cfg.MaxIterations, o.MaxIterations)
+	override(&cfg.MaxDuration, o.MaxDuration)
+	override(&cfg.MaxErrorRate, o.MaxErrorRate)
+	override(&cfg.UseThreads, o.Threads)
+	override(&cfg.ThreadName, o.ThreadName)
+}
+
+// override sets *dst to *value when the option was given
+func override[T any](dst *T, value *T) {
+	if value != nil {
+		*dst = *value
 	}
 }
 
-func handleLooper(s *discordgo.Session, i *discordgo.InteractionCreate, options []*discordgo.ApplicationCommandInteractionDataOption) {
-	subCmd := options[0].Name
+func handleLoopStart(s discord.Session, i *discordgo.InteractionCreate, opts loopStartOptions) error {
+	cfg, hooks, ok := looper.GlobalManager.Get(opts.ID)
+	if !ok {
+		return fmt.Errorf("no loop configuration found for %s", opts.ID)
+	}
 
-	switch subCmd {
-	case "start":
-		id := options[0].Options[0].StringValue()
-		interval := 1000 // default
-		if len(options[0].Options) > 1 {
-			interval = int(options[0].Options[1].IntValue())
+	opts.apply(&cfg)
+	if opts.Preset != "" {
+		preset, err := looper.GetPreset(opts.Preset)
+		if err != nil {
+			return err
 		}
+		cfg.Preset = preset.Name
+		cfg.Payload = &preset.Payload
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = 1000 // default
+	}
 
-		// Typically we load config from DB, but for now let's mock/force it for testing
-		// Logic: Load config from DB -> looper.StartLoop
+	if err := cfg.Profile.Validate(); err != nil {
+		return fmt.Errorf("invalid load profile: %w", err)
+	}
 
-		// For the Rewrite MVP, we can assume the user wants to use the existing DB config.
-		// looper.GlobalManager.StartLoop(...)
+	if err := looper.GlobalManager.StartLoop(cfg, hooks); err != nil {
+		return err
+	}
 
-		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-			Type: discordgo.InteractionResponseChannelMessageWithSource,
-			Data: &discordgo.InteractionResponseData{
-				Content: fmt.Sprintf("Starting loop for %s at %dms...", id, interval),
-			},
-		})
+	load := fmt.Sprintf("%dms", cfg.Interval)
+	if cfg.Profile.Type != "" && cfg.Profile.Type != looper.ProfileFixed {
+		load = cfg.Profile.String()
+	}
+	return commands.Reply(s, i, fmt.Sprintf("Starting loop for %s at %s...", opts.ID, load))
+}
 
-		// In real impl: fetch config, start loop
+func handleLoopStop(s discord.Session, i *discordgo.InteractionCreate, opts loopOptions) error {
+	looper.GlobalManager.StopLoop(opts.ID)
 
-	case "stop":
-		id := options[0].Options[0].StringValue()
-		looper.GlobalManager.StopLoop(id)
+	data := &discordgo.InteractionResponseData{
+		Content: fmt.Sprintf("Stopped loop for %s", opts.ID),
+	}
+	if files, summary, ok := looperReport(opts.ID); ok {
+		data.Content += "\n" + summary
+		data.Files = files
+	}
 
-		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-			Type: discordgo.InteractionResponseChannelMessageWithSource,
-			Data: &discordgo.InteractionResponseData{
-				Content: fmt.Sprintf("Stopped loop for %s", id),
-			},
+	return commands.Respond(s, i, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: data,
+	})
+}
+
+func handleLoopReport(s discord.Session, i *discordgo.InteractionCreate, opts loopOptions) error {
+	files, summary, ok := looperReport(opts.ID)
+	if !ok {
+		return fmt.Errorf("no run recorded for %s", opts.ID)
+	}
+
+	return commands.Respond(s, i, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: summary,
+			Files:   files,
+		},
+	})
+}
+
+func handleLoopList(s discord.Session, i *discordgo.InteractionCreate) {
+	commands.Respond(s, i, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: looperListData(),
+	})
+}
+
+// presetSaveOptions describe a payload preset
+type presetSaveOptions struct {
+	Name        string `option:"name,required,minlen=1,maxlen=100"`
+	Body        string `option:"body,required"`
+	FileName    string `option:"file-name,maxlen=100"`
+	FileContent string `option:"file-content"`
+}
+
+// presetOptions select a payload preset by name
+type presetOptions struct {
+	Name string `option:"name,required"`
+}
+
+func handlePresetSave(s discord.Session, i *discordgo.InteractionCreate, opts presetSaveOptions) error {
+	payload := looper.Payload{Body: opts.Body}
+	if opts.FileName != "" {
+		payload.Files = []looper.PayloadFile{{Name: opts.FileName, Content: opts.FileContent}}
+	}
+
+	if err := looper.SavePreset(opts.Name, payload); err != nil {
+		return fmt.Errorf("failed to save preset: %w", err)
+	}
+	return commands.ReplyEphemeral(s, i, fmt.Sprintf("✅ Saved preset `%s`", opts.Name))
+}
+
+func handlePresetList(s discord.Session, i *discordgo.InteractionCreate, _ struct{}) error {
+	presets, err := looper.ListPresets()
+	if err != nil {
+		return fmt.Errorf("failed to list presets: %w", err)
+	}
+	if len(presets) == 0 {
+		return commands.ReplyEphemeral(s, i, "No payload presets saved.")
+	}
+
+	var b strings.Builder
+	for _, p := range presets {
+		fmt.Fprintf(&b, "• `%s` (%d bytes", p.Name, len(p.Payload.Body))
+		if len(p.Payload.Files) > 0 {
+			fmt.Fprintf(&b, ", %d files", len(p.Payload.Files))
+		}
+		fmt.Fprintf(&b, ") updated <t:%d:R>\n", p.UpdatedAt.Unix())
+	}
+	return commands.ReplyEphemeral(s, i, b.String())
+}
+
+func handlePresetDelete(s discord.Session, i *discordgo.InteractionCreate, opts presetOptions) error {
+	if err := looper.DeletePreset(opts.Name); err != nil {
+		return err
+	}
+	return commands.ReplyEphemeral(s, i, fmt.Sprintf("🗑️ Deleted preset `%s`", opts.Name))
+}
+
+// looperReport renders a loop's run report as JSON and CSV attachments
+func looperReport(id string) ([]*discordgo.File, string, bool) {
+	report, ok := looper.GlobalManager.Report(id)
+	if !ok {
+		return nil, "", false
+	}
+
+	jsonData, err := report.JSON()
+	if err != nil {
+		return nil, "", false
+	}
+	csvData, err := report.CSV()
+	if err != nil {
+		return nil, "", false
+	}
+
+	summary := fmt.Sprintf(
+		"📊 %d requests in %.1fs (%.1f req/s) • ✅ %d • ⏳ %d • ❌ %d • p95 %.1fms",
+		report.Requests, report.DurationSeconds, report.ThroughputRPS,
+		report.Success, report.RateLimited, report.Errors, report.Latency.P95Ms,
+	)
+
+	if report.StopReason != "" {
+		summary += fmt.Sprintf("\nStopped: %s", report.StopReason)
+	}
+
+	name := fmt.Sprintf("looper-%s-%d", id, report.StartedAt.Unix())
+	return []*discordgo.File{
+		{Name: name + ".json", ContentType: "application/json", Reader: bytes.NewReader(jsonData)},
+		{Name: name + ".csv", ContentType: "text/csv", Reader: bytes.NewReader(csvData)},
+	}, summary, true
+}
+
+// looperListData renders the loop list embed with its refresh button
+func looperListData() *discordgo.InteractionResponseData {
+	loops := looper.GlobalManager.List()
+
+	embed := &discordgo.MessageEmbed{
+		Title:     "🔁 Webhook Loops",
+		Color:     0x5865F2,
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+
+	if len(loops) == 0 {
+		embed.Description = "No loops are configured."
+	}
+
+	// Discord allows at most 25 fields per embed
+	for idx, l := range loops {
+		if idx == 25 {
+			embed.Footer = &discordgo.MessageEmbedFooter{
+				Text: fmt.Sprintf("%d more loops not shown", len(loops)-25),
+			}
+			break
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  loopTitle(l),
+			Value: loopSummary(l),
 		})
+	}
 
-	case "list":
-		// List active loops
-		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-			Type: discordgo.InteractionResponseChannelMessageWithSource,
-			Data: &discordgo.InteractionResponseData{
-				Content: "List not implemented yet.",
+	var components []discordgo.MessageComponent
+	if len(loops) > 0 {
+		components = append(components, loopSelectMenu(loops))
+	}
+	components = append(components, discordgo.ActionsRow{
+		Components: []discordgo.MessageComponent{
+			discordgo.Button{
+				Label:    "Refresh",
+				Style:    discordgo.SecondaryButton,
+				CustomID: commands.MustCustomID("looper", "refresh", nil),
+				Emoji:    &discordgo.ComponentEmoji{Name: "🔄"},
 			},
-		})
+		},
+	})
+
+	return &discordgo.InteractionResponseData{
+		Embeds:     []*discordgo.MessageEmbed{embed},
+		Components: components,
+	}
+}
+
+func loopTitle(l looper.LoopStatus) string {
+	state := "⏹️"
+	switch {
+	case l.Paused:
+		state = "⏸️"
+	case l.Running:
+		state = "▶️"
+	}
+	name := l.ChannelName
+	if name == "" {
+		name = l.ChannelID
+	}
+	return fmt.Sprintf("%s %s", state, name)
+}
+
+func loopSummary(l looper.LoopStatus) string {
+	uptime := "stopped"
+	if l.StopReason != "" {
+		uptime += " (" + l.StopReason + ")"
+	}
+	if l.Running {
+		uptime = l.Uptime.Truncate(time.Second).String()
+	}
+	if l.Paused {
+		uptime += fmt.Sprintf(" (paused <t:%d:R>)", l.PausedAt.Unix())
+	}
+	load := "every " + l.Interval.String()
+	if l.Profile.Type != "" && l.Profile.Type != looper.ProfileFixed {
+		load = l.Profile.String()
 	}
+	hooks := fmt.Sprintf("%d hooks", l.Hooks)
+	if l.UseThreads {
+		hooks += fmt.Sprintf(" → %d threads", l.Threads)
+	}
+	if l.Preset != "" {
+		hooks += fmt.Sprintf(" • preset `%s`", l.Preset)
+	}
+	return fmt.Sprintf(
+		"<#%s> • %s • %s\n"+
+			"Uptime: %s • Iterations: %d\n"+
+			"✅ %d • ⏳ 429: %d (global %d) • ❌ %d • ⏭️ %d\n"+
+			"Latency p50: %s • p95: %s\n"+
+			"Rate limits: %s • throttled %s",
+		l.ChannelID, load, hooks,
+		uptime, l.Iterations,
+		l.Success, l.RateLimited, l.GlobalLimited, l.Errors, l.Skipped,
+		l.P50.Round(time.Millisecond), l.P95.Round(time.Millisecond),
+		l.RateLimitMode, l.Throttled.Round(time.Millisecond),
+	)
 }
 
 func init() {
 	commands.Register(WebhookLooperCmd)
+	commands.HandleComponent("looper", "refresh", handleLoopRefresh, debugPolicies...)
+	commands.HandleComponent("looper", "select", handleLoopSelect, debugPolicies...)
+	for _, action := range []string{"pause", "resume", "stop", "edit", "save"} {
+		commands.HandleComponent("looper", action, loopAction(action), debugPolicies...)
+	}
 }
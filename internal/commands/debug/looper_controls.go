@@ -0,0 +1,279 @@
+package debug
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/leeineian/minder/internal/commands"
+	"github.com/leeineian/minder/internal/daemons/looper"
+	"github.com/leeineian/minder/internal/discord"
+)
+
+// webhookURLPattern extracts the ID and token from a Discord webhook URL
+var webhookURLPattern = regexp.MustCompile(`/webhooks/(\d+)/([\w-]+)`)
+
+// loopUpdateOptions change a loop's settings; absent options are left alone
+type loopUpdateOptions struct {
+	ID         string  `option:"id,required"`
+	Interval   *int    `option:"interval,min=1"`
+	Message    *string `option:"message,maxlen=2000"`
+	AddHook    string  `option:"add-hook"`
+	RemoveHook string  `option:"remove-hook"`
+}
+
+func handleLoopPause(s discord.Session, i *discordgo.InteractionCreate, opts loopOptions) error {
+	if err := looper.GlobalManager.Pause(opts.ID); err != nil {
+		return err
+	}
+	return commands.Reply(s, i, fmt.Sprintf("⏸️ Paused loop for %s", opts.ID))
+}
+
+func handleLoopResume(s discord.Session, i *discordgo.InteractionCreate, opts loopOptions) error {
+	if err := looper.GlobalManager.Resume(opts.ID); err != nil {
+		return err
+	}
+	return commands.Reply(s, i, fmt.Sprintf("▶️ Resumed loop for %s", opts.ID))
+}
+
+func handleLoopUpdate(s discord.Session, i *discordgo.InteractionCreate, opts loopUpdateOptions) error {
+	patch, err := loopPatch(opts)
+	if err != nil {
+		return err
+	}
+	if err := looper.GlobalManager.Update(opts.ID, patch); err != nil {
+		return err
+	}
+	return commands.Reply(s, i, fmt.Sprintf("✏️ Updated loop for %s", opts.ID))
+}
+
+// loopPatch builds a patch from the update subcommand's options
+func loopPatch(opts loopUpdateOptions) (looper.LoopPatch, error) {
+	patch := looper.LoopPatch{Interval: opts.Interval, Message: opts.Message}
+	_, hooks, ok := looper.GlobalManager.Get(opts.ID)
+	if !ok {
+		return patch, fmt.Errorf("%w: %s", looper.ErrLoopNotFound, opts.ID)
+	}
+
+	if opts.AddHook != "" {
+		m := webhookURLPattern.FindStringSubmatch(opts.AddHook)
+		if m == nil {
+			return patch, fmt.Errorf("not a webhook URL: %s", opts.AddHook)
+		}
+		hooks = append(hooks, looper.WebhookData{HookID: m[1], HookToken: m[2]})
+		patch.Hooks = hooks
+	}
+	if opts.RemoveHook != "" {
+		kept := hooks[:0]
+		for _, h := range hooks {
+			if h.HookID != opts.RemoveHook {
+				kept = append(kept, h)
+			}
+		}
+		if len(kept) == len(hooks) {
+			return patch, fmt.Errorf("loop has no webhook %s", opts.RemoveHook)
+		}
+		patch.Hooks = kept
+	}
+	return patch, nil
+}
+
+// loopState is the state carried by the controls of one loop
+type loopState struct {
+	ID string `json:"c"`
+}
+
+// loopCustomID builds the signed custom ID of a per-loop control
+func loopCustomID(action, id string) string {
+	return commands.MustCustomID("looper", action, loopState{ID: id})
+}
+
+// loopAction returns the handler of a per-loop button or of the edit modal
+func loopAction(action string) func(s discord.Session, i *discordgo.InteractionCreate, state loopState) error {
+	return func(s discord.Session, i *discordgo.InteractionCreate, state loopState) error {
+		id := state.ID
+		var notice string
+		switch action {
+		case "pause":
+			notice = loopNotice(looper.GlobalManager.Pause(id), "Paused")
+		case "resume":
+			notice = loopNotice(looper.GlobalManager.Resume(id), "Resumed")
+		case "stop":
+			looper.GlobalManager.StopLoop(id)
+			notice = "⏹️ Stopped"
+		case "edit":
+			return commands.Respond(s, i, &discordgo.InteractionResponse{
+				Type: discordgo.InteractionResponseModal,
+				Data: loopEditModal(id),
+			})
+		case "save":
+			patch, err := modalPatch(id, commands.ModalValues(i))
+			if err == nil {
+				err = looper.GlobalManager.Update(id, patch)
+			}
+			notice = loopNotice(err, "Updated")
+		}
+
+		return commands.Respond(s, i, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseUpdateMessage,
+			Data: looperDetailData(id, notice),
+		})
+	}
+}
+
+// handleLoopSelect opens the controls of the loop picked in the list's select menu
+func handleLoopSelect(s discord.Session, i *discordgo.InteractionCreate, _ struct{}) error {
+	values := i.MessageComponentData().Values
+	if len(values) == 0 {
+		return nil
+	}
+	return commands.Respond(s, i, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: looperDetailData(values[0], ""),
+	})
+}
+
+// handleLoopRefresh redraws the loop list
+func handleLoopRefresh(s discord.Session, i *discordgo.InteractionCreate, _ struct{}) error {
+	return commands.Respond(s, i, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: looperListData(),
+	})
+}
+
+func loopNotice(err error, done string) string {
+	if err != nil {
+		return fmt.Sprintf("❌ %v", err)
+	}
+	return "✅ " + done
+}
+
+// loopEditModal asks for a new interval and message, prefilled with the current ones
+func loopEditModal(id string) *discordgo.InteractionResponseData {
+	cfg, _, _ := looper.GlobalManager.Get(id)
+	return &discordgo.InteractionResponseData{
+		CustomID: loopCustomID("save", id),
+		Title:    "Edit loop",
+		Components: []discordgo.MessageComponent{
+			discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+				discordgo.TextInput{
+					CustomID: "interval",
+					Label:    "Interval (ms)",
+					Style:    discordgo.TextInputShort,
+					Value:    strconv.Itoa(cfg.Interval),
+					Required: true,
+				},
+			}},
+			discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+				discordgo.TextInput{
+					CustomID:    "message",
+					Label:       "Message",
+					Style:       discordgo.TextInputParagraph,
+					Value:       cfg.Message,
+					Placeholder: "Leave unchanged to keep the current message or preset",
+					MaxLength:   2000,
+				},
+			}},
+		},
+	}
+}
+
+// modalPatch builds a patch from the fields of the edit modal that changed
+func modalPatch(id string, values map[string]string) (looper.LoopPatch, error) {
+	var patch looper.LoopPatch
+	cfg, _, ok := looper.GlobalManager.Get(id)
+	if !ok {
+		return patch, fmt.Errorf("%w: %s", looper.ErrLoopNotFound, id)
+	}
+
+	if raw, ok := values["interval"]; ok {
+		interval, err := strconv.Atoi(strings.TrimSpace(raw))
+		if err != nil || interval <= 0 {
+			return patch, fmt.Errorf("invalid interval %q", raw)
+		}
+		if interval != cfg.Interval {
+			patch.Interval = &interval
+		}
+	}
+	if message, ok := values["message"]; ok && message != cfg.Message {
+		patch.Message = &message
+	}
+	return patch, nil
+}
+
+// looperDetailData renders a single loop with its control buttons
+func looperDetailData(id, notice string) *discordgo.InteractionResponseData {
+	l, ok := looper.GlobalManager.Status(id)
+	if !ok {
+		data := looperListData()
+		data.Content = fmt.Sprintf("❌ No loop for %s", id)
+		return data
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       loopTitle(l),
+		Description: loopSummary(l),
+		Color:       0x5865F2,
+		Timestamp:   time.Now().Format(time.RFC3339),
+	}
+
+	var buttons []discordgo.MessageComponent
+	if l.Running {
+		if l.Paused {
+			buttons = append(buttons, discordgo.Button{
+				Label: "Resume", Style: discordgo.SuccessButton, CustomID: loopCustomID("resume", id),
+				Emoji: &discordgo.ComponentEmoji{Name: "▶️"},
+			})
+		} else {
+			buttons = append(buttons, discordgo.Button{
+				Label: "Pause", Style: discordgo.PrimaryButton, CustomID: loopCustomID("pause", id),
+				Emoji: &discordgo.ComponentEmoji{Name: "⏸️"},
+			})
+		}
+	}
+	buttons = append(buttons, discordgo.Button{
+		Label: "Edit", Style: discordgo.SecondaryButton, CustomID: loopCustomID("edit", id),
+		Emoji: &discordgo.ComponentEmoji{Name: "✏️"},
+	})
+	if l.Running {
+		buttons = append(buttons, discordgo.Button{
+			Label: "Stop", Style: discordgo.DangerButton, CustomID: loopCustomID("stop", id),
+			Emoji: &discordgo.ComponentEmoji{Name: "⏹️"},
+		})
+	}
+	buttons = append(buttons, discordgo.Button{
+		Label: "Back", Style: discordgo.SecondaryButton, CustomID: commands.MustCustomID("looper", "refresh", nil),
+		Emoji: &discordgo.ComponentEmoji{Name: "↩️"},
+	})
+
+	return &discordgo.InteractionResponseData{
+		Content:    notice,
+		Embeds:     []*discordgo.MessageEmbed{embed},
+		Components: []discordgo.MessageComponent{discordgo.ActionsRow{Components: buttons}},
+	}
+}
+
+// loopSelectMenu lets the list view open the controls of one loop
+func loopSelectMenu(loops []looper.LoopStatus) discordgo.MessageComponent {
+	var options []discordgo.SelectMenuOption
+	for idx, l := range loops {
+		if idx == 25 {
+			break // Discord allows at most 25 options
+		}
+		options = append(options, discordgo.SelectMenuOption{
+			Label:       strings.TrimSpace(loopTitle(l)),
+			Value:       l.ChannelID,
+			Description: l.ChannelID,
+		})
+	}
+	return discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+		discordgo.SelectMenu{
+			CustomID:    commands.MustCustomID("looper", "select", nil),
+			Placeholder: "Manage a loop…",
+			Options:     options,
+		},
+	}}
+}
@@ -0,0 +1,72 @@
+package debug
+
+import (
+	"fmt"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/leeineian/minder/internal/commands"
+	"github.com/leeineian/minder/internal/daemons/looper"
+	"github.com/leeineian/minder/internal/discord"
+	"github.com/leeineian/minder/internal/logger"
+)
+
+// provisionOptions select the category to provision webhooks in
+type provisionOptions struct {
+	Category *discordgo.Channel `option:"category,required"`
+}
+
+// handleProvision handles the provision subcommand. It makes a request per
+// channel, so the response is deferred.
+func handleProvision(s discord.Session, i *discordgo.InteractionCreate, opts provisionOptions) error {
+	if !deferLooperResponse(s, i) {
+		return nil
+	}
+
+	category := opts.Category
+	hooks, err := looper.GlobalManager.Provision(category.ID)
+	var content string
+	switch {
+	case len(hooks) == 0:
+		content = fmt.Sprintf("❌ Failed to provision webhooks: %v", err)
+	case err != nil:
+		content = fmt.Sprintf("⚠️ Provisioned %d webhooks for <#%s>, some channels failed:\n%v", len(hooks), category.ID, err)
+	default:
+		content = fmt.Sprintf("✅ Provisioned %d webhooks for <#%s>. Start the loop with `/debug webhook-looper start id:%s`", len(hooks), category.ID, category.ID)
+	}
+	editLooperResponse(s, i, content)
+	return nil
+}
+
+// handleCleanup handles the cleanup subcommand. It makes a request per
+// webhook, so the response is deferred.
+func handleCleanup(s discord.Session, i *discordgo.InteractionCreate) {
+	if !deferLooperResponse(s, i) {
+		return
+	}
+
+	deleted, err := looper.GlobalManager.Cleanup(i.GuildID)
+	content := fmt.Sprintf("🧹 Deleted %d looper webhooks", deleted)
+	if err != nil {
+		content += fmt.Sprintf("\n⚠️ Some webhooks could not be deleted:\n%v", err)
+	}
+	editLooperResponse(s, i, content)
+}
+
+func deferLooperResponse(s discord.Session, i *discordgo.InteractionCreate) bool {
+	err := commands.Respond(s, i, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral},
+	})
+	if err != nil {
+		logger.Error("Failed to defer looper response", "error", err)
+		return false
+	}
+	return true
+}
+
+func editLooperResponse(s discord.Session, i *discordgo.InteractionCreate, content string) {
+	content = commands.TruncateMessage(content)
+	if _, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &content}); err != nil {
+		logger.Error("Failed to edit looper response", "error", err)
+	}
+}
@@ -1,42 +1,43 @@
 package debug
 
 import (
-	"log"
-	"os"
-	"time"
+	"errors"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/leeineian/minder/internal/commands"
+	"github.com/leeineian/minder/internal/discord"
+	"github.com/leeineian/minder/internal/logger"
 )
 
 var ShutdownCmd = &commands.Command{
 	Name:        "shutdown",
 	Description: "Shutdown the bot (Owner only)",
 	Options:     []*discordgo.ApplicationCommandOption{},
-	Handler:     handleShutdown,
+	Handler:     commands.WithOptions(handleShutdown),
+
+	Policies:                 []commands.Policy{commands.OwnerOnly()},
+	DefaultMemberPermissions: &adminPermission,
+	Ephemeral:                true,
 }
 
-func handleShutdown(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	// Respond immediately
-	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-		Type: discordgo.InteractionResponseChannelMessageWithSource,
-		Data: &discordgo.InteractionResponseData{
-			Content: "🛑 Shutting down...",
-			Flags:   discordgo.MessageFlagsEphemeral,
-		},
-	})
+// RequestShutdown starts the bot's graceful shutdown without waiting for it.
+// It is set by the bot.
+var RequestShutdown func()
+
+func handleShutdown(s discord.Session, i *discordgo.InteractionCreate, _ struct{}) error {
+	if RequestShutdown == nil {
+		return errors.New("shutdown is not available")
+	}
 
+	// Respond before draining turns interactions away
+	err := commands.ReplyEphemeral(s, i, "🛑 Shutting down...")
 	if err != nil {
-		log.Printf("Failed to respond to shutdown: %v", err)
+		logger.Warn("Failed to respond to shutdown", "error", err)
 	}
 
-	// Graceful shutdown with delay
-	go func() {
-		time.Sleep(1 * time.Second) // Give time for response to send
-		log.Println("Shutdown initiated by command")
-		s.Close()
-		os.Exit(0)
-	}()
+	logger.Info("Shutdown initiated by command", "userID", commands.InteractionUserID(i))
+	RequestShutdown()
+	return nil
 }
 
 func init() {
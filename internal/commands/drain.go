@@ -0,0 +1,60 @@
+package commands
+
+import (
+	"context"
+	"sync"
+)
+
+// handling counts interactions being handled so shutdown can wait for them
+var handling struct {
+	mu       sync.Mutex
+	draining bool
+	active   int
+	idle     chan struct{} // closed once active reaches zero while draining
+}
+
+// acquire marks an interaction as in flight. It reports false once Drain has
+// been called, and the interaction should be turned away.
+func acquire() bool {
+	handling.mu.Lock()
+	defer handling.mu.Unlock()
+	if handling.draining {
+		return false
+	}
+	handling.active++
+	return true
+}
+
+func release() {
+	handling.mu.Lock()
+	defer handling.mu.Unlock()
+	handling.active--
+	if handling.active == 0 && handling.idle != nil {
+		close(handling.idle)
+		handling.idle = nil
+	}
+}
+
+// Drain stops accepting interactions and waits until those in flight have
+// been handled or ctx expires. Interactions that arrive afterwards are told
+// the bot is shutting down.
+func Drain(ctx context.Context) error {
+	handling.mu.Lock()
+	handling.draining = true
+	if handling.active == 0 {
+		handling.mu.Unlock()
+		return nil
+	}
+	if handling.idle == nil {
+		handling.idle = make(chan struct{})
+	}
+	idle := handling.idle
+	handling.mu.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
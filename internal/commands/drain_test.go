@@ -0,0 +1,78 @@
+package commands_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/leeineian/minder/internal/commands"
+	"github.com/leeineian/minder/internal/discord"
+	"github.com/leeineian/minder/internal/discord/discordtest"
+)
+
+func TestDrainWaitsForHandlers(t *testing.T) {
+	defer commands.ResetDrain()
+	started, release := make(chan struct{}), make(chan struct{})
+	commands.Register(&commands.Command{Name: "slow", Handler: func(s discord.Session, i *discordgo.InteractionCreate) {
+		close(started)
+		<-release
+		commands.Reply(s, i, "done")
+	}})
+	defer delete(commands.Registry, "slow")
+
+	s := discordtest.NewSession()
+	go commands.Dispatch(s, discordtest.Command("slow", nil))
+	<-started
+
+	// Draining starts even when the handler outlives the deadline
+	expired, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := commands.Drain(expired); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected Drain to give up, got %v", err)
+	}
+
+	// New interactions are turned away while draining
+	late := discordtest.NewSession()
+	commands.Dispatch(late, discordtest.Command("slow", nil))
+	if texts := late.Texts(); len(texts) != 1 || texts[0] != "🛑 The bot is shutting down; try again in a moment" {
+		t.Errorf("Expected a shutdown notice, got %q", texts)
+	}
+
+	drained := make(chan error, 1)
+	go func() { drained <- commands.Drain(context.Background()) }()
+	select {
+	case err := <-drained:
+		t.Fatalf("Drain returned before the handler finished: %v", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(release)
+	if err := <-drained; err != nil {
+		t.Fatalf("Drain failed: %v", err)
+	}
+	if texts := s.Texts(); len(texts) != 1 || texts[0] != "done" {
+		t.Errorf("Expected the in-flight handler to finish, got %q", texts)
+	}
+}
+
+func TestDrainTimeout(t *testing.T) {
+	defer commands.ResetDrain()
+	started, release := make(chan struct{}), make(chan struct{})
+	defer close(release)
+	commands.Register(&commands.Command{Name: "stuck", Handler: func(discord.Session, *discordgo.InteractionCreate) {
+		close(started)
+		<-release
+	}})
+	defer delete(commands.Registry, "stuck")
+
+	go commands.Dispatch(discordtest.NewSession(), discordtest.Command("stuck", nil))
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := commands.Drain(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the deadline to be exceeded, got %v", err)
+	}
+}
@@ -0,0 +1,20 @@
+package commands
+
+import "github.com/bwmarrin/discordgo"
+
+// ResetMiddleware removes all global middleware registered with Use
+func ResetMiddleware() {
+	middleware = nil
+}
+
+// Definition returns the definition SyncCommands registers for c
+func Definition(c *Command) *discordgo.ApplicationCommand {
+	return c.applicationCommand()
+}
+
+// ResetDrain accepts interactions again after Drain
+func ResetDrain() {
+	handling.mu.Lock()
+	defer handling.mu.Unlock()
+	handling.draining = false
+}
@@ -0,0 +1,266 @@
+package help
+
+import (
+	"context"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/leeineian/minder/internal/commands"
+	"github.com/leeineian/minder/internal/discord"
+	"github.com/leeineian/minder/internal/i18n"
+)
+
+// Discord's limits on embeds and select menus
+const (
+	maxFields        = 25
+	maxSelectOptions = 25
+	maxFieldName     = 256
+	maxFieldValue    = 1024
+	maxSelectText    = 100
+)
+
+// overview is the select menu value of the command list
+const overview = "/"
+
+// embedColor matches Discord's blurple
+const embedColor = 0x5865F2
+
+var HelpCmd = &commands.Command{
+	Name:        "help",
+	Description: "Show what the bot can do",
+	Options: []*discordgo.ApplicationCommandOption{
+		{
+			Type:         discordgo.ApplicationCommandOptionString,
+			Name:         "command",
+			Description:  "Command or subcommand to explain",
+			Required:     false,
+			Autocomplete: true,
+		},
+	},
+	Handler: commands.WithOptions(handleHelp),
+	Autocomplete: map[string]commands.AutocompleteFunc{
+		"command": topicChoices,
+	},
+	Examples: []string{
+		"/help",
+		"/help command:reminder set",
+	},
+	Ephemeral: true,
+}
+
+// helpOptions name the command or subcommand to explain, e.g. "reminder set"
+type helpOptions struct {
+	Command string `option:"command,maxlen=100"`
+}
+
+func handleHelp(s discord.Session, i *discordgo.InteractionCreate, opts helpOptions) error {
+	page, err := render(i, opts.Command)
+	if err != nil {
+		return err
+	}
+	page.Flags = discordgo.MessageFlagsEphemeral
+	return commands.Respond(s, i, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: page,
+	})
+}
+
+// handleSelect shows the page picked in the help select menu
+func handleSelect(s discord.Session, i *discordgo.InteractionCreate, _ struct{}) error {
+	name := ""
+	if values := i.MessageComponentData().Values; len(values) > 0 && values[0] != overview {
+		name = values[0]
+	}
+	page, err := render(i, name)
+	if err != nil {
+		return err
+	}
+	return commands.Respond(s, i, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: page,
+	})
+}
+
+// render returns the help page for a topic such as "reminder set", or the
+// command list if name is empty
+func render(i *discordgo.InteractionCreate, name string) (*discordgo.InteractionResponseData, error) {
+	name = strings.Join(strings.Fields(strings.TrimPrefix(strings.TrimSpace(name), "/")), " ")
+	if name == "" {
+		return overviewPage(i), nil
+	}
+	t, ok := findTopic(i, name)
+	if !ok {
+		// Commands the user may not run are reported as unknown, not forbidden
+		return nil, i18n.Errorf("help.unknown", name)
+	}
+	return topicPage(i, t), nil
+}
+
+// overviewPage lists the commands available to the user
+func overviewPage(i *discordgo.InteractionCreate) *discordgo.InteractionResponseData {
+	embed := &discordgo.MessageEmbed{
+		Title:       commands.Text(i, "help.title"),
+		Description: commands.Text(i, "help.overview"),
+		Color:       embedColor,
+	}
+
+	var apps []string
+	var topics []topic
+	for _, cmd := range available(i) {
+		if !isSlash(cmd) {
+			apps = append(apps, "• "+cmd.LocalizedName(i.Locale))
+			continue
+		}
+		t := topic{cmd: cmd, options: cmd.Options}
+		topics = append(topics, t)
+		embed.Fields = appendField(embed.Fields, "/"+t.String(), t.describe(i))
+	}
+	if len(apps) > 0 {
+		embed.Fields = appendField(embed.Fields, commands.Text(i, "help.apps"), strings.Join(apps, "\n"))
+	}
+
+	return &discordgo.InteractionResponseData{
+		Embeds:     []*discordgo.MessageEmbed{embed},
+		Components: selectMenu(i, topics, ""),
+	}
+}
+
+// topicPage explains a command, subcommand group or subcommand
+func topicPage(i *discordgo.InteractionCreate, t topic) *discordgo.InteractionResponseData {
+	embed := &discordgo.MessageEmbed{
+		Title:       "/" + t.String(),
+		Description: t.describe(i),
+		Color:       embedColor,
+	}
+
+	subs := t.subcommands()
+	if len(subs) > 0 {
+		for _, sub := range subs {
+			embed.Fields = appendField(embed.Fields, sub.usage(), sub.describe(i))
+		}
+	} else {
+		embed.Fields = appendField(embed.Fields, commands.Text(i, "help.usage"), "`"+t.usage()+"`")
+		for _, o := range t.options {
+			embed.Fields = appendField(embed.Fields, optionTitle(i, o), t.child(o).describe(i)+choiceList(o))
+		}
+	}
+
+	if examples := t.examples(); len(examples) > 0 {
+		embed.Fields = appendField(embed.Fields, commands.Text(i, "help.examples"), "`"+strings.Join(examples, "`\n`")+"`")
+	}
+
+	// The menu moves between the command and its subcommands
+	root := topic{cmd: t.cmd, options: t.cmd.Options}
+	return &discordgo.InteractionResponseData{
+		Embeds:     []*discordgo.MessageEmbed{embed},
+		Components: selectMenu(i, append([]topic{root}, root.subcommands()...), t.String()),
+	}
+}
+
+// selectMenu offers the command list and topics, marking current as selected
+func selectMenu(i *discordgo.InteractionCreate, topics []topic, current string) []discordgo.MessageComponent {
+	options := []discordgo.SelectMenuOption{{
+		Label:   commands.Text(i, "help.back"),
+		Value:   overview,
+		Emoji:   &discordgo.ComponentEmoji{Name: "📖"},
+		Default: current == "",
+	}}
+	for _, t := range topics {
+		if len(options) == maxSelectOptions {
+			break
+		}
+		options = append(options, discordgo.SelectMenuOption{
+			Label:       truncate("/"+t.String(), maxSelectText),
+			Value:       t.String(),
+			Description: truncate(t.describe(i), maxSelectText),
+			Default:     t.String() == current,
+		})
+	}
+
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.SelectMenu{
+				CustomID:    commands.MustCustomID("help", "show", nil),
+				Placeholder: commands.Text(i, "help.placeholder"),
+				Options:     options,
+			},
+		}},
+	}
+}
+
+// topicChoices suggests the commands and subcommands the user can run
+func topicChoices(_ context.Context, i *discordgo.InteractionCreate, value string) []*discordgo.ApplicationCommandOptionChoice {
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	for _, cmd := range available(i) {
+		if !isSlash(cmd) {
+			continue
+		}
+		root := topic{cmd: cmd, options: cmd.Options}
+		for _, t := range append([]topic{root}, root.subcommands()...) {
+			choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: "/" + t.String(), Value: t.String()})
+		}
+	}
+	return commands.MatchChoices(strings.TrimPrefix(value, "/"), choices)
+}
+
+// available returns the registered commands the user may run, by name
+func available(i *discordgo.InteractionCreate) []*commands.Command {
+	var cmds []*commands.Command
+	for _, cmd := range commands.Commands() {
+		if cmd.Available(i) {
+			cmds = append(cmds, cmd)
+		}
+	}
+	return cmds
+}
+
+func isSlash(cmd *commands.Command) bool {
+	return cmd.Type == 0 || cmd.Type == discordgo.ChatApplicationCommand
+}
+
+// optionTitle names an option, marking required ones
+func optionTitle(i *discordgo.InteractionCreate, o *discordgo.ApplicationCommandOption) string {
+	title := "`" + o.Name + "`"
+	if o.Required {
+		title += " (" + commands.Text(i, "help.required") + ")"
+	}
+	return title
+}
+
+// choiceList lists the fixed choices of an option
+func choiceList(o *discordgo.ApplicationCommandOption) string {
+	if len(o.Choices) == 0 {
+		return ""
+	}
+	names := make([]string, len(o.Choices))
+	for idx, c := range o.Choices {
+		names[idx] = "`" + c.Name + "`"
+	}
+	return "\n" + strings.Join(names, ", ")
+}
+
+// appendField adds an embed field within Discord's limits
+func appendField(fields []*discordgo.MessageEmbedField, name, value string) []*discordgo.MessageEmbedField {
+	if len(fields) == maxFields {
+		return fields
+	}
+	if value == "" {
+		value = "\u200b" // Discord rejects empty field values
+	}
+	return append(fields, &discordgo.MessageEmbedField{
+		Name:  truncate(name, maxFieldName),
+		Value: truncate(value, maxFieldValue),
+	})
+}
+
+func truncate(s string, n int) string {
+	if r := []rune(s); len(r) > n {
+		return string(r[:n-1]) + "…"
+	}
+	return s
+}
+
+func init() {
+	commands.Register(HelpCmd)
+	commands.HandleComponent("help", "show", handleSelect)
+}
@@ -0,0 +1,112 @@
+package help_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/leeineian/minder/internal/commands"
+	"github.com/leeineian/minder/internal/commands/help"
+	"github.com/leeineian/minder/internal/discord/discordtest"
+)
+
+func setup(t *testing.T) *discordtest.Session {
+	t.Helper()
+	saved := commands.Registry
+	commands.Registry = map[string]*commands.Command{"help": help.HelpCmd}
+	commands.Register(&commands.Command{
+		Name:        "tool",
+		Description: "Tools",
+		Options: []*discordgo.ApplicationCommandOption{{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "run",
+			Description: "Run a tool",
+			Options: []*discordgo.ApplicationCommandOption{
+				{Type: discordgo.ApplicationCommandOptionString, Name: "name", Description: "Tool name", Required: true},
+				{Type: discordgo.ApplicationCommandOptionInteger, Name: "times", Description: "Repeat count"},
+			},
+		}},
+		Examples: []string{"/tool run name:hammer times:2"},
+	})
+	commands.Register(&commands.Command{Name: "secret", Description: "Owner stuff", Policies: []commands.Policy{commands.OwnerOnly()}})
+	commands.Register(&commands.Command{Type: discordgo.MessageApplicationCommand, Name: "Quote this"})
+	t.Cleanup(func() { commands.Registry = saved })
+
+	return discordtest.NewSession()
+}
+
+func askHelp(topic string) *discordgo.InteractionCreate {
+	var args []*discordgo.ApplicationCommandInteractionDataOption
+	if topic != "" {
+		args = discordtest.Args(discordtest.String("command", topic))
+	}
+	return discordtest.Command("help", args)
+}
+
+// response returns the only interaction response sent
+func response(t *testing.T, s *discordtest.Session) *discordgo.InteractionResponseData {
+	t.Helper()
+	calls := s.CallsTo("InteractionRespond")
+	if len(calls) != 1 || calls[0].Response.Data == nil {
+		t.Fatalf("Expected one response, got %+v", s.Calls())
+	}
+	return calls[0].Response.Data
+}
+
+func fieldNames(embed *discordgo.MessageEmbed) string {
+	var names []string
+	for _, f := range embed.Fields {
+		names = append(names, f.Name)
+	}
+	return strings.Join(names, " | ")
+}
+
+func TestOverview(t *testing.T) {
+	s := setup(t)
+	help.HelpCmd.Handler(s, askHelp(""))
+
+	got := response(t, s)
+	if len(got.Embeds) != 1 {
+		t.Fatalf("Expected one embed, got %+v", got)
+	}
+	components, _ := json.Marshal(got.Components)
+	if names := fieldNames(got.Embeds[0]); names != "/help | /tool | Apps (right-click a message)" {
+		t.Errorf("Expected the public commands and apps, got %q", names)
+	}
+	if strings.Contains(string(components), "secret") {
+		t.Error("Expected commands the user may not run to be hidden from the menu")
+	}
+	if !strings.Contains(string(components), `"value":"tool"`) {
+		t.Errorf("Expected the menu to offer /tool, got %s", components)
+	}
+}
+
+func TestSubcommandPage(t *testing.T) {
+	s := setup(t)
+	help.HelpCmd.Handler(s, askHelp("/tool run"))
+
+	got := response(t, s)
+	if len(got.Embeds) != 1 {
+		t.Fatalf("Expected one embed, got %+v", got)
+	}
+	embed := got.Embeds[0]
+	if embed.Title != "/tool run" || embed.Description != "Run a tool" {
+		t.Errorf("Unexpected title %q and description %q", embed.Title, embed.Description)
+	}
+	if names := fieldNames(embed); names != "Usage | `name` (required) | `times` | Examples" {
+		t.Errorf("Unexpected fields %q", names)
+	}
+	if embed.Fields[0].Value != "`/tool run <name> [times]`" || !strings.Contains(embed.Fields[3].Value, "name:hammer") {
+		t.Errorf("Unexpected usage %q or examples %q", embed.Fields[0].Value, embed.Fields[3].Value)
+	}
+}
+
+func TestHiddenCommand(t *testing.T) {
+	s := setup(t)
+	help.HelpCmd.Handler(s, askHelp("secret"))
+
+	if got := response(t, s); !strings.Contains(got.Content, `There is no command "secret"`) {
+		t.Errorf("Expected commands the user may not run to look unknown, got %q", got.Content)
+	}
+}
@@ -0,0 +1,100 @@
+package help
+
+import (
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/leeineian/minder/internal/commands"
+)
+
+// topic is a command, subcommand group or subcommand /help can explain
+type topic struct {
+	cmd  *commands.Command
+	path []string
+	// options are the topic's subcommands, groups or parameters
+	options []*discordgo.ApplicationCommandOption
+}
+
+// String returns the topic as typed after the slash, e.g. "reminder set"
+func (t topic) String() string {
+	return strings.Join(append([]string{t.cmd.Name}, t.path...), " ")
+}
+
+func (t topic) describe(i *discordgo.InteractionCreate) string {
+	return t.cmd.Describe(i.Locale, t.path...)
+}
+
+// child returns the topic of one of t's options
+func (t topic) child(o *discordgo.ApplicationCommandOption) topic {
+	path := append(append([]string(nil), t.path...), o.Name)
+	return topic{cmd: t.cmd, path: path, options: o.Options}
+}
+
+// subcommands returns the subcommands below t, flattening groups
+func (t topic) subcommands() []topic {
+	var subs []topic
+	for _, o := range t.options {
+		switch o.Type {
+		case discordgo.ApplicationCommandOptionSubCommand:
+			subs = append(subs, t.child(o))
+		case discordgo.ApplicationCommandOptionSubCommandGroup:
+			subs = append(subs, t.child(o).subcommands()...)
+		}
+	}
+	return subs
+}
+
+// usage returns the invocation syntax, with required options in <> and optional ones in []
+func (t topic) usage() string {
+	parts := []string{"/" + t.String()}
+	for _, o := range t.options {
+		switch {
+		case o.Type == discordgo.ApplicationCommandOptionSubCommand || o.Type == discordgo.ApplicationCommandOptionSubCommandGroup:
+			continue
+		case o.Required:
+			parts = append(parts, "<"+o.Name+">")
+		default:
+			parts = append(parts, "["+o.Name+"]")
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// examples returns the command's examples that invoke t or a subcommand of it
+func (t topic) examples() []string {
+	prefix := "/" + t.String()
+	var out []string
+	for _, ex := range t.cmd.Examples {
+		if ex == prefix || strings.HasPrefix(ex, prefix+" ") {
+			out = append(out, ex)
+		}
+	}
+	return out
+}
+
+// findTopic resolves a name such as "debug webhook-looper start" to a topic
+// the user may see
+func findTopic(i *discordgo.InteractionCreate, name string) (topic, bool) {
+	fields := strings.Fields(name)
+	cmd, ok := commands.Registry[fields[0]]
+	if !ok || !isSlash(cmd) || !cmd.Available(i) {
+		return topic{}, false
+	}
+
+	t := topic{cmd: cmd, options: cmd.Options}
+	for _, part := range fields[1:] {
+		var next *discordgo.ApplicationCommandOption
+		for _, o := range t.options {
+			isSub := o.Type == discordgo.ApplicationCommandOptionSubCommand || o.Type == discordgo.ApplicationCommandOptionSubCommandGroup
+			if isSub && o.Name == part {
+				next = o
+				break
+			}
+		}
+		if next == nil {
+			return topic{}, false
+		}
+		t = t.child(next)
+	}
+	return t, true
+}
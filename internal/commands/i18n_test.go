@@ -0,0 +1,54 @@
+package commands_test
+
+import (
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/leeineian/minder/internal/commands"
+	"github.com/leeineian/minder/internal/discord"
+	"github.com/leeineian/minder/internal/discord/discordtest"
+)
+
+func TestLocalizedDefinition(t *testing.T) {
+	set := &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionSubCommand,
+		Name:        "set",
+		Description: "Set a new reminder",
+	}
+	cmd := &commands.Command{
+		Name:        "reminder",
+		Description: "Manage your reminders",
+		Options:     []*discordgo.ApplicationCommandOption{set},
+	}
+
+	def := commands.Definition(cmd)
+	if def.NameLocalizations == nil || (*def.NameLocalizations)[discordgo.German] != "erinnerung" {
+		t.Errorf("Expected a German name, got %v", def.NameLocalizations)
+	}
+	if def.DescriptionLocalizations == nil || (*def.DescriptionLocalizations)[discordgo.SpanishES] == "" {
+		t.Errorf("Expected a Spanish description, got %v", def.DescriptionLocalizations)
+	}
+	if def.Options[0].DescriptionLocalizations[discordgo.German] != "Neue Erinnerung setzen" {
+		t.Errorf("Expected a German subcommand description, got %v", def.Options[0].DescriptionLocalizations)
+	}
+	if set.DescriptionLocalizations != nil {
+		t.Error("Expected the command's own options to be left untouched")
+	}
+
+	if def := commands.Definition(&commands.Command{Name: "ping", Description: "Pong"}); def.NameLocalizations != nil || def.DescriptionLocalizations != nil {
+		t.Errorf("Expected no localizations for commands without translations, got %+v", def)
+	}
+}
+
+func TestLocalizedErrorReply(t *testing.T) {
+	type opts struct {
+		Count int `option:"count,required"`
+	}
+	handler := commands.WithOptions(func(s discord.Session, i *discordgo.InteractionCreate, _ opts) error { return nil })
+
+	s := discordtest.NewSession()
+	handler(s, discordtest.Command("test", nil, discordtest.WithLocale(discordgo.German)))
+	if got := s.Texts(); len(got) != 1 || got[0] != "❌ `count` ist erforderlich" {
+		t.Errorf("Expected a German validation error, got %q", got)
+	}
+}
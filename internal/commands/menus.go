@@ -0,0 +1,58 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// TargetMessage returns the message a message command was used on
+func TargetMessage(i *discordgo.InteractionCreate) (*discordgo.Message, error) {
+	data := i.ApplicationCommandData()
+	if data.CommandType != discordgo.MessageApplicationCommand || data.Resolved == nil {
+		return nil, fmt.Errorf("/%s was not used on a message", data.Name)
+	}
+	msg, ok := data.Resolved.Messages[data.TargetID]
+	if !ok {
+		return nil, fmt.Errorf("message %s was not included in the interaction", data.TargetID)
+	}
+	return msg, nil
+}
+
+// TargetUser returns the user a user command was used on
+func TargetUser(i *discordgo.InteractionCreate) (*discordgo.User, error) {
+	data := i.ApplicationCommandData()
+	if data.CommandType != discordgo.UserApplicationCommand || data.Resolved == nil {
+		return nil, fmt.Errorf("/%s was not used on a user", data.Name)
+	}
+	user, ok := data.Resolved.Users[data.TargetID]
+	if !ok {
+		return nil, fmt.Errorf("user %s was not included in the interaction", data.TargetID)
+	}
+	return user, nil
+}
+
+// MessageLink returns the jump URL of a message
+func MessageLink(guildID, channelID, messageID string) string {
+	if guildID == "" {
+		guildID = "@me"
+	}
+	return fmt.Sprintf("https://discord.com/channels/%s/%s/%s", guildID, channelID, messageID)
+}
+
+// ModalValues returns the values of a modal submission's text inputs by custom ID
+func ModalValues(i *discordgo.InteractionCreate) map[string]string {
+	values := make(map[string]string)
+	for _, row := range i.ModalSubmitData().Components {
+		r, ok := row.(*discordgo.ActionsRow)
+		if !ok {
+			continue
+		}
+		for _, c := range r.Components {
+			if input, ok := c.(*discordgo.TextInput); ok {
+				values[input.CustomID] = input.Value
+			}
+		}
+	}
+	return values
+}
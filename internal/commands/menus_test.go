@@ -0,0 +1,82 @@
+package commands_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/leeineian/minder/internal/commands"
+	"github.com/leeineian/minder/internal/discord"
+	"github.com/leeineian/minder/internal/discord/discordtest"
+)
+
+// onMessage invokes a message command on a message with the given content
+func onMessage(name, content string) *discordgo.InteractionCreate {
+	return discordtest.MessageCommand(name, &discordgo.Message{ID: "m1", Content: content})
+}
+
+func TestContextMenuDefinition(t *testing.T) {
+	menu := commands.Definition(&commands.Command{
+		Type:        discordgo.MessageApplicationCommand,
+		Name:        "Quote this",
+		Description: "ignored",
+		Options:     []*discordgo.ApplicationCommandOption{{Name: "ignored"}},
+	})
+	if menu.Type != discordgo.MessageApplicationCommand || menu.Description != "" || menu.Options != nil {
+		t.Errorf("Expected a message command without description or options, got %+v", menu)
+	}
+
+	chat := commands.Definition(&commands.Command{Name: "ping", Description: "Pong"})
+	if chat.Type != discordgo.ChatApplicationCommand || chat.Description != "Pong" {
+		t.Errorf("Expected commands without a type to be slash commands, got %+v", chat)
+	}
+}
+
+func TestDispatchMessageCommand(t *testing.T) {
+	var target string
+	s := discordtest.NewSession()
+	withRegistry(t, nil, &commands.Command{
+		Type: discordgo.MessageApplicationCommand,
+		Name: "Quote this",
+		Handler: commands.WithOptions(func(s discord.Session, i *discordgo.InteractionCreate, _ struct{}) error {
+			msg, err := commands.TargetMessage(i)
+			if err != nil {
+				return err
+			}
+			target = msg.Content
+			return nil
+		}),
+	})
+
+	if !commands.Dispatch(s, onMessage("Quote this", "hello")) || target != "hello" {
+		t.Errorf("Expected the handler to get the target message, got %q", target)
+	}
+	if calls := s.Calls(); len(calls) != 0 {
+		t.Errorf("Expected no replies, got %+v", calls)
+	}
+}
+
+func TestTargetMismatch(t *testing.T) {
+	if _, err := commands.TargetMessage(command("ping", "u1")); err == nil {
+		t.Error("Expected TargetMessage to fail for a slash command")
+	}
+	if _, err := commands.TargetUser(onMessage("Quote this", "hello")); err == nil {
+		t.Error("Expected TargetUser to fail for a message command")
+	}
+}
+
+func TestMessageLink(t *testing.T) {
+	if got := commands.MessageLink("g1", "c1", "m1"); got != "https://discord.com/channels/g1/c1/m1" {
+		t.Errorf("Unexpected guild link %q", got)
+	}
+	if got := commands.MessageLink("", "c1", "m1"); !strings.Contains(got, "/@me/c1/m1") {
+		t.Errorf("Expected DM links to use @me, got %q", got)
+	}
+}
+
+func TestModalValues(t *testing.T) {
+	values := commands.ModalValues(discordtest.ModalSubmit("x", map[string]string{"when": "2h", "note": "call"}))
+	if values["when"] != "2h" || values["note"] != "call" {
+		t.Errorf("Unexpected values %v", values)
+	}
+}
@@ -0,0 +1,124 @@
+package commands
+
+import (
+	"runtime/debug"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/leeineian/minder/internal/discord"
+	"github.com/leeineian/minder/internal/logger"
+)
+
+// HandlerFunc handles an interaction
+type HandlerFunc func(s discord.Session, i *discordgo.InteractionCreate)
+
+// Middleware wraps the handler of a command. It receives the command being
+// run so it can read per-command settings such as Policies or Cooldown.
+type Middleware func(cmd *Command, next HandlerFunc) HandlerFunc
+
+// middleware runs around every command, outermost first
+var middleware []Middleware
+
+// Use registers global middleware. It runs around every command and component
+// interaction, before the command's own Middleware, in registration order.
+func Use(mw ...Middleware) {
+	middleware = append(middleware, mw...)
+}
+
+// Dispatch runs the command an application command interaction names through
+// the middleware chain. It reports false if no such command is registered.
+func Dispatch(s discord.Session, i *discordgo.InteractionCreate) bool {
+	cmd, ok := Registry[i.ApplicationCommandData().Name]
+	if !ok {
+		return false
+	}
+	run(cmd, cmd.Handler, cmd.Middleware, s, i)
+	return true
+}
+
+func run(cmd *Command, handler HandlerFunc, local []Middleware, s discord.Session, i *discordgo.InteractionCreate) {
+	if !acquire() {
+		ReplyEphemeral(s, i, Text(i, "error.shutting_down"))
+		return
+	}
+	defer release()
+	defer track(i)()
+
+	h := handler
+	for idx := len(local) - 1; idx >= 0; idx-- {
+		h = local[idx](cmd, h)
+	}
+	for idx := len(middleware) - 1; idx >= 0; idx-- {
+		h = middleware[idx](cmd, h)
+	}
+	h(s, i)
+}
+
+// Recover turns a panicking handler into a logged error and an ephemeral reply
+func Recover() Middleware {
+	return func(cmd *Command, next HandlerFunc) HandlerFunc {
+		return func(s discord.Session, i *discordgo.InteractionCreate) {
+			defer func() {
+				if r := recover(); r != nil {
+					logger.Error("Panic in interaction handler",
+						"command", cmd.Name,
+						"interactionID", i.ID,
+						"panic", r,
+						"stack", string(debug.Stack()))
+					ReplyEphemeral(s, i, Text(i, "error.internal"))
+				}
+			}()
+			next(s, i)
+		}
+	}
+}
+
+// Logging logs every handled interaction with its latency
+func Logging() Middleware {
+	return func(cmd *Command, next HandlerFunc) HandlerFunc {
+		return func(s discord.Session, i *discordgo.InteractionCreate) {
+			start := time.Now()
+			next(s, i)
+			logger.Info("Handled interaction",
+				"interactionID", i.ID,
+				"command", cmd.Name,
+				"userID", InteractionUserID(i),
+				"guildID", i.GuildID,
+				"latency", time.Since(start))
+		}
+	}
+}
+
+// Permissions denies interactions that fail one of the command's Policies
+func Permissions() Middleware {
+	return func(cmd *Command, next HandlerFunc) HandlerFunc {
+		return func(s discord.Session, i *discordgo.InteractionCreate) {
+			if err := cmd.Authorize(i); err != nil {
+				Deny(s, i, err)
+				return
+			}
+			next(s, i)
+		}
+	}
+}
+
+// AutoDefer defers the interaction when the handler has not responded within
+// after, so slow handlers do not hit Discord's three second deadline. The defer
+// is ephemeral for commands with Ephemeral set. Handlers must respond through
+// Respond for their reply to land on the deferred response.
+func AutoDefer(after time.Duration) Middleware {
+	return func(cmd *Command, next HandlerFunc) HandlerFunc {
+		return func(s discord.Session, i *discordgo.InteractionCreate) {
+			timer := time.AfterFunc(after, func() {
+				deferred, err := deferInteraction(s, i, cmd.Ephemeral)
+				if err != nil {
+					logger.Warn("Failed to defer interaction", "command", cmd.Name, "interactionID", i.ID, "error", err)
+				} else if deferred {
+					logger.Debug("Deferred slow interaction", "command", cmd.Name, "interactionID", i.ID)
+				}
+			})
+			defer timer.Stop()
+			next(s, i)
+		}
+	}
+}
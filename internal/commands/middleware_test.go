@@ -0,0 +1,168 @@
+package commands_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/leeineian/minder/internal/commands"
+	"github.com/leeineian/minder/internal/discord"
+	"github.com/leeineian/minder/internal/discord/discordtest"
+)
+
+// command invokes name as userID
+func command(name, userID string) *discordgo.InteractionCreate {
+	return discordtest.Command(name, nil, discordtest.From(userID))
+}
+
+// withRegistry swaps in a registry and global middleware for one test
+func withRegistry(t *testing.T, mw []commands.Middleware, cmds ...*commands.Command) {
+	t.Helper()
+	saved := commands.Registry
+	commands.Registry = make(map[string]*commands.Command)
+	for _, c := range cmds {
+		commands.Register(c)
+	}
+	commands.Use(mw...)
+	t.Cleanup(func() {
+		commands.Registry = saved
+		commands.ResetMiddleware()
+	})
+}
+
+func reply(content string) commands.HandlerFunc {
+	return func(s discord.Session, i *discordgo.InteractionCreate) {
+		commands.Respond(s, i, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Content: content},
+		})
+	}
+}
+
+func TestMiddlewareOrder(t *testing.T) {
+	var order []string
+	trace := func(name string) commands.Middleware {
+		return func(cmd *commands.Command, next commands.HandlerFunc) commands.HandlerFunc {
+			return func(s discord.Session, i *discordgo.InteractionCreate) {
+				order = append(order, name+":"+cmd.Name)
+				next(s, i)
+			}
+		}
+	}
+
+	withRegistry(t, []commands.Middleware{trace("global1"), trace("global2")}, &commands.Command{
+		Name:       "ping",
+		Middleware: []commands.Middleware{trace("local")},
+		Handler: func(s discord.Session, i *discordgo.InteractionCreate) {
+			order = append(order, "handler")
+		},
+	})
+
+	if !commands.Dispatch(nil, command("ping", "u1")) {
+		t.Fatal("Expected ping to be dispatched")
+	}
+	if commands.Dispatch(nil, command("missing", "u1")) {
+		t.Error("Expected an unknown command not to be dispatched")
+	}
+
+	want := "global1:ping global2:ping local:ping handler"
+	if got := strings.Join(order, " "); got != want {
+		t.Errorf("Order = %q, want %q", got, want)
+	}
+}
+
+func TestRecoverRepliesWithError(t *testing.T) {
+	s := discordtest.NewSession()
+	withRegistry(t, []commands.Middleware{commands.Recover()}, &commands.Command{
+		Name:    "boom",
+		Handler: func(s discord.Session, i *discordgo.InteractionCreate) { panic("kaboom") },
+	})
+
+	commands.Dispatch(s, command("boom", "u1"))
+
+	calls := s.CallsTo("InteractionRespond")
+	if len(calls) != 1 || calls[0].Response.Type != discordgo.InteractionResponseChannelMessageWithSource ||
+		!strings.Contains(calls[0].Response.Data.Content, "Something went wrong") {
+		t.Errorf("Expected an error reply, got %q", s.Texts())
+	}
+}
+
+func TestPermissionsMiddleware(t *testing.T) {
+	commands.SetOwnerID("owner")
+	defer commands.SetOwnerID("")
+
+	s := discordtest.NewSession()
+	ran := false
+	withRegistry(t, []commands.Middleware{commands.Permissions()}, &commands.Command{
+		Name:     "secret",
+		Policies: []commands.Policy{commands.OwnerOnly()},
+		Handler:  func(s discord.Session, i *discordgo.InteractionCreate) { ran = true },
+	})
+
+	commands.Dispatch(s, command("secret", "someone"))
+	if ran {
+		t.Error("Handler ran for a denied user")
+	}
+	if got := s.Texts(); len(got) != 1 || !strings.Contains(got[0], "⛔") {
+		t.Errorf("Expected a denial, got %q", got)
+	}
+
+	commands.Dispatch(s, command("secret", "owner"))
+	if !ran {
+		t.Error("Handler did not run for the owner")
+	}
+}
+
+func TestCooldowns(t *testing.T) {
+	s := discordtest.NewSession()
+	runs := 0
+	withRegistry(t, []commands.Middleware{commands.Cooldowns()}, &commands.Command{
+		Name:      "slow",
+		Cooldowns: []commands.Cooldown{{Uses: 1, Window: time.Hour}},
+		Handler:   func(s discord.Session, i *discordgo.InteractionCreate) { runs++ },
+	})
+
+	commands.Dispatch(s, command("slow", "u1"))
+	commands.Dispatch(s, command("slow", "u1"))
+	commands.Dispatch(s, command("slow", "u2"))
+
+	if runs != 2 {
+		t.Errorf("Expected one run per user, got %d", runs)
+	}
+	if got := s.Texts(); len(got) != 1 || !strings.Contains(got[0], "again in 1h0m0s") {
+		t.Errorf("Expected one cooldown notice, got %q", got)
+	}
+}
+
+func TestAutoDefer(t *testing.T) {
+	s := discordtest.NewSession()
+	withRegistry(t, []commands.Middleware{commands.AutoDefer(10 * time.Millisecond)},
+		&commands.Command{
+			Name:      "slow",
+			Ephemeral: true,
+			Handler: func(s discord.Session, i *discordgo.InteractionCreate) {
+				time.Sleep(100 * time.Millisecond)
+				reply("done")(s, i)
+			},
+		},
+		&commands.Command{Name: "fast", Handler: reply("quick")},
+	)
+
+	commands.Dispatch(s, command("slow", "u1"))
+	calls := s.Calls()
+	if len(calls) != 2 ||
+		calls[0].Method != "InteractionRespond" || calls[0].Response.Type != discordgo.InteractionResponseDeferredChannelMessageWithSource ||
+		calls[1].Method != "InteractionResponseEdit" || calls[1].Edit.Content == nil || *calls[1].Edit.Content != "done" {
+		t.Errorf("Expected a defer followed by an edit, got %+v", calls)
+	}
+
+	s.Reset()
+	commands.Dispatch(s, command("fast", "u1"))
+	time.Sleep(30 * time.Millisecond)
+	calls = s.Calls()
+	if len(calls) != 1 || calls[0].Method != "InteractionRespond" ||
+		calls[0].Response.Type != discordgo.InteractionResponseChannelMessageWithSource || calls[0].Response.Data.Content != "quick" {
+		t.Errorf("Expected a fast handler to respond without a defer, got %+v", calls)
+	}
+}
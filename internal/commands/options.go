@@ -0,0 +1,271 @@
+package commands
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/leeineian/minder/internal/i18n"
+)
+
+// OptionError reports an option that is missing or fails validation
+type OptionError struct {
+	Option string
+	Reason *i18n.Error
+}
+
+func (e *OptionError) Error() string {
+	return e.Localize(i18n.Fallback)
+}
+
+// Localize describes the problem in locale
+func (e *OptionError) Localize(locale discordgo.Locale) string {
+	return i18n.T(locale, "option.error", e.Option, e.Reason)
+}
+
+// Subcommand returns the path of the invoked subcommand, "<subcommand>" or
+// "<group> <subcommand>", and its options. Commands without subcommands
+// return an empty path and their top-level options.
+func Subcommand(i *discordgo.InteractionCreate) (string, []*discordgo.ApplicationCommandInteractionDataOption) {
+	if i.Type != discordgo.InteractionApplicationCommand && i.Type != discordgo.InteractionApplicationCommandAutocomplete {
+		return "", nil
+	}
+
+	var path []string
+	options := i.ApplicationCommandData().Options
+	for len(options) > 0 {
+		first := options[0]
+		if first.Type != discordgo.ApplicationCommandOptionSubCommandGroup && first.Type != discordgo.ApplicationCommandOptionSubCommand {
+			break
+		}
+		path = append(path, first.Name)
+		options = first.Options
+	}
+	return strings.Join(path, " "), options
+}
+
+// Bind decodes the options of the invoked subcommand into the struct dst
+// points to. Fields are matched by their option tag, which names the option
+// and lists validation rules:
+//
+//	ID       string `option:"id,required"`
+//	Interval *int   `option:"interval,min=1,max=600000"`
+//	Message  string `option:"message,minlen=1,maxlen=500"`
+//	Mode     string `option:"mode,choices=respect|measure"`
+//
+// Fields may be strings, integers, floats and bools, including named types
+// and pointers that stay nil when the option is absent, or *discordgo.User,
+// *discordgo.Channel and *discordgo.Role, filled from the resolved data.
+func Bind(i *discordgo.InteractionCreate, dst any) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("bind options: %T is not a pointer to a struct", dst)
+	}
+	v = v.Elem()
+
+	_, options := Subcommand(i)
+	byName := make(map[string]*discordgo.ApplicationCommandInteractionDataOption, len(options))
+	for _, opt := range options {
+		byName[opt.Name] = opt
+	}
+	var resolved *discordgo.ApplicationCommandInteractionDataResolved
+	if len(options) > 0 {
+		resolved = i.ApplicationCommandData().Resolved
+	}
+
+	t := v.Type()
+	for idx := 0; idx < t.NumField(); idx++ {
+		field := t.Field(idx)
+		tag, ok := field.Tag.Lookup("option")
+		if !ok || !field.IsExported() {
+			continue
+		}
+		name, rules, err := parseOptionTag(tag)
+		if err != nil {
+			return fmt.Errorf("bind option %s: %w", field.Name, err)
+		}
+
+		opt, ok := byName[name]
+		if !ok {
+			if rules.required {
+				return &OptionError{Option: name, Reason: i18n.Errorf("option.required")}
+			}
+			continue
+		}
+		if err := rules.check(name, optionValue(opt)); err != nil {
+			return err
+		}
+		if err := setOption(v.Field(idx), opt, resolved); err != nil {
+			return fmt.Errorf("bind option %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// optionRules are the validation rules of an option tag
+type optionRules struct {
+	required       bool
+	min, max       *float64
+	minLen, maxLen int
+	choices        []string
+}
+
+func parseOptionTag(tag string) (string, optionRules, error) {
+	parts := strings.Split(tag, ",")
+	rules := optionRules{minLen: -1, maxLen: -1}
+	for _, part := range parts[1:] {
+		key, value, _ := strings.Cut(part, "=")
+		var err error
+		switch key {
+		case "required":
+			rules.required = true
+		case "min", "max":
+			var n float64
+			if n, err = strconv.ParseFloat(value, 64); err == nil {
+				if key == "min" {
+					rules.min = &n
+				} else {
+					rules.max = &n
+				}
+			}
+		case "minlen":
+			rules.minLen, err = strconv.Atoi(value)
+		case "maxlen":
+			rules.maxLen, err = strconv.Atoi(value)
+		case "choices":
+			rules.choices = strings.Split(value, "|")
+		default:
+			err = fmt.Errorf("unknown rule %q", key)
+		}
+		if err != nil {
+			return "", rules, fmt.Errorf("invalid option tag %q: %w", tag, err)
+		}
+	}
+	return parts[0], rules, nil
+}
+
+func (r optionRules) check(name string, value any) error {
+	switch v := value.(type) {
+	case int64:
+		return r.checkNumber(name, float64(v))
+	case float64:
+		return r.checkNumber(name, v)
+	case string:
+		n := utf8.RuneCountInString(v)
+		if r.minLen >= 0 && n < r.minLen {
+			return &OptionError{Option: name, Reason: i18n.Errorf("option.min_length", r.minLen)}
+		}
+		if r.maxLen >= 0 && n > r.maxLen {
+			return &OptionError{Option: name, Reason: i18n.Errorf("option.max_length", r.maxLen)}
+		}
+		if len(r.choices) > 0 {
+			for _, c := range r.choices {
+				if v == c {
+					return nil
+				}
+			}
+			return &OptionError{Option: name, Reason: i18n.Errorf("option.choices", strings.Join(r.choices, ", "))}
+		}
+	}
+	return nil
+}
+
+func (r optionRules) checkNumber(name string, n float64) error {
+	if r.min != nil && n < *r.min {
+		return &OptionError{Option: name, Reason: i18n.Errorf("option.min", *r.min)}
+	}
+	if r.max != nil && n > *r.max {
+		return &OptionError{Option: name, Reason: i18n.Errorf("option.max", *r.max)}
+	}
+	return nil
+}
+
+// optionValue returns an option's value as an int64, float64, bool or string
+func optionValue(opt *discordgo.ApplicationCommandInteractionDataOption) any {
+	switch opt.Type {
+	case discordgo.ApplicationCommandOptionInteger:
+		return opt.IntValue()
+	case discordgo.ApplicationCommandOptionNumber:
+		return opt.FloatValue()
+	case discordgo.ApplicationCommandOptionBoolean:
+		return opt.BoolValue()
+	}
+	s, _ := opt.Value.(string)
+	return s
+}
+
+var (
+	userType    = reflect.TypeOf(&discordgo.User{})
+	channelType = reflect.TypeOf(&discordgo.Channel{})
+	roleType    = reflect.TypeOf(&discordgo.Role{})
+)
+
+func setOption(field reflect.Value, opt *discordgo.ApplicationCommandInteractionDataOption, resolved *discordgo.ApplicationCommandInteractionDataResolved) error {
+	id, _ := opt.Value.(string)
+	switch field.Type() {
+	case userType:
+		user := &discordgo.User{ID: id}
+		if resolved != nil && resolved.Users[id] != nil {
+			user = resolved.Users[id]
+		}
+		field.Set(reflect.ValueOf(user))
+		return nil
+	case channelType:
+		channel := &discordgo.Channel{ID: id}
+		if resolved != nil && resolved.Channels[id] != nil {
+			channel = resolved.Channels[id]
+		}
+		field.Set(reflect.ValueOf(channel))
+		return nil
+	case roleType:
+		role := &discordgo.Role{ID: id}
+		if resolved != nil && resolved.Roles[id] != nil {
+			role = resolved.Roles[id]
+		}
+		field.Set(reflect.ValueOf(role))
+		return nil
+	}
+
+	if field.Kind() == reflect.Pointer {
+		ptr := reflect.New(field.Type().Elem())
+		if err := setScalar(ptr.Elem(), optionValue(opt)); err != nil {
+			return err
+		}
+		field.Set(ptr)
+		return nil
+	}
+	return setScalar(field, optionValue(opt))
+}
+
+func setScalar(field reflect.Value, value any) error {
+	switch field.Kind() {
+	case reflect.String:
+		if s, ok := value.(string); ok {
+			field.SetString(s)
+			return nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if n, ok := value.(int64); ok && !field.OverflowInt(n) {
+			field.SetInt(n)
+			return nil
+		}
+	case reflect.Float32, reflect.Float64:
+		switch n := value.(type) {
+		case float64:
+			field.SetFloat(n)
+			return nil
+		case int64:
+			field.SetFloat(float64(n))
+			return nil
+		}
+	case reflect.Bool:
+		if b, ok := value.(bool); ok {
+			field.SetBool(b)
+			return nil
+		}
+	}
+	return fmt.Errorf("cannot decode %T into %s", value, field.Type())
+}
@@ -0,0 +1,131 @@
+package commands_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/leeineian/minder/internal/commands"
+	"github.com/leeineian/minder/internal/discord/discordtest"
+)
+
+type option = discordgo.ApplicationCommandInteractionDataOption
+
+func invoke(options ...*option) *discordgo.InteractionCreate {
+	return discordtest.Command("test", options)
+}
+
+type mode string
+
+type bindTarget struct {
+	ID       string   `option:"id,required"`
+	Count    int      `option:"count,min=1,max=10"`
+	Rate     *float64 `option:"rate,min=0,max=1"`
+	Enabled  *bool    `option:"enabled"`
+	Mode     mode     `option:"mode,choices=fast|slow"`
+	Note     string   `option:"note,minlen=2,maxlen=5"`
+	Untagged string
+}
+
+func TestSubcommand(t *testing.T) {
+	tests := []struct {
+		name string
+		i    *discordgo.InteractionCreate
+		want string
+		opts int
+	}{
+		{"top level", invoke(discordtest.String("id", "x")), "", 1},
+		{"subcommand", invoke(discordtest.Sub("set", discordtest.String("id", "x"), discordtest.String("mode", "fast"))), "set", 2},
+		{"group", invoke(discordtest.Group("webhook-looper", discordtest.Sub("start", discordtest.String("id", "x")))), "webhook-looper start", 1},
+		{"no options", invoke(), "", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, opts := commands.Subcommand(tt.i)
+			if path != tt.want || len(opts) != tt.opts {
+				t.Errorf("Subcommand() = %q with %d options, want %q with %d", path, len(opts), tt.want, tt.opts)
+			}
+		})
+	}
+}
+
+func TestBind(t *testing.T) {
+	var got bindTarget
+	err := commands.Bind(invoke(discordtest.Sub("set",
+		discordtest.String("mode", "slow"), // order does not matter
+		discordtest.String("id", "abc"),
+		discordtest.Int("count", 3),
+		&option{Name: "rate", Type: discordgo.ApplicationCommandOptionNumber, Value: 0.5},
+		discordtest.Bool("enabled", false),
+	)), &got)
+	if err != nil {
+		t.Fatalf("Bind failed: %v", err)
+	}
+	if got.ID != "abc" || got.Count != 3 || got.Mode != "slow" || got.Note != "" {
+		t.Errorf("Unexpected values %+v", got)
+	}
+	if got.Rate == nil || *got.Rate != 0.5 || got.Enabled == nil || *got.Enabled {
+		t.Errorf("Expected pointers to given options, got rate %v enabled %v", got.Rate, got.Enabled)
+	}
+
+	var absent bindTarget
+	if err := commands.Bind(invoke(discordtest.String("id", "abc")), &absent); err != nil {
+		t.Fatalf("Bind failed: %v", err)
+	}
+	if absent.Rate != nil || absent.Enabled != nil {
+		t.Error("Expected absent options to leave pointers nil")
+	}
+}
+
+func TestBindValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []*option
+		want    string
+	}{
+		{"missing required", []*option{discordtest.Int("count", 1)}, "`id` is required"},
+		{"below min", []*option{discordtest.String("id", "x"), discordtest.Int("count", 0)}, "`count` must be at least 1"},
+		{"above max", []*option{discordtest.String("id", "x"), discordtest.Int("count", 11)}, "`count` must be at most 10"},
+		{"float above max", []*option{discordtest.String("id", "x"), {Name: "rate", Type: discordgo.ApplicationCommandOptionNumber, Value: 1.5}}, "`rate` must be at most 1"},
+		{"too short", []*option{discordtest.String("id", "x"), discordtest.String("note", "a")}, "`note` must be at least 2 characters"},
+		{"too long", []*option{discordtest.String("id", "x"), discordtest.String("note", "héllo!")}, "`note` must be at most 5 characters"},
+		{"not a choice", []*option{discordtest.String("id", "x"), discordtest.String("mode", "medium")}, "`mode` must be one of fast, slow"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got bindTarget
+			err := commands.Bind(invoke(tt.options...), &got)
+			var oerr *commands.OptionError
+			if !errors.As(err, &oerr) || err.Error() != tt.want {
+				t.Errorf("Bind() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestBindResolved(t *testing.T) {
+	i := discordtest.Command("test", discordtest.Args(discordtest.Sub("provision", discordtest.Channel("category", "c1"))),
+		discordtest.Resolve(&discordgo.Channel{ID: "c1", Name: "load", Type: discordgo.ChannelTypeGuildCategory}))
+
+	var got struct {
+		Category *discordgo.Channel `option:"category,required"`
+	}
+	if err := commands.Bind(i, &got); err != nil {
+		t.Fatalf("Bind failed: %v", err)
+	}
+	if got.Category == nil || got.Category.Name != "load" {
+		t.Errorf("Expected the resolved channel, got %+v", got.Category)
+	}
+}
+
+func TestBindTypeMismatch(t *testing.T) {
+	var got struct {
+		Count int `option:"count"`
+	}
+	if err := commands.Bind(invoke(discordtest.String("count", "three")), &got); err == nil {
+		t.Error("Expected binding a string option into an int to fail")
+	}
+	if err := commands.Bind(invoke(), got); err == nil {
+		t.Error("Expected binding into a non-pointer to fail")
+	}
+}
@@ -0,0 +1,173 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/leeineian/minder/internal/database"
+	"github.com/leeineian/minder/internal/discord"
+	"github.com/leeineian/minder/internal/logger"
+)
+
+// Policy decides whether the user behind an interaction may run a command
+type Policy struct {
+	Name  string // shown in denials and audit entries, e.g. "owner only"
+	Allow func(i *discordgo.InteractionCreate) bool
+}
+
+// ownerID is the bot owner's user ID; OwnerOnly denies everyone while it is unset
+var ownerID string
+
+// SetOwnerID configures the user OwnerOnly allows
+func SetOwnerID(id string) {
+	ownerID = id
+}
+
+// OwnerOnly allows only the configured bot owner
+func OwnerOnly() Policy {
+	return Policy{
+		Name: "owner only",
+		Allow: func(i *discordgo.InteractionCreate) bool {
+			return ownerID != "" && InteractionUserID(i) == ownerID
+		},
+	}
+}
+
+// GuildAdmin allows guild members with the Administrator permission
+func GuildAdmin() Policy {
+	return RequirePermissions("guild admin", discordgo.PermissionAdministrator)
+}
+
+// RequirePermissions allows guild members holding every permission in perms
+func RequirePermissions(name string, perms int64) Policy {
+	return Policy{
+		Name: name,
+		Allow: func(i *discordgo.InteractionCreate) bool {
+			return i.Member != nil && i.Member.Permissions&perms == perms
+		},
+	}
+}
+
+// RequireRole allows guild members with at least one of the roles
+func RequireRole(roleIDs ...string) Policy {
+	return Policy{
+		Name: "requires role",
+		Allow: func(i *discordgo.InteractionCreate) bool {
+			if i.Member == nil {
+				return false
+			}
+			for _, have := range i.Member.Roles {
+				for _, want := range roleIDs {
+					if have == want {
+						return true
+					}
+				}
+			}
+			return false
+		},
+	}
+}
+
+// AnyOf allows interactions that at least one of the policies allows
+func AnyOf(policies ...Policy) Policy {
+	names := make([]string, len(policies))
+	for idx, p := range policies {
+		names[idx] = p.Name
+	}
+	return Policy{
+		Name: strings.Join(names, " or "),
+		Allow: func(i *discordgo.InteractionCreate) bool {
+			for _, p := range policies {
+				if p.Allow(i) {
+					return true
+				}
+			}
+			return false
+		},
+	}
+}
+
+// PermissionError reports the policy that denied an interaction
+type PermissionError struct {
+	Command string
+	Policy  string
+}
+
+func (e *PermissionError) Error() string {
+	return fmt.Sprintf("/%s is %s", e.Command, e.Policy)
+}
+
+// Authorize checks an interaction against every policy of the command
+func (c *Command) Authorize(i *discordgo.InteractionCreate) error {
+	return authorize(c.Name, c.Policies, i)
+}
+
+func authorize(name string, policies []Policy, i *discordgo.InteractionCreate) error {
+	for _, p := range policies {
+		if !p.Allow(i) {
+			return &PermissionError{Command: name, Policy: p.Name}
+		}
+	}
+	return nil
+}
+
+// Available reports whether the user behind an interaction may run the
+// command and, in guilds, holds its DefaultMemberPermissions so Discord
+// shows it to them
+func (c *Command) Available(i *discordgo.InteractionCreate) bool {
+	if c.Authorize(i) != nil {
+		return false
+	}
+	if c.DefaultMemberPermissions == nil || i.Member == nil {
+		return true
+	}
+	perms := *c.DefaultMemberPermissions
+	return i.Member.Permissions&perms == perms || i.Member.Permissions&discordgo.PermissionAdministrator != 0
+}
+
+// Deny tells the user they may not run a command and records the attempt
+func Deny(s discord.Session, i *discordgo.InteractionCreate, err error) {
+	logger.Warn("Denied interaction",
+		"userID", InteractionUserID(i),
+		"guildID", i.GuildID,
+		"reason", err.Error())
+	if aerr := RecordDenied(i, err); aerr != nil {
+		logger.Error("Failed to record denied interaction", "error", aerr)
+	}
+
+	ReplyEphemeral(s, i, Text(i, "error.permission", err))
+}
+
+// RecordDenied writes a denied interaction to the permission_audit table
+func RecordDenied(i *discordgo.InteractionCreate, reason error) error {
+	if database.DB == nil {
+		return nil
+	}
+
+	command := ""
+	switch i.Type {
+	case discordgo.InteractionApplicationCommand:
+		command = i.ApplicationCommandData().Name
+	case discordgo.InteractionMessageComponent, discordgo.InteractionModalSubmit:
+		command = ComponentCustomID(i)
+	}
+
+	_, err := database.DB.Exec(
+		"INSERT INTO permission_audit (userId, guildId, channelId, command, reason, createdAt) VALUES (?, ?, ?, ?, ?, ?)",
+		InteractionUserID(i), i.GuildID, i.ChannelID, command, reason.Error(), time.Now().Unix(),
+	)
+	return err
+}
+
+// InteractionUserID returns the ID of the user behind an interaction in a guild or DM
+func InteractionUserID(i *discordgo.InteractionCreate) string {
+	if i.Member != nil && i.Member.User != nil {
+		return i.Member.User.ID
+	}
+	if i.User != nil {
+		return i.User.ID
+	}
+	return ""
+}
@@ -0,0 +1,109 @@
+package commands_test
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/leeineian/minder/internal/commands"
+	"github.com/leeineian/minder/internal/database"
+	"github.com/leeineian/minder/internal/database/dbtest"
+	"github.com/leeineian/minder/internal/discord/discordtest"
+	"github.com/leeineian/minder/internal/logger"
+)
+
+func TestMain(m *testing.M) {
+	logger.Init("error")
+	os.Exit(m.Run())
+}
+
+func member(userID string, perms int64, roles ...string) *discordgo.InteractionCreate {
+	return discordtest.Command("debug", nil, discordtest.From(userID), discordtest.WithPermissions(perms), discordtest.WithRoles(roles...))
+}
+
+func dm(userID string) *discordgo.InteractionCreate {
+	return discordtest.Command("debug", nil, discordtest.From(userID), discordtest.InDM())
+}
+
+func TestPolicies(t *testing.T) {
+	commands.SetOwnerID("owner")
+	defer commands.SetOwnerID("")
+
+	tests := []struct {
+		name   string
+		policy commands.Policy
+		i      *discordgo.InteractionCreate
+		want   bool
+	}{
+		{"owner in guild", commands.OwnerOnly(), member("owner", 0), true},
+		{"owner in DM", commands.OwnerOnly(), dm("owner"), true},
+		{"not owner", commands.OwnerOnly(), member("someone", discordgo.PermissionAdministrator), false},
+		{"admin", commands.GuildAdmin(), member("someone", discordgo.PermissionAdministrator|discordgo.PermissionSendMessages), true},
+		{"not admin", commands.GuildAdmin(), member("someone", discordgo.PermissionManageGuild), false},
+		{"admin in DM", commands.GuildAdmin(), dm("someone"), false},
+		{"has role", commands.RequireRole("r1", "r2"), member("someone", 0, "r0", "r2"), true},
+		{"missing role", commands.RequireRole("r1"), member("someone", 0, "r0"), false},
+		{"any of, second", commands.AnyOf(commands.OwnerOnly(), commands.RequireRole("r1")), member("someone", 0, "r1"), true},
+		{"any of, none", commands.AnyOf(commands.OwnerOnly(), commands.GuildAdmin()), member("someone", 0), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.Allow(tt.i); got != tt.want {
+				t.Errorf("%s.Allow() = %v, want %v", tt.policy.Name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOwnerOnlyWithoutOwner(t *testing.T) {
+	commands.SetOwnerID("")
+	if commands.OwnerOnly().Allow(dm("")) {
+		t.Error("OwnerOnly must deny everyone when no owner is configured")
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	commands.SetOwnerID("owner")
+	defer commands.SetOwnerID("")
+
+	cmd := &commands.Command{
+		Name:     "debug",
+		Policies: []commands.Policy{commands.GuildAdmin(), commands.OwnerOnly()},
+	}
+
+	if err := cmd.Authorize(member("owner", discordgo.PermissionAdministrator)); err != nil {
+		t.Errorf("Expected the owner to be allowed, got %v", err)
+	}
+
+	err := cmd.Authorize(member("someone", discordgo.PermissionAdministrator))
+	var perr *commands.PermissionError
+	if !errors.As(err, &perr) || perr.Policy != "owner only" || perr.Command != "debug" {
+		t.Errorf("Expected an owner only denial, got %v", err)
+	}
+
+	if err := (&commands.Command{Name: "open"}).Authorize(dm("anyone")); err != nil {
+		t.Errorf("Commands without policies must allow everyone, got %v", err)
+	}
+}
+
+func TestRecordDenied(t *testing.T) {
+	dbtest.Setup(t)
+
+	i := member("someone", 0)
+	i.ChannelID = "c1"
+	if err := commands.RecordDenied(i, &commands.PermissionError{Command: "debug", Policy: "owner only"}); err != nil {
+		t.Fatalf("RecordDenied failed: %v", err)
+	}
+
+	var userID, guildID, channelID, command, reason string
+	err := database.DB.QueryRow("SELECT userId, guildId, channelId, command, reason FROM permission_audit").
+		Scan(&userID, &guildID, &channelID, &command, &reason)
+	if err != nil {
+		t.Fatalf("Failed to read audit entry: %v", err)
+	}
+	if userID != "someone" || guildID != "g1" || channelID != "c1" || command != "debug" || reason != "/debug is owner only" {
+		t.Errorf("Unexpected audit entry: %s %s %s %s %q", userID, guildID, channelID, command, reason)
+	}
+}
@@ -1,17 +1,45 @@
 package commands
 
 import (
+	"sort"
+	"strings"
+
 	"github.com/bwmarrin/discordgo"
-	"github.com/leeineian/minder/internal/config"
-	"github.com/leeineian/minder/internal/logger"
+	"github.com/leeineian/minder/internal/i18n"
 )
 
 // Command definition
 type Command struct {
+	// Type defaults to a slash command; user and message commands appear in
+	// context menus, take no options and have no description
+	Type        discordgo.ApplicationCommandType
 	Name        string
 	Description string
 	Options     []*discordgo.ApplicationCommandOption
-	Handler     func(s *discordgo.Session, i *discordgo.InteractionCreate)
+	Handler     HandlerFunc
+
+	// Middleware runs around Handler, inside the global middleware registered with Use
+	Middleware []Middleware
+	// Ephemeral makes automatic defers visible only to the user
+	Ephemeral bool
+	// Cooldowns limit how often the command runs; all of them must allow a run
+	Cooldowns []Cooldown
+	// Autocomplete maps an option, as "<option>" or "<subcommand path> <option>",
+	// to its choices provider; the option must also set Autocomplete
+	Autocomplete map[string]AutocompleteFunc
+
+	// Policies must all allow an interaction before Handler runs
+	Policies []Policy
+	// DefaultMemberPermissions hides the command from members without these
+	// permissions until a server admin overrides it; it is not a security check
+	DefaultMemberPermissions *int64
+	// Guilds limits the command to these guilds; commands without Guilds
+	// are global, or registered in GUILD_ID when it is set
+	Guilds []string
+
+	// Examples are invocations /help shows for the command and its
+	// subcommands, e.g. "/reminder set message:Stretch when:30m"
+	Examples []string
 }
 
 // Registry stores all available commands
@@ -22,26 +50,99 @@ func Register(cmd *Command) {
 	Registry[cmd.Name] = cmd
 }
 
-// SyncCommands registers commands with Discord
-func SyncCommands(s *discordgo.Session, cfg *config.Config) error {
-	logger.Info("Syncing commands", "count", len(Registry), "guildID", cfg.GuildId)
-
-	cmds := make([]*discordgo.ApplicationCommand, 0, len(Registry))
+// Commands returns the registered commands sorted by name
+func Commands() []*Command {
+	cmds := make([]*Command, 0, len(Registry))
 	for _, cmd := range Registry {
-		cmds = append(cmds, &discordgo.ApplicationCommand{
-			Name:        cmd.Name,
-			Description: cmd.Description,
-			Options:     cmd.Options,
-		})
+		cmds = append(cmds, cmd)
+	}
+	sort.Slice(cmds, func(a, b int) bool { return cmds[a].Name < cmds[b].Name })
+	return cmds
+}
+
+// LocalizedName returns the command's name in locale
+func (c *Command) LocalizedName(locale discordgo.Locale) string {
+	if name, ok := i18n.Find(locale, c.localizationKey()+".name"); ok {
+		return name
 	}
+	return c.Name
+}
 
-	// Bulk overwrite commands (for guild or global)
-	// If GuildID is set, use it for instant updates
-	if cfg.GuildId != "" {
-		_, err := s.ApplicationCommandBulkOverwrite(s.State.User.ID, cfg.GuildId, cmds)
-		return err
+// Describe returns the description of the command, or of the subcommand or
+// option at path below it, in locale
+func (c *Command) Describe(locale discordgo.Locale, path ...string) string {
+	description := c.Description
+	options := c.Options
+	for _, name := range path {
+		description = ""
+		for _, o := range options {
+			if o.Name == name {
+				description, options = o.Description, o.Options
+				break
+			}
+		}
 	}
+	key := c.localizationKey()
+	if len(path) > 0 {
+		key += "." + strings.Join(path, ".")
+	}
+	if msg, ok := i18n.Find(locale, key+".description"); ok {
+		return msg
+	}
+	return description
+}
 
-	_, err := s.ApplicationCommandBulkOverwrite(s.State.User.ID, "", cmds)
-	return err
+// localizationKey returns the prefix of the command's i18n keys, e.g.
+// "cmd.reminder" or "cmd.remind-me-about-this"
+func (c *Command) localizationKey() string {
+	return "cmd." + strings.ReplaceAll(strings.ToLower(c.Name), " ", "-")
+}
+
+// applicationCommand returns the definition Discord registers for the
+// command, with the translations of its name, description and options from
+// the i18n catalogs
+func (c *Command) applicationCommand() *discordgo.ApplicationCommand {
+	key := c.localizationKey()
+	def := &discordgo.ApplicationCommand{
+		Type:                     c.Type,
+		Name:                     c.Name,
+		NameLocalizations:        localizations(key + ".name"),
+		DefaultMemberPermissions: c.DefaultMemberPermissions,
+	}
+	if c.Type == 0 || c.Type == discordgo.ChatApplicationCommand {
+		def.Type = discordgo.ChatApplicationCommand
+		def.Description = c.Description
+		def.DescriptionLocalizations = localizations(key + ".description")
+		def.Options = localizeOptions(key, c.Options)
+	}
+	return def
+}
+
+// localizeOptions returns copies of options with the translations keyed
+// "<prefix>.<option>.name" and "<prefix>.<option>.description"
+func localizeOptions(prefix string, options []*discordgo.ApplicationCommandOption) []*discordgo.ApplicationCommandOption {
+	if options == nil {
+		return nil
+	}
+	out := make([]*discordgo.ApplicationCommandOption, len(options))
+	for idx, o := range options {
+		key := prefix + "." + o.Name
+		opt := *o
+		if l := i18n.Localizations(key + ".name"); l != nil {
+			opt.NameLocalizations = l
+		}
+		if l := i18n.Localizations(key + ".description"); l != nil {
+			opt.DescriptionLocalizations = l
+		}
+		opt.Options = localizeOptions(key, o.Options)
+		out[idx] = &opt
+	}
+	return out
+}
+
+func localizations(key string) *map[discordgo.Locale]string {
+	if l := i18n.Localizations(key); l != nil {
+		return &l
+	}
+	return nil
 }
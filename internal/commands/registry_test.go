@@ -5,6 +5,7 @@ import (
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/leeineian/minder/internal/commands"
+	"github.com/leeineian/minder/internal/discord"
 )
 
 func TestRegister(t *testing.T) {
@@ -16,7 +17,7 @@ func TestRegister(t *testing.T) {
 		Name:        "testcmd",
 		Description: "A test command",
 		Options:     nil,
-		Handler:     func(s *discordgo.Session, i *discordgo.InteractionCreate) {},
+		Handler:     func(s discord.Session, i *discordgo.InteractionCreate) {},
 	}
 
 	// Register the command
@@ -47,7 +48,7 @@ func TestRegisterMultiple(t *testing.T) {
 		cmd := &commands.Command{
 			Name:        string(rune('a' + i)),
 			Description: "Command " + string(rune('a'+i)),
-			Handler:     func(s *discordgo.Session, i *discordgo.InteractionCreate) {},
+			Handler:     func(s discord.Session, i *discordgo.InteractionCreate) {},
 		}
 		commands.Register(cmd)
 	}
@@ -66,7 +67,7 @@ func TestRegisterOverwrite(t *testing.T) {
 	cmd1 := &commands.Command{
 		Name:        "overwrite",
 		Description: "First version",
-		Handler:     func(s *discordgo.Session, i *discordgo.InteractionCreate) {},
+		Handler:     func(s discord.Session, i *discordgo.InteractionCreate) {},
 	}
 	commands.Register(cmd1)
 
@@ -74,7 +75,7 @@ func TestRegisterOverwrite(t *testing.T) {
 	cmd2 := &commands.Command{
 		Name:        "overwrite",
 		Description: "Second version",
-		Handler:     func(s *discordgo.Session, i *discordgo.InteractionCreate) {},
+		Handler:     func(s discord.Session, i *discordgo.InteractionCreate) {},
 	}
 	commands.Register(cmd2)
 
@@ -0,0 +1,90 @@
+package reminder
+
+import (
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/leeineian/minder/internal/commands"
+	"github.com/leeineian/minder/internal/discord"
+)
+
+// maxNote keeps a reminder's note and message link within the 500 character limit
+const maxNote = 400
+
+// RemindMessageCmd is the "Remind me about this" message context menu command
+var RemindMessageCmd = &commands.Command{
+	Type:      discordgo.MessageApplicationCommand,
+	Name:      "Remind me about this",
+	Handler:   commands.WithOptions(handleRemindMessage),
+	Ephemeral: true,
+}
+
+// remindMessageState identifies the message a reminder modal is for; the
+// channel is the one the modal is submitted in
+type remindMessageState struct {
+	MessageID string `json:"m"`
+}
+
+// handleRemindMessage asks when to remind, with the message text as an editable note
+func handleRemindMessage(s discord.Session, i *discordgo.InteractionCreate, _ struct{}) error {
+	msg, err := commands.TargetMessage(i)
+	if err != nil {
+		return err
+	}
+
+	note := []rune(msg.Content)
+	if len(note) > maxNote {
+		note = append(note[:maxNote-1], '…')
+	}
+
+	return commands.Respond(s, i, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseModal,
+		Data: &discordgo.InteractionResponseData{
+			CustomID: commands.MustCustomID("reminder", "message", remindMessageState{MessageID: msg.ID}),
+			Title:    commands.Text(i, "reminder.modal_title"),
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+					discordgo.TextInput{
+						CustomID:    "when",
+						Label:       commands.Text(i, "reminder.modal_when"),
+						Style:       discordgo.TextInputShort,
+						Placeholder: commands.Text(i, "reminder.modal_when_placeholder"),
+						Required:    true,
+						MaxLength:   50,
+					},
+				}},
+				discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+					discordgo.TextInput{
+						CustomID:  "note",
+						Label:     commands.Text(i, "reminder.modal_note"),
+						Style:     discordgo.TextInputParagraph,
+						Value:     string(note),
+						Required:  false,
+						MaxLength: maxNote,
+					},
+				}},
+			},
+		},
+	})
+}
+
+// handleRemindMessageSubmit sets a reminder linking back to the message
+func handleRemindMessageSubmit(s discord.Session, i *discordgo.InteractionCreate, state remindMessageState) error {
+	values := commands.ModalValues(i)
+
+	message := commands.MessageLink(i.GuildID, i.ChannelID, state.MessageID)
+	if note := strings.TrimSpace(values["note"]); note != "" {
+		message = note + "\n" + message
+	}
+
+	dueAt, err := createReminder(s, i, message, values["when"])
+	if err != nil {
+		return err
+	}
+	return commands.ReplyEphemeral(s, i, commands.Text(i, "reminder.set", dueAt.Unix()))
+}
+
+func init() {
+	commands.Register(RemindMessageCmd)
+	commands.HandleComponent("reminder", "message", handleRemindMessageSubmit)
+}
@@ -1,13 +1,17 @@
 package reminder
 
 import (
+	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/leeineian/minder/internal/commands"
 	"github.com/leeineian/minder/internal/daemons/scheduler"
 	"github.com/leeineian/minder/internal/database"
+	"github.com/leeineian/minder/internal/discord"
+	"github.com/leeineian/minder/internal/i18n"
 )
 
 var ReminderCmd = &commands.Command{
@@ -38,73 +42,69 @@ var ReminderCmd = &commands.Command{
 			Name:        "list",
 			Description: "List your active reminders",
 		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "delete",
+			Description: "Delete one of your reminders",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:         discordgo.ApplicationCommandOptionInteger,
+					Name:         "id",
+					Description:  "Which reminder?",
+					Required:     true,
+					Autocomplete: true,
+				},
+			},
+		},
+	},
+	Handler: commands.Router{
+		"set":    commands.WithOptions(handleSet),
+		"list":   commands.WithOptions(handleList),
+		"delete": commands.WithOptions(handleDelete),
+	}.Handle,
+	Autocomplete: map[string]commands.AutocompleteFunc{
+		"delete id": reminderChoices,
+	},
+	Examples: []string{
+		"/reminder set message:Stretch when:30m",
+		"/reminder set message:Stand-up meeting when:1h30m",
+		"/reminder list",
+		"/reminder delete id:3",
 	},
-	Handler: handleReminder,
+
+	Ephemeral: true,
 }
 
-func handleReminder(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	options := i.ApplicationCommandData().Options
-	subCmd := options[0].Name
+// reminderSetOptions describe a new reminder
+type reminderSetOptions struct {
+	Message string `option:"message,required,minlen=1,maxlen=500"`
+	When    string `option:"when,required"`
+}
 
-	switch subCmd {
-	case "set":
-		handleSet(s, i, options[0].Options)
-	case "list":
-		handleList(s, i)
+func handleSet(s discord.Session, i *discordgo.InteractionCreate, opts reminderSetOptions) error {
+	dueAt, err := createReminder(s, i, opts.Message, opts.When)
+	if err != nil {
+		return err
 	}
+	return commands.ReplyEphemeral(s, i, commands.Text(i, "reminder.set", dueAt.Unix()))
 }
 
-func handleSet(s *discordgo.Session, i *discordgo.InteractionCreate, options []*discordgo.ApplicationCommandInteractionDataOption) {
-	message := options[0].StringValue()
-	when := options[1].StringValue()
-
-	// Get user ID safely (works in both guild and DM)
-	userID := getUserID(i)
+// createReminder saves and schedules a reminder for the user behind an
+// interaction, delivered in its channel if their DMs are closed
+func createReminder(s discord.Session, i *discordgo.InteractionCreate, message, when string) (time.Time, error) {
+	// Works in both guilds and DMs
+	userID := commands.InteractionUserID(i)
 	if userID == "" {
-		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-			Type: discordgo.InteractionResponseChannelMessageWithSource,
-			Data: &discordgo.InteractionResponseData{
-				Content: "❌ Could not identify user",
-				Flags:   discordgo.MessageFlagsEphemeral,
-			},
-		})
-		return
+		return time.Time{}, i18n.Errorf("reminder.no_user")
 	}
 
 	// Parse time (simple implementation - you can add chrono-like parsing)
-	dueAt, err := parseTime(when)
+	dueAt, err := parseTime(strings.TrimSpace(when))
 	if err != nil {
-		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-			Type: discordgo.InteractionResponseChannelMessageWithSource,
-			Data: &discordgo.InteractionResponseData{
-				Content: fmt.Sprintf("⚠️ Could not parse time: %v", err),
-				Flags:   discordgo.MessageFlagsEphemeral,
-			},
-		})
-		return
+		return time.Time{}, i18n.Errorf("reminder.bad_time", err)
 	}
-
 	if dueAt.Before(time.Now()) {
-		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-			Type: discordgo.InteractionResponseChannelMessageWithSource,
-			Data: &discordgo.InteractionResponseData{
-				Content: "⚠️ That time is in the past!",
-				Flags:   discordgo.MessageFlagsEphemeral,
-			},
-		})
-		return
-	}
-
-	// Validate message length
-	if len(message) > 500 {
-		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-			Type: discordgo.InteractionResponseChannelMessageWithSource,
-			Data: &discordgo.InteractionResponseData{
-				Content: "❌ Message too long (max 500 characters)",
-				Flags:   discordgo.MessageFlagsEphemeral,
-			},
-		})
-		return
+		return time.Time{}, i18n.Errorf("reminder.past")
 	}
 
 	// Save to DB
@@ -116,41 +116,20 @@ func handleSet(s *discordgo.Session, i *discordgo.InteractionCreate, options []*
 		dueAt.Unix(),
 	)
 	if err != nil {
-		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-			Type: discordgo.InteractionResponseChannelMessageWithSource,
-			Data: &discordgo.InteractionResponseData{
-				Content: "❌ Failed to save reminder",
-				Flags:   discordgo.MessageFlagsEphemeral,
-			},
-		})
-		return
+		return time.Time{}, i18n.Errorf("reminder.save_failed")
 	}
 
 	id, _ := result.LastInsertId()
 
 	// Schedule
 	scheduler.ScheduleReminder(s, userID, i.ChannelID, message, int(id), dueAt)
-
-	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-		Type: discordgo.InteractionResponseChannelMessageWithSource,
-		Data: &discordgo.InteractionResponseData{
-			Content: fmt.Sprintf("✅ Reminder set for <t:%d:R>", dueAt.Unix()),
-			Flags:   discordgo.MessageFlagsEphemeral,
-		},
-	})
+	return dueAt, nil
 }
 
-func handleList(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	userID := getUserID(i)
+func handleList(s discord.Session, i *discordgo.InteractionCreate, _ struct{}) error {
+	userID := commands.InteractionUserID(i)
 	if userID == "" {
-		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-			Type: discordgo.InteractionResponseChannelMessageWithSource,
-			Data: &discordgo.InteractionResponseData{
-				Content: "❌ Could not identify user",
-				Flags:   discordgo.MessageFlagsEphemeral,
-			},
-		})
-		return
+		return i18n.Errorf("reminder.no_user")
 	}
 
 	rows, err := database.DB.Query(
@@ -158,14 +137,7 @@ func handleList(s *discordgo.Session, i *discordgo.InteractionCreate) {
 		userID,
 	)
 	if err != nil {
-		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-			Type: discordgo.InteractionResponseChannelMessageWithSource,
-			Data: &discordgo.InteractionResponseData{
-				Content: "❌ Failed to fetch reminders",
-				Flags:   discordgo.MessageFlagsEphemeral,
-			},
-		})
-		return
+		return i18n.Errorf("reminder.fetch_failed")
 	}
 	defer rows.Close()
 
@@ -181,58 +153,75 @@ func handleList(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	}
 
 	if len(reminders) == 0 {
-		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-			Type: discordgo.InteractionResponseChannelMessageWithSource,
-			Data: &discordgo.InteractionResponseData{
-				Content: "You have no active reminders.",
-				Flags:   discordgo.MessageFlagsEphemeral,
-			},
-		})
-		return
+		return commands.ReplyEphemeral(s, i, commands.Text(i, "reminder.list_empty"))
 	}
 
-	content := "📋 **Your Reminders:**\n" + joinStrings(reminders, "\n")
-	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-		Type: discordgo.InteractionResponseChannelMessageWithSource,
-		Data: &discordgo.InteractionResponseData{
-			Content: content,
-			Flags:   discordgo.MessageFlagsEphemeral,
-		},
-	})
+	return commands.ReplyEphemeral(s, i, commands.Text(i, "reminder.list_title")+"\n"+strings.Join(reminders, "\n"))
 }
 
-// Simple time parser (supports "in 30m", "2h", etc.)
-func parseTime(when string) (time.Time, error) {
-	duration, err := time.ParseDuration(when)
-	if err == nil {
-		return time.Now().Add(duration), nil
+// reminderDeleteOptions select a reminder by ID
+type reminderDeleteOptions struct {
+	ID int `option:"id,required"`
+}
+
+func handleDelete(s discord.Session, i *discordgo.InteractionCreate, opts reminderDeleteOptions) error {
+	result, err := database.DB.Exec(
+		"UPDATE reminders SET active = 0 WHERE id = ? AND userId = ? AND active = 1",
+		opts.ID,
+		commands.InteractionUserID(i),
+	)
+	if err != nil {
+		return i18n.Errorf("reminder.delete_failed")
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return i18n.Errorf("reminder.not_found", opts.ID)
 	}
 
-	// If not a duration, try parsing as absolute time
-	// For now, return an error encouraging duration format
-	return time.Time{}, fmt.Errorf("use duration format like '30m', '2h', '1h30m'")
+	scheduler.CancelReminder(opts.ID)
+	return commands.ReplyEphemeral(s, i, commands.Text(i, "reminder.deleted", opts.ID))
 }
 
-func joinStrings(strs []string, sep string) string {
-	result := ""
-	for i, s := range strs {
-		if i > 0 {
-			result += sep
+// reminderChoices suggests the user's active reminders, soonest first
+func reminderChoices(ctx context.Context, i *discordgo.InteractionCreate, value string) []*discordgo.ApplicationCommandOptionChoice {
+	rows, err := database.DB.QueryContext(ctx,
+		"SELECT id, message, time FROM reminders WHERE userId = ? AND active = 1 ORDER BY time ASC",
+		commands.InteractionUserID(i),
+	)
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	for rows.Next() {
+		var id int
+		var message string
+		var timeUnix int64
+		if err := rows.Scan(&id, &message, &timeUnix); err != nil {
+			continue // Skip invalid rows
+		}
+		due := commands.Text(i, "reminder.choice_soon")
+		if d := time.Until(time.Unix(timeUnix, 0)).Round(time.Minute); d >= time.Minute {
+			due = strings.TrimSuffix(d.String(), "0s")
 		}
-		result += s
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
+			Name:  commands.Text(i, "reminder.choice", id, due, message),
+			Value: id,
+		})
 	}
-	return result
+	return commands.MatchChoices(value, choices)
 }
 
-// getUserID safely extracts user ID from interaction (works in guild and DM)
-func getUserID(i *discordgo.InteractionCreate) string {
-	if i.Member != nil && i.Member.User != nil {
-		return i.Member.User.ID
-	}
-	if i.User != nil {
-		return i.User.ID
+// Simple time parser (supports "in 30m", "2h", etc.)
+func parseTime(when string) (time.Time, error) {
+	duration, err := time.ParseDuration(when)
+	if err == nil {
+		return time.Now().Add(duration), nil
 	}
-	return ""
+
+	// If not a duration, try parsing as absolute time
+	// For now, return an error encouraging duration format
+	return time.Time{}, i18n.Errorf("reminder.time_format")
 }
 
 func init() {
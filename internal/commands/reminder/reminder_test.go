@@ -0,0 +1,126 @@
+package reminder_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/leeineian/minder/internal/commands"
+	_ "github.com/leeineian/minder/internal/commands/reminder"
+	"github.com/leeineian/minder/internal/daemons/scheduler"
+	"github.com/leeineian/minder/internal/database"
+	"github.com/leeineian/minder/internal/database/dbtest"
+	"github.com/leeineian/minder/internal/discord/discordtest"
+	"github.com/leeineian/minder/internal/logger"
+)
+
+func TestMain(m *testing.M) {
+	logger.Init("error")
+	os.Exit(m.Run())
+}
+
+// reminder runs a /reminder subcommand and returns the reply
+func reminder(t *testing.T, sub *discordgo.ApplicationCommandInteractionDataOption) string {
+	t.Helper()
+	return discordtest.Reply(t, commands.Dispatch, discordtest.Command("reminder", discordtest.Args(sub)))
+}
+
+func TestSetListDelete(t *testing.T) {
+	dbtest.Setup(t)
+
+	if got := reminder(t, discordtest.Sub("set", discordtest.String("message", "Stretch"), discordtest.String("when", "30m"))); !strings.HasPrefix(got, "✅ Reminder set for <t:") {
+		t.Errorf("Unexpected set reply %q", got)
+	}
+	if got := reminder(t, discordtest.Sub("list")); !strings.Contains(got, "[1]") || !strings.Contains(got, "Stretch") {
+		t.Errorf("Expected the reminder to be listed, got %q", got)
+	}
+	if got := reminder(t, discordtest.Sub("delete", discordtest.Int("id", 1))); got != "🗑️ Deleted reminder #1" {
+		t.Errorf("Unexpected delete reply %q", got)
+	}
+	if got := reminder(t, discordtest.Sub("list")); got != "You have no active reminders." {
+		t.Errorf("Expected no reminders left, got %q", got)
+	}
+}
+
+func TestSetInvalidTime(t *testing.T) {
+	dbtest.Setup(t)
+
+	got := reminder(t, discordtest.Sub("set", discordtest.String("message", "Stretch"), discordtest.String("when", "soonish")))
+	if !strings.Contains(got, "Could not parse time") {
+		t.Errorf("Expected a parse error, got %q", got)
+	}
+}
+
+func TestDeleteOthersReminder(t *testing.T) {
+	dbtest.Setup(t)
+	reminder(t, discordtest.Sub("set", discordtest.String("message", "Stretch"), discordtest.String("when", "30m")))
+
+	got := discordtest.Reply(t, commands.Dispatch, discordtest.Command("reminder", discordtest.Args(
+		discordtest.Sub("delete", discordtest.Int("id", 1)),
+	), discordtest.From("u2")))
+	if !strings.Contains(got, "no active reminder #1") {
+		t.Errorf("Expected another user's reminder to be untouchable, got %q", got)
+	}
+	reminder(t, discordtest.Sub("delete", discordtest.Int("id", 1)))
+}
+
+func TestDeleteAutocomplete(t *testing.T) {
+	dbtest.Setup(t)
+	reminder(t, discordtest.Sub("set", discordtest.String("message", "Stretch"), discordtest.String("when", "30m")))
+	defer reminder(t, discordtest.Sub("delete", discordtest.Int("id", 1)))
+
+	s := discordtest.NewSession()
+	commands.DispatchAutocomplete(s, discordtest.Autocomplete("reminder", discordtest.Args(
+		discordtest.Sub("delete", discordtest.Focused("id", "")),
+	)))
+
+	responses := s.CallsTo("InteractionRespond")
+	if len(responses) != 1 || len(responses[0].Response.Data.Choices) != 1 {
+		t.Fatalf("Expected one suggestion, got %+v", responses)
+	}
+	if choice := responses[0].Response.Data.Choices[0]; !strings.HasPrefix(choice.Name, "#1 in 30m") || !strings.HasSuffix(choice.Name, "Stretch") {
+		t.Errorf("Unexpected suggestion %q", choice.Name)
+	}
+}
+
+func TestSnoozeWindow(t *testing.T) {
+	dbtest.Setup(t)
+	now := time.Now()
+	expired := now.Add(-scheduler.SnoozeWindow - time.Hour).Unix()
+	database.DB.Exec("INSERT INTO reminders (id, userId, channelId, message, time, active, deliveredAt) VALUES (1, ?, 'c1', 'Stretch', 0, 0, ?)", discordtest.UserID, now.Unix())
+	database.DB.Exec("INSERT INTO reminders (id, userId, channelId, message, time, active, deliveredAt) VALUES (2, ?, 'c1', 'Drink', 0, 0, ?)", discordtest.UserID, expired)
+	database.DB.Exec("INSERT INTO reminders (id, userId, channelId, message, time, active) VALUES (3, ?, 'c1', 'Deleted', 0, 0)", discordtest.UserID)
+
+	snooze := func(id int) string {
+		t.Helper()
+		state := struct {
+			ID      int `json:"r"`
+			Minutes int `json:"m"`
+		}{id, 10}
+		return discordtest.Reply(t, commands.DispatchComponent, discordtest.Button(commands.MustCustomID("reminder", "snooze", state)))
+	}
+	if got := snooze(1); !strings.HasPrefix(got, "💤 Snoozed until <t:") {
+		t.Errorf("Expected a recent reminder to be snoozed, got %q", got)
+	}
+	scheduler.CancelReminder(1)
+	if got := snooze(2); !strings.Contains(got, "no longer exists") {
+		t.Errorf("Expected an expired reminder to be gone, got %q", got)
+	}
+
+	if err := scheduler.PruneReminders(now); err != nil {
+		t.Fatalf("Failed to prune reminders: %v", err)
+	}
+	var ids []int
+	rows, _ := database.DB.Query("SELECT id FROM reminders ORDER BY id")
+	for rows.Next() {
+		var id int
+		rows.Scan(&id)
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if len(ids) != 1 || ids[0] != 1 {
+		t.Errorf("Expected only the snoozed reminder to be kept, got %v", ids)
+	}
+}
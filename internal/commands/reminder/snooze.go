@@ -0,0 +1,90 @@
+package reminder
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/leeineian/minder/internal/commands"
+	"github.com/leeineian/minder/internal/daemons/scheduler"
+	"github.com/leeineian/minder/internal/database"
+	"github.com/leeineian/minder/internal/discord"
+	"github.com/leeineian/minder/internal/i18n"
+)
+
+// snoozeState is carried by the snooze buttons of a delivered reminder
+type snoozeState struct {
+	ID      int `json:"r"`
+	Minutes int `json:"m"`
+}
+
+// snoozeOptions are the snooze buttons offered on delivered reminders
+var snoozeOptions = []struct {
+	label   string
+	minutes int
+}{
+	{"10 min", 10},
+	{"1 hour", 60},
+	{"Tomorrow", 24 * 60},
+}
+
+// snoozeButtons returns the buttons sent with a delivered reminder
+func snoozeButtons(id int) []discordgo.MessageComponent {
+	buttons := make([]discordgo.MessageComponent, 0, len(snoozeOptions))
+	for _, o := range snoozeOptions {
+		buttons = append(buttons, discordgo.Button{
+			Label:    o.label,
+			Style:    discordgo.SecondaryButton,
+			CustomID: commands.MustCustomID("reminder", "snooze", snoozeState{ID: id, Minutes: o.minutes}),
+			Emoji:    &discordgo.ComponentEmoji{Name: "💤"},
+		})
+	}
+	return []discordgo.MessageComponent{discordgo.ActionsRow{Components: buttons}}
+}
+
+// handleSnooze reschedules a delivered reminder and removes its buttons
+func handleSnooze(s discord.Session, i *discordgo.InteractionCreate, state snoozeState) error {
+	if state.Minutes <= 0 {
+		return commands.ErrInvalidCustomID
+	}
+
+	// Delivered reminders can only be snoozed within the snooze window
+	var userID, channelID, message string
+	err := database.DB.QueryRow(
+		"SELECT userId, channelId, message FROM reminders WHERE id = ? AND (active = 1 OR deliveredAt >= ?)",
+		state.ID, time.Now().Add(-scheduler.SnoozeWindow).Unix(),
+	).Scan(&userID, &channelID, &message)
+	if errors.Is(err, sql.ErrNoRows) {
+		return i18n.Errorf("reminder.snooze_gone")
+	}
+	if err != nil {
+		return i18n.Errorf("reminder.snooze_load_failed")
+	}
+	if userID != commands.InteractionUserID(i) {
+		return i18n.Errorf("reminder.snooze_not_owner")
+	}
+
+	dueAt := time.Now().Add(time.Duration(state.Minutes) * time.Minute)
+	if _, err := database.DB.Exec("UPDATE reminders SET time = ?, active = 1 WHERE id = ?", dueAt.Unix(), state.ID); err != nil {
+		return i18n.Errorf("reminder.snooze_failed")
+	}
+	scheduler.ScheduleReminder(s, userID, channelID, message, state.ID, dueAt)
+
+	content := commands.Text(i, "reminder.snoozed", dueAt.Unix())
+	if i.Message != nil {
+		content = i.Message.Content + "\n" + content
+	}
+	return commands.Respond(s, i, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Content:    content,
+			Components: []discordgo.MessageComponent{},
+		},
+	})
+}
+
+func init() {
+	scheduler.ReminderComponents = snoozeButtons
+	commands.HandleComponent("reminder", "snooze", handleSnooze)
+}
@@ -0,0 +1,188 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/leeineian/minder/internal/discord"
+	"github.com/leeineian/minder/internal/i18n"
+)
+
+// interactionState tracks how an in-flight interaction was acknowledged so
+// later responses, including AutoDefer's and error replies, still reach the user
+type interactionState struct {
+	mu sync.Mutex
+	// response is the type of the initial response, zero until one is sent
+	response discordgo.InteractionResponseType
+}
+
+// inflight maps interaction IDs dispatched through the middleware chain to their state
+var inflight sync.Map
+
+func track(i *discordgo.InteractionCreate) func() {
+	inflight.Store(i.ID, &interactionState{})
+	return func() { inflight.Delete(i.ID) }
+}
+
+func stateOf(i *discordgo.InteractionCreate) *interactionState {
+	if v, ok := inflight.Load(i.ID); ok {
+		return v.(*interactionState)
+	}
+	return nil
+}
+
+// Respond sends a response to an interaction. Handlers should use it instead
+// of s.InteractionRespond: once the interaction has been acknowledged, e.g. by
+// AutoDefer, deferrals become no-ops, messages edit a deferred response or are
+// sent as follow-ups, and message updates edit the deferred message. A message
+// that edits a deferred response keeps the visibility of the defer.
+func Respond(s discord.Session, i *discordgo.InteractionCreate, resp *discordgo.InteractionResponse) error {
+	st := stateOf(i)
+	if st == nil {
+		return s.InteractionRespond(i.Interaction, resp)
+	}
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if st.response == 0 {
+		err := s.InteractionRespond(i.Interaction, resp)
+		if err == nil {
+			st.response = resp.Type
+		}
+		return err
+	}
+
+	deferred := st.response == discordgo.InteractionResponseDeferredChannelMessageWithSource ||
+		st.response == discordgo.InteractionResponseDeferredMessageUpdate
+	switch resp.Type {
+	case discordgo.InteractionResponseDeferredChannelMessageWithSource, discordgo.InteractionResponseDeferredMessageUpdate:
+		if deferred {
+			return nil
+		}
+	case discordgo.InteractionResponseChannelMessageWithSource:
+		if st.response == discordgo.InteractionResponseDeferredChannelMessageWithSource {
+			_, err := s.InteractionResponseEdit(i.Interaction, responseEdit(resp.Data))
+			return err
+		}
+		_, err := s.FollowupMessageCreate(i.Interaction, true, followup(resp.Data))
+		return err
+	case discordgo.InteractionResponseUpdateMessage:
+		if deferred {
+			_, err := s.InteractionResponseEdit(i.Interaction, responseEdit(resp.Data))
+			return err
+		}
+	}
+	return fmt.Errorf("cannot send response type %d after the interaction was acknowledged with type %d", resp.Type, st.response)
+}
+
+// deferInteraction acknowledges an interaction nothing has responded to yet
+func deferInteraction(s discord.Session, i *discordgo.InteractionCreate, ephemeral bool) (bool, error) {
+	st := stateOf(i)
+	if st == nil {
+		return false, nil
+	}
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if st.response != 0 {
+		return false, nil
+	}
+
+	resp := &discordgo.InteractionResponse{Type: discordgo.InteractionResponseDeferredChannelMessageWithSource}
+	if i.Type == discordgo.InteractionMessageComponent || i.Type == discordgo.InteractionModalSubmit {
+		resp.Type = discordgo.InteractionResponseDeferredMessageUpdate
+	} else if ephemeral {
+		resp.Data = &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral}
+	}
+	if err := s.InteractionRespond(i.Interaction, resp); err != nil {
+		return false, err
+	}
+	st.response = resp.Type
+	return true, nil
+}
+
+// Reply responds with a message everyone in the channel can see
+func Reply(s discord.Session, i *discordgo.InteractionCreate, content string) error {
+	return Respond(s, i, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Content: content},
+	})
+}
+
+// ReplyEphemeral responds with a message only the user can see
+func ReplyEphemeral(s discord.Session, i *discordgo.InteractionCreate, content string) error {
+	return Respond(s, i, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+// ReplyError tells the user what went wrong in an ephemeral message. Errors
+// that are or wrap an i18n.Localizer are shown in the user's language.
+func ReplyError(s discord.Session, i *discordgo.InteractionCreate, err error) error {
+	msg := err.Error()
+	var l i18n.Localizer
+	if errors.As(err, &l) {
+		msg = l.Localize(i.Locale)
+	}
+	return ReplyEphemeral(s, i, "❌ "+capitalize(msg))
+}
+
+// MaxMessageLength is the most characters Discord accepts in a message
+const MaxMessageLength = 2000
+
+// TruncateMessage cuts content to MaxMessageLength characters. Discord counts
+// the limit in characters, so the cut falls on a rune boundary.
+func TruncateMessage(content string) string {
+	if r := []rune(content); len(r) > MaxMessageLength {
+		return string(r[:MaxMessageLength-3]) + "..."
+	}
+	return content
+}
+
+// Text renders a message from the i18n catalogs in the interaction's locale
+func Text(i *discordgo.InteractionCreate, key string, args ...any) string {
+	return i18n.T(i.Locale, key, args...)
+}
+
+func capitalize(msg string) string {
+	if msg == "" {
+		return msg
+	}
+	r, size := utf8.DecodeRuneInString(msg)
+	return string(unicode.ToUpper(r)) + msg[size:]
+}
+
+func responseEdit(data *discordgo.InteractionResponseData) *discordgo.WebhookEdit {
+	if data == nil {
+		return &discordgo.WebhookEdit{}
+	}
+	return &discordgo.WebhookEdit{
+		Content:         &data.Content,
+		Embeds:          &data.Embeds,
+		Components:      &data.Components,
+		Files:           data.Files,
+		AllowedMentions: data.AllowedMentions,
+	}
+}
+
+func followup(data *discordgo.InteractionResponseData) *discordgo.WebhookParams {
+	if data == nil {
+		return &discordgo.WebhookParams{}
+	}
+	return &discordgo.WebhookParams{
+		Content:         data.Content,
+		Embeds:          data.Embeds,
+		Components:      data.Components,
+		Files:           data.Files,
+		AllowedMentions: data.AllowedMentions,
+		Flags:           data.Flags,
+	}
+}
@@ -0,0 +1,43 @@
+package commands
+
+import (
+	"github.com/bwmarrin/discordgo"
+	"github.com/leeineian/minder/internal/discord"
+	"github.com/leeineian/minder/internal/i18n"
+)
+
+// Router routes the subcommands of a command to their handlers. Keys are
+// subcommand paths as returned by Subcommand, e.g. "list" or
+// "webhook-looper start"; use its Handle method as the command's Handler.
+type Router map[string]HandlerFunc
+
+// Handle runs the handler of the invoked subcommand
+func (r Router) Handle(s discord.Session, i *discordgo.InteractionCreate) {
+	path, _ := Subcommand(i)
+	handler, ok := r[path]
+	if !ok {
+		err := i18n.Errorf("error.unknown_subcommand", path)
+		if path == "" {
+			err = i18n.Errorf("error.choose_subcommand")
+		}
+		ReplyError(s, i, err)
+		return
+	}
+	handler(s, i)
+}
+
+// WithOptions adapts a handler that takes its options decoded into T by Bind.
+// Decoding errors and errors the handler returns are sent to the user as
+// ephemeral replies, so handlers only respond themselves on success.
+func WithOptions[T any](handler func(s discord.Session, i *discordgo.InteractionCreate, opts T) error) HandlerFunc {
+	return func(s discord.Session, i *discordgo.InteractionCreate) {
+		var opts T
+		err := Bind(i, &opts)
+		if err == nil {
+			err = handler(s, i, opts)
+		}
+		if err != nil {
+			ReplyError(s, i, err)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package commands_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/leeineian/minder/internal/commands"
+	"github.com/leeineian/minder/internal/discord"
+	"github.com/leeineian/minder/internal/discord/discordtest"
+)
+
+func TestRouter(t *testing.T) {
+	var ran []string
+	route := func(name string) commands.HandlerFunc {
+		return func(s discord.Session, i *discordgo.InteractionCreate) { ran = append(ran, name) }
+	}
+	router := commands.Router{
+		"list":                 route("list"),
+		"webhook-looper start": route("start"),
+	}
+
+	s := discordtest.NewSession()
+	router.Handle(s, invoke(discordtest.Sub("list")))
+	router.Handle(s, invoke(discordtest.Group("webhook-looper", discordtest.Sub("start", discordtest.String("id", "x")))))
+	if strings.Join(ran, " ") != "list start" {
+		t.Errorf("Expected list and start to run, got %v", ran)
+	}
+	if calls := s.Calls(); len(calls) != 0 {
+		t.Errorf("Expected routed handlers to respond themselves, got %+v", calls)
+	}
+
+	// Neither panics on missing or unknown subcommands
+	router.Handle(s, invoke())
+	router.Handle(s, invoke(discordtest.Sub("delete")))
+	got := s.Texts()
+	if len(got) != 2 || !strings.Contains(got[0], "Choose a subcommand") || !strings.Contains(got[1], `Unknown subcommand "delete"`) {
+		t.Errorf("Expected two error replies, got %q", got)
+	}
+}
+
+func TestWithOptions(t *testing.T) {
+	type opts struct {
+		Count int `option:"count,required,max=5"`
+	}
+	var seen int
+	handler := commands.WithOptions(func(s discord.Session, i *discordgo.InteractionCreate, o opts) error {
+		seen = o.Count
+		if o.Count == 4 {
+			return errors.New("four is unlucky")
+		}
+		return nil
+	})
+
+	s := discordtest.NewSession()
+	handler(s, invoke(discordtest.Int("count", 3)))
+	if calls := s.Calls(); seen != 3 || len(calls) != 0 {
+		t.Errorf("Expected the handler to get count 3 without replies, got %d and %+v", seen, calls)
+	}
+
+	handler(s, invoke(discordtest.Int("count", 9)))
+	handler(s, invoke(discordtest.Int("count", 4)))
+	got := s.Texts()
+	if len(got) != 2 || got[0] != "❌ `count` must be at most 5" || got[1] != "❌ Four is unlucky" {
+		t.Errorf("Expected validation and handler errors as replies, got %q", got)
+	}
+}
+
+func TestReplyErrorAfterDefer(t *testing.T) {
+	s := discordtest.NewSession()
+	withRegistry(t, nil, &commands.Command{
+		Name: "slow",
+		Handler: commands.WithOptions(func(s discord.Session, i *discordgo.InteractionCreate, _ struct{}) error {
+			commands.Respond(s, i, &discordgo.InteractionResponse{
+				Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+			})
+			return errors.New("upstream failed")
+		}),
+	})
+
+	commands.Dispatch(s, command("slow", "u1"))
+	calls := s.Calls()
+	if len(calls) != 2 || calls[1].Method != "InteractionResponseEdit" || !strings.Contains(*calls[1].Edit.Content, "Upstream failed") {
+		t.Errorf("Expected the error to edit the deferred response, got %+v", calls)
+	}
+}
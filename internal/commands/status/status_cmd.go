@@ -0,0 +1,184 @@
+package status
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/leeineian/minder/internal/commands"
+	rotator "github.com/leeineian/minder/internal/daemons/status"
+	"github.com/leeineian/minder/internal/discord"
+	"github.com/leeineian/minder/internal/i18n"
+	"github.com/leeineian/minder/internal/logger"
+)
+
+// adminPermission hides the command from regular members in the client
+var adminPermission int64 = discordgo.PermissionAdministrator
+
+var StatusCmd = &commands.Command{
+	Name:        "status",
+	Description: "Manage the statuses the bot rotates through (Owner only)",
+	Options: []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "add",
+			Description: "Add a status",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "type",
+					Description: "How the status is introduced",
+					Required:    true,
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "Playing ...", Value: string(rotator.Playing)},
+						{Name: "Watching ...", Value: string(rotator.Watching)},
+						{Name: "Listening to ...", Value: string(rotator.Listening)},
+						{Name: "Competing in ...", Value: string(rotator.Competing)},
+						{Name: "custom text", Value: string(rotator.Custom)},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "text",
+					Description: "Template; may use {{.Guilds}}, {{.ActiveReminders}} and {{.RunningLoops}}",
+					Required:    true,
+					MaxLength:   rotator.MaxTextLength,
+				},
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "remove",
+			Description: "Remove a status",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:         discordgo.ApplicationCommandOptionInteger,
+					Name:         "id",
+					Description:  "Which status?",
+					Required:     true,
+					Autocomplete: true,
+				},
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "list",
+			Description: "List the statuses and how they currently render",
+		},
+	},
+	Handler: commands.Router{
+		"add":    commands.WithOptions(handleAdd),
+		"remove": commands.WithOptions(handleRemove),
+		"list":   commands.WithOptions(handleList),
+	}.Handle,
+	Autocomplete: map[string]commands.AutocompleteFunc{
+		"remove id": statusChoices,
+	},
+	Examples: []string{
+		"/status add type:Watching ... text:{{.Guilds}} servers",
+		"/status add type:custom text text:{{.ActiveReminders}} reminders pending",
+		"/status list",
+		"/status remove id:2",
+	},
+
+	Policies:                 []commands.Policy{commands.OwnerOnly()},
+	DefaultMemberPermissions: &adminPermission,
+	Ephemeral:                true,
+}
+
+// addOptions describe a new status
+type addOptions struct {
+	Type rotator.ActivityType `option:"type,required,choices=playing|watching|listening|competing|custom"`
+	Text string               `option:"text,required,minlen=1,maxlen=128"`
+}
+
+func handleAdd(s discord.Session, i *discordgo.InteractionCreate, opts addOptions) error {
+	entry, err := rotator.Add(opts.Type, opts.Text)
+	if err != nil {
+		return i18n.Errorf("status.add_failed", err)
+	}
+	return commands.ReplyEphemeral(s, i, commands.Text(i, "status.added", entry.ID, preview(entry)))
+}
+
+// removeOptions select a status by ID
+type removeOptions struct {
+	ID int `option:"id,required"`
+}
+
+func handleRemove(s discord.Session, i *discordgo.InteractionCreate, opts removeOptions) error {
+	removed, err := rotator.Remove(opts.ID)
+	if err != nil {
+		return i18n.Errorf("status.remove_failed", err)
+	}
+	if !removed {
+		return i18n.Errorf("status.not_found", opts.ID)
+	}
+	return commands.ReplyEphemeral(s, i, commands.Text(i, "status.removed", opts.ID))
+}
+
+// listMoreRoom is kept free in a listing for the note about entries left out
+const listMoreRoom = 50
+
+func handleList(s discord.Session, i *discordgo.InteractionCreate, _ struct{}) error {
+	entries, err := rotator.List()
+	if err != nil {
+		return i18n.Errorf("status.list_failed", err)
+	}
+	if len(entries) == 0 {
+		return commands.ReplyEphemeral(s, i, commands.Text(i, "status.list_empty"))
+	}
+
+	// List whole entries while they fit in one message, leaving room to say
+	// how many were left out
+	lines := make([]string, 0, len(entries))
+	length := 0
+	for n, e := range entries {
+		line := fmt.Sprintf("• [%d] %s `%s` → %s", e.ID, e.Type, e.Text, preview(e))
+		length += utf8.RuneCountInString(line) + 1
+		if length > commands.MaxMessageLength-listMoreRoom {
+			lines = append(lines, commands.Text(i, "status.list_more", len(entries)-n))
+			break
+		}
+		lines = append(lines, line)
+	}
+	return commands.ReplyEphemeral(s, i, commands.TruncateMessage(strings.Join(lines, "\n")))
+}
+
+// preview renders an entry with the current values
+func preview(e rotator.Entry) string {
+	text, err := e.Preview(rotator.CurrentValues())
+	if err != nil {
+		return fmt.Sprintf("⚠️ %v", err)
+	}
+	return text
+}
+
+// statusChoices suggests the stored statuses
+func statusChoices(_ context.Context, _ *discordgo.InteractionCreate, value string) []*discordgo.ApplicationCommandOptionChoice {
+	entries, err := rotator.List()
+	if err != nil {
+		logger.Warn("Failed to list statuses for autocomplete", "error", err)
+		return nil
+	}
+	choices := make([]*discordgo.ApplicationCommandOptionChoice, 0, len(entries))
+	for _, e := range entries {
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
+			Name:  truncate(fmt.Sprintf("#%d %s: %s", e.ID, e.Type, e.Text), 100),
+			Value: e.ID,
+		})
+	}
+	return commands.MatchChoices(value, choices)
+}
+
+func truncate(s string, n int) string {
+	if r := []rune(s); len(r) > n {
+		return string(r[:n-1]) + "…"
+	}
+	return s
+}
+
+func init() {
+	commands.Register(StatusCmd)
+}
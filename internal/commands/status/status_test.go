@@ -0,0 +1,97 @@
+package status_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/leeineian/minder/internal/commands"
+	_ "github.com/leeineian/minder/internal/commands/status"
+	rotator "github.com/leeineian/minder/internal/daemons/status"
+	"github.com/leeineian/minder/internal/database/dbtest"
+	"github.com/leeineian/minder/internal/discord/discordtest"
+	"github.com/leeineian/minder/internal/logger"
+)
+
+func TestMain(m *testing.M) {
+	logger.Init("error")
+	os.Exit(m.Run())
+}
+
+// status runs a /status subcommand and returns the reply
+func status(t *testing.T, sub *discordgo.ApplicationCommandInteractionDataOption) string {
+	t.Helper()
+	return discordtest.Reply(t, commands.Dispatch, discordtest.Command("status", discordtest.Args(sub)))
+}
+
+func TestAddListRemove(t *testing.T) {
+	dbtest.Setup(t)
+
+	got := status(t, discordtest.Sub("add", discordtest.String("type", "watching"), discordtest.String("text", "{{.RunningLoops}} loops")))
+	if got != "✅ Added status #1: Watching 0 loops" {
+		t.Errorf("Unexpected add reply %q", got)
+	}
+	if got := status(t, discordtest.Sub("list")); !strings.Contains(got, "[1] watching `{{.RunningLoops}} loops` → Watching 0 loops") {
+		t.Errorf("Expected the status and its preview to be listed, got %q", got)
+	}
+	if got := status(t, discordtest.Sub("remove", discordtest.Int("id", 1))); got != "🗑️ Removed status #1" {
+		t.Errorf("Unexpected remove reply %q", got)
+	}
+	if got := status(t, discordtest.Sub("list")); !strings.HasPrefix(got, "No statuses saved") {
+		t.Errorf("Expected no statuses left, got %q", got)
+	}
+}
+
+func TestListFitsInOneMessage(t *testing.T) {
+	dbtest.Setup(t)
+	for n := 0; n < 30; n++ {
+		if _, err := rotator.Add(rotator.Playing, strings.Repeat("é", 100)); err != nil {
+			t.Fatalf("Failed to add status: %v", err)
+		}
+	}
+
+	got := status(t, discordtest.Sub("list"))
+	if n := utf8.RuneCountInString(got); n > commands.MaxMessageLength {
+		t.Errorf("Expected the listing to fit in one message, got %d characters", n)
+	}
+	if !strings.HasSuffix(got, " more") || !strings.Contains(got, "[1] playing") {
+		t.Errorf("Expected the first statuses and a note about the rest, got %q", got)
+	}
+}
+
+func TestAddInvalidTemplate(t *testing.T) {
+	dbtest.Setup(t)
+
+	got := status(t, discordtest.Sub("add", discordtest.String("type", "playing"), discordtest.String("text", "{{.Users}}")))
+	if !strings.HasPrefix(got, "❌ Failed to add status: invalid template") {
+		t.Errorf("Expected the template error, got %q", got)
+	}
+}
+
+func TestRemoveUnknown(t *testing.T) {
+	dbtest.Setup(t)
+
+	if got := status(t, discordtest.Sub("remove", discordtest.Int("id", 9))); got != "❌ There is no status #9" {
+		t.Errorf("Unexpected reply %q", got)
+	}
+}
+
+func TestLocalizedReplies(t *testing.T) {
+	dbtest.Setup(t)
+
+	got := discordtest.Reply(t, commands.Dispatch, discordtest.Command("status", discordtest.Args(
+		discordtest.Sub("remove", discordtest.Int("id", 9)),
+	), discordtest.WithLocale(discordgo.German)))
+	if got != "❌ Es gibt keinen Status #9" {
+		t.Errorf("Expected a German error, got %q", got)
+	}
+
+	got = discordtest.Reply(t, commands.Dispatch, discordtest.Command("status", discordtest.Args(
+		discordtest.Sub("list"),
+	), discordtest.WithLocale(discordgo.SpanishES)))
+	if got != "No hay estados guardados; se muestran los integrados." {
+		t.Errorf("Expected a Spanish reply, got %q", got)
+	}
+}
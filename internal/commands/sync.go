@@ -0,0 +1,364 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/leeineian/minder/internal/config"
+	"github.com/leeineian/minder/internal/logger"
+)
+
+// guildPageSize is how many guilds Discord returns per page of /users/@me/guilds
+const guildPageSize = 200
+
+// SyncPlan lists the changes that bring the commands registered with Discord
+// in line with the Registry
+type SyncPlan struct {
+	AppID  string
+	Scopes []*ScopePlan
+}
+
+// ScopePlan lists the changes to the global commands, or to one guild's
+// commands if GuildID is set
+type ScopePlan struct {
+	GuildID string
+	Create  []*discordgo.ApplicationCommand
+	// Edit holds the new definitions, with the IDs of the commands they replace
+	Edit      []*discordgo.ApplicationCommand
+	Delete    []*discordgo.ApplicationCommand
+	Unchanged int
+}
+
+// Changes reports how many commands the plan creates, edits or deletes
+func (p *SyncPlan) Changes() int {
+	n := 0
+	for _, sp := range p.Scopes {
+		n += sp.Changes()
+	}
+	return n
+}
+
+// Changes reports how many commands the scope's plan creates, edits or deletes
+func (sp *ScopePlan) Changes() int {
+	return len(sp.Create) + len(sp.Edit) + len(sp.Delete)
+}
+
+// Scope names the scope for logs
+func (sp *ScopePlan) Scope() string {
+	if sp.GuildID == "" {
+		return "global"
+	}
+	return "guild " + sp.GuildID
+}
+
+// SyncCommands registers the Registry with Discord, changing only the
+// commands that differ from what Discord already has
+func SyncCommands(s *discordgo.Session, cfg *config.Config) error {
+	plan, err := PlanSync(s, cfg)
+	if err != nil {
+		return err
+	}
+	plan.Log()
+	return plan.Apply(s)
+}
+
+// PlanSync compares the Registry with the commands registered with Discord.
+// Commands listing Guilds belong to those guilds; the rest are global, or
+// belong to cfg.GuildId when it is set for instant updates during
+// development. Commands anywhere else are planned for deletion, so switching
+// between guild and global commands leaves no duplicates behind.
+func PlanSync(s *discordgo.Session, cfg *config.Config) (*SyncPlan, error) {
+	appID, err := applicationID(s, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	desired := desiredCommands(cfg.GuildId)
+	scopes := make(map[string]bool, len(desired))
+	for guildID := range desired {
+		scopes[guildID] = true
+	}
+	// Stale commands can be left in any guild the bot is in
+	guilds, err := userGuilds(s)
+	if err != nil {
+		logger.Warn("Failed to list guilds; stale commands in unlisted guilds will not be deleted", "error", err)
+	}
+	for _, g := range guilds {
+		scopes[g.ID] = true
+	}
+	logger.Info("Scanning guilds for stale commands", "guilds", len(guilds))
+
+	plan := &SyncPlan{AppID: appID}
+	for _, guildID := range sortedKeys(scopes) {
+		existing, err := s.ApplicationCommands(appID, guildID)
+		if err != nil {
+			if _, wanted := desired[guildID]; wanted {
+				return nil, fmt.Errorf("fetch %s commands: %w", (&ScopePlan{GuildID: guildID}).Scope(), err)
+			}
+			// Guilds that did not authorize the applications.commands scope refuse the request
+			logger.Debug("Skipping guild commands", "guildID", guildID, "error", err)
+			continue
+		}
+		sp := DiffCommands(desired[guildID], existing)
+		sp.GuildID = guildID
+		plan.Scopes = append(plan.Scopes, sp)
+	}
+	return plan, nil
+}
+
+// userGuilds lists every guild the bot is in, following the after cursor
+// through as many pages as it takes. The guilds listed before an error are
+// returned with it.
+func userGuilds(s *discordgo.Session) ([]*discordgo.UserGuild, error) {
+	var guilds []*discordgo.UserGuild
+	after := ""
+	for {
+		page, err := s.UserGuilds(guildPageSize, "", after, false)
+		if err != nil {
+			return guilds, err
+		}
+		guilds = append(guilds, page...)
+		if len(page) < guildPageSize {
+			return guilds, nil
+		}
+		// Pages are sorted by guild ID
+		after = page[len(page)-1].ID
+	}
+}
+
+// Log logs the changes the plan makes in each scope
+func (p *SyncPlan) Log() {
+	for _, sp := range p.Scopes {
+		if sp.Changes() == 0 {
+			logger.Debug("Commands up to date", "scope", sp.Scope(), "count", sp.Unchanged)
+			continue
+		}
+		logger.Info("Command sync plan",
+			"scope", sp.Scope(),
+			"create", commandNames(sp.Create),
+			"edit", commandNames(sp.Edit),
+			"delete", commandNames(sp.Delete),
+			"unchanged", sp.Unchanged)
+	}
+	if p.Changes() == 0 {
+		logger.Info("Commands are up to date")
+	}
+}
+
+// Apply makes the planned changes, continuing past failures and returning
+// them all. Deletions go first to stay within Discord's command limits.
+func (p *SyncPlan) Apply(s *discordgo.Session) error {
+	var errs []error
+	for _, sp := range p.Scopes {
+		for _, c := range sp.Delete {
+			if err := s.ApplicationCommandDelete(p.AppID, sp.GuildID, c.ID); err != nil {
+				errs = append(errs, fmt.Errorf("delete %s from %s: %w", c.Name, sp.Scope(), err))
+			}
+		}
+		for _, c := range sp.Edit {
+			if _, err := s.ApplicationCommandEdit(p.AppID, sp.GuildID, c.ID, c); err != nil {
+				errs = append(errs, fmt.Errorf("edit %s in %s: %w", c.Name, sp.Scope(), err))
+			}
+		}
+		for _, c := range sp.Create {
+			if _, err := s.ApplicationCommandCreate(p.AppID, sp.GuildID, c); err != nil {
+				errs = append(errs, fmt.Errorf("create %s in %s: %w", c.Name, sp.Scope(), err))
+			}
+		}
+	}
+	if len(errs) == 0 {
+		logger.Info("Synced commands", "changes", p.Changes())
+	}
+	return errors.Join(errs...)
+}
+
+// DiffCommands plans the changes that turn existing into desired. Commands
+// are matched by type and name and compared by the fields the bot sets, so
+// defaults Discord fills in do not count as changes.
+func DiffCommands(desired, existing []*discordgo.ApplicationCommand) *ScopePlan {
+	sp := &ScopePlan{}
+	byKey := make(map[string]*discordgo.ApplicationCommand, len(existing))
+	for _, c := range existing {
+		byKey[commandKey(c)] = c
+	}
+
+	for _, want := range desired {
+		key := commandKey(want)
+		have, ok := byKey[key]
+		delete(byKey, key)
+		switch {
+		case !ok:
+			sp.Create = append(sp.Create, want)
+		case fingerprint(want) != fingerprint(have):
+			edit := *want
+			edit.ID = have.ID
+			sp.Edit = append(sp.Edit, &edit)
+		default:
+			sp.Unchanged++
+		}
+	}
+
+	for _, c := range existing {
+		if _, stale := byKey[commandKey(c)]; stale {
+			sp.Delete = append(sp.Delete, c)
+		}
+	}
+	return sp
+}
+
+// desiredCommands returns the definitions of the Registry by guild ID, with
+// "" for global commands, which are always present
+func desiredCommands(guildID string) map[string][]*discordgo.ApplicationCommand {
+	desired := map[string][]*discordgo.ApplicationCommand{"": nil}
+	// Sorted so plans and logs are stable
+	for _, cmd := range Commands() {
+		scopes := cmd.Guilds
+		if len(scopes) == 0 {
+			scopes = []string{guildID}
+		}
+		for _, scope := range scopes {
+			desired[scope] = append(desired[scope], cmd.applicationCommand())
+		}
+	}
+	return desired
+}
+
+// applicationID returns the ID commands are registered under
+func applicationID(s *discordgo.Session, cfg *config.Config) (string, error) {
+	if cfg.ClientId != "" {
+		return cfg.ClientId, nil
+	}
+	if s.State != nil && s.State.User != nil {
+		return s.State.User.ID, nil
+	}
+	// Not connected, as in a dry run
+	u, err := s.User("@me")
+	if err != nil {
+		return "", fmt.Errorf("look up application ID: %w", err)
+	}
+	return u.ID, nil
+}
+
+func commandKey(c *discordgo.ApplicationCommand) string {
+	t := c.Type
+	if t == 0 {
+		t = discordgo.ChatApplicationCommand
+	}
+	return fmt.Sprintf("%d:%s", t, c.Name)
+}
+
+// commandShape holds the fields of a command the bot controls, normalized
+// so equal definitions encode identically
+type commandShape struct {
+	Key                      string
+	Description              string
+	NameLocalizations        map[discordgo.Locale]string
+	DescriptionLocalizations map[discordgo.Locale]string
+	DefaultMemberPermissions *int64
+	Options                  []optionShape
+}
+
+type optionShape struct {
+	Type                     discordgo.ApplicationCommandOptionType
+	Name                     string
+	NameLocalizations        map[discordgo.Locale]string
+	Description              string
+	DescriptionLocalizations map[discordgo.Locale]string
+	ChannelTypes             []discordgo.ChannelType
+	Required                 bool
+	Autocomplete             bool
+	Choices                  []choiceShape
+	MinValue                 *float64
+	MaxValue                 float64
+	MinLength                *int
+	MaxLength                int
+	Options                  []optionShape
+}
+
+type choiceShape struct {
+	Name              string
+	NameLocalizations map[discordgo.Locale]string
+	// Value is formatted, since Discord returns integers as JSON numbers
+	Value string
+}
+
+// fingerprint encodes the shape of a command for comparison
+func fingerprint(c *discordgo.ApplicationCommand) string {
+	shape := commandShape{
+		Key:                      commandKey(c),
+		Description:              c.Description,
+		DefaultMemberPermissions: c.DefaultMemberPermissions,
+		Options:                  optionShapes(c.Options),
+	}
+	if c.NameLocalizations != nil {
+		shape.NameLocalizations = nonEmpty(*c.NameLocalizations)
+	}
+	if c.DescriptionLocalizations != nil {
+		shape.DescriptionLocalizations = nonEmpty(*c.DescriptionLocalizations)
+	}
+	raw, _ := json.Marshal(shape)
+	return string(raw)
+}
+
+func optionShapes(options []*discordgo.ApplicationCommandOption) []optionShape {
+	if len(options) == 0 {
+		return nil
+	}
+	shapes := make([]optionShape, len(options))
+	for idx, o := range options {
+		shape := optionShape{
+			Type:                     o.Type,
+			Name:                     o.Name,
+			NameLocalizations:        nonEmpty(o.NameLocalizations),
+			Description:              o.Description,
+			DescriptionLocalizations: nonEmpty(o.DescriptionLocalizations),
+			Required:                 o.Required,
+			Autocomplete:             o.Autocomplete,
+			MinValue:                 o.MinValue,
+			MaxValue:                 o.MaxValue,
+			MinLength:                o.MinLength,
+			MaxLength:                o.MaxLength,
+			Options:                  optionShapes(o.Options),
+		}
+		if len(o.ChannelTypes) > 0 {
+			shape.ChannelTypes = o.ChannelTypes
+		}
+		for _, c := range o.Choices {
+			shape.Choices = append(shape.Choices, choiceShape{
+				Name:              c.Name,
+				NameLocalizations: nonEmpty(c.NameLocalizations),
+				Value:             fmt.Sprint(c.Value),
+			})
+		}
+		shapes[idx] = shape
+	}
+	return shapes
+}
+
+func nonEmpty(m map[discordgo.Locale]string) map[discordgo.Locale]string {
+	if len(m) == 0 {
+		return nil
+	}
+	return m
+}
+
+func commandNames(cmds []*discordgo.ApplicationCommand) string {
+	names := make([]string, len(cmds))
+	for idx, c := range cmds {
+		names[idx] = c.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,201 @@
+package commands_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/leeineian/minder/internal/commands"
+	"github.com/leeineian/minder/internal/config"
+)
+
+// commandAPI serves registered commands by guild ID ("" for global) and
+// records the requests that change them
+type commandAPI struct {
+	mu       sync.Mutex
+	commands map[string][]*discordgo.ApplicationCommand
+	changes  []string
+}
+
+func (a *commandAPI) RoundTrip(req *http.Request) (*http.Response, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	path := strings.TrimPrefix(req.URL.Path, "/api/v9")
+	resp := `{}`
+	switch {
+	case path == "/users/@me/guilds":
+		// Pages of guilds sorted by ID, like Discord
+		var ids []string
+		for id := range a.commands {
+			if id != "" && id > req.URL.Query().Get("after") {
+				ids = append(ids, id)
+			}
+		}
+		sort.Strings(ids)
+		if limit, _ := strconv.Atoi(req.URL.Query().Get("limit")); limit > 0 && len(ids) > limit {
+			ids = ids[:limit]
+		}
+		guilds := make([]discordgo.UserGuild, len(ids))
+		for n, id := range ids {
+			guilds[n] = discordgo.UserGuild{ID: id}
+		}
+		raw, _ := json.Marshal(guilds)
+		resp = string(raw)
+	case req.Method == http.MethodGet:
+		guildID := ""
+		if _, rest, ok := strings.Cut(path, "/guilds/"); ok {
+			guildID = strings.TrimSuffix(rest, "/commands")
+		}
+		raw, _ := json.Marshal(a.commands[guildID])
+		resp = string(raw)
+	default:
+		var body struct {
+			Name string `json:"name"`
+		}
+		if req.Body != nil {
+			raw, _ := io.ReadAll(req.Body)
+			json.Unmarshal(raw, &body)
+		}
+		a.changes = append(a.changes, strings.TrimSpace(req.Method+" "+path+" "+body.Name))
+	}
+
+	status := http.StatusOK
+	if req.Method == http.MethodDelete {
+		status, resp = http.StatusNoContent, ""
+	}
+	return &http.Response{
+		StatusCode: status,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(resp)),
+		Request:    req,
+	}, nil
+}
+
+func apiSession(t *testing.T, registered map[string][]*discordgo.ApplicationCommand) (*discordgo.Session, *commandAPI) {
+	t.Helper()
+	s, err := discordgo.New("Bot test")
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	api := &commandAPI{commands: registered}
+	s.Client = &http.Client{Transport: api}
+	return s, api
+}
+
+func TestDiffCommands(t *testing.T) {
+	minLen := 1
+	desired := []*discordgo.ApplicationCommand{
+		{Type: discordgo.ChatApplicationCommand, Name: "same", Description: "Same", Options: []*discordgo.ApplicationCommandOption{{
+			Type: discordgo.ApplicationCommandOptionInteger, Name: "n", Description: "N", MinLength: &minLen,
+			Choices: []*discordgo.ApplicationCommandOptionChoice{{Name: "one", Value: 1}},
+		}}},
+		{Type: discordgo.ChatApplicationCommand, Name: "changed", Description: "New text"},
+		{Type: discordgo.ChatApplicationCommand, Name: "added", Description: "Added"},
+		{Type: discordgo.MessageApplicationCommand, Name: "same"},
+	}
+	existing := []*discordgo.ApplicationCommand{
+		// Discord returns integer choices as JSON numbers and omits empty lists
+		{ID: "1", Name: "same", Description: "Same", Options: []*discordgo.ApplicationCommandOption{{
+			Type: discordgo.ApplicationCommandOptionInteger, Name: "n", Description: "N", MinLength: &minLen,
+			ChannelTypes: []discordgo.ChannelType{},
+			Choices:      []*discordgo.ApplicationCommandOptionChoice{{Name: "one", Value: float64(1)}},
+		}}},
+		{ID: "2", Type: discordgo.ChatApplicationCommand, Name: "changed", Description: "Old text"},
+		{ID: "3", Type: discordgo.ChatApplicationCommand, Name: "removed", Description: "Removed"},
+		{ID: "4", Type: discordgo.MessageApplicationCommand, Name: "same"},
+	}
+
+	plan := commands.DiffCommands(desired, existing)
+	if plan.Unchanged != 2 {
+		t.Errorf("Expected 2 unchanged commands, got %d", plan.Unchanged)
+	}
+	if len(plan.Create) != 1 || plan.Create[0].Name != "added" {
+		t.Errorf("Expected to create added, got %+v", plan.Create)
+	}
+	if len(plan.Edit) != 1 || plan.Edit[0].Name != "changed" || plan.Edit[0].ID != "2" {
+		t.Errorf("Expected to edit changed by its ID, got %+v", plan.Edit)
+	}
+	if len(plan.Delete) != 1 || plan.Delete[0].ID != "3" {
+		t.Errorf("Expected to delete removed, got %+v", plan.Delete)
+	}
+	if desired[1].ID != "" {
+		t.Error("Expected the desired definition to be left untouched")
+	}
+}
+
+func TestSyncCommands(t *testing.T) {
+	withRegistry(t, nil,
+		&commands.Command{Name: "ping", Description: "Pong"},
+		&commands.Command{Name: "admin", Description: "Admin tools", Guilds: []string{"g2"}},
+	)
+	cfg := &config.Config{ClientId: "app"}
+
+	// ping was registered in g1 while GUILD_ID pointed there
+	s, api := apiSession(t, map[string][]*discordgo.ApplicationCommand{
+		"g1": {{ID: "10", Type: discordgo.ChatApplicationCommand, Name: "ping", Description: "Pong"}},
+		"g2": nil,
+	})
+
+	plan, err := commands.PlanSync(s, cfg)
+	if err != nil {
+		t.Fatalf("PlanSync failed: %v", err)
+	}
+	if plan.Changes() != 3 || len(api.changes) != 0 {
+		t.Fatalf("Expected a plan of 3 changes without changing anything, got %d and %v", plan.Changes(), api.changes)
+	}
+
+	if err := commands.SyncCommands(s, cfg); err != nil {
+		t.Fatalf("SyncCommands failed: %v", err)
+	}
+	want := []string{
+		"POST /applications/app/commands ping",
+		"DELETE /applications/app/guilds/g1/commands/10",
+		"POST /applications/app/guilds/g2/commands admin",
+	}
+	if strings.Join(api.changes, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Unexpected changes:\n%s", strings.Join(api.changes, "\n"))
+	}
+}
+
+func TestSyncCommandsToDevGuild(t *testing.T) {
+	withRegistry(t, nil, &commands.Command{Name: "ping", Description: "Pong"})
+	s, api := apiSession(t, map[string][]*discordgo.ApplicationCommand{
+		"":   {{ID: "10", Type: discordgo.ChatApplicationCommand, Name: "ping", Description: "Pong"}},
+		"g1": {{ID: "11", Type: discordgo.ChatApplicationCommand, Name: "ping", Description: "Pong"}},
+	})
+
+	if err := commands.SyncCommands(s, &config.Config{ClientId: "app", GuildId: "g1"}); err != nil {
+		t.Fatalf("SyncCommands failed: %v", err)
+	}
+	if len(api.changes) != 1 || api.changes[0] != "DELETE /applications/app/commands/10" {
+		t.Errorf("Expected only the global duplicate to be deleted, got %v", api.changes)
+	}
+}
+
+func TestSyncCommandsPagesGuilds(t *testing.T) {
+	withRegistry(t, nil, &commands.Command{Name: "ping", Description: "Pong"})
+	registered := map[string][]*discordgo.ApplicationCommand{
+		"": {{ID: "10", Type: discordgo.ChatApplicationCommand, Name: "ping", Description: "Pong"}},
+	}
+	for n := 0; n < 450; n++ {
+		registered[fmt.Sprintf("g%03d", n)] = nil
+	}
+	// Past the first two pages of guilds
+	registered["g420"] = []*discordgo.ApplicationCommand{{ID: "11", Type: discordgo.ChatApplicationCommand, Name: "ping", Description: "Pong"}}
+	s, api := apiSession(t, registered)
+
+	if err := commands.SyncCommands(s, &config.Config{ClientId: "app"}); err != nil {
+		t.Fatalf("SyncCommands failed: %v", err)
+	}
+	if len(api.changes) != 1 || api.changes[0] != "DELETE /applications/app/guilds/g420/commands/11" {
+		t.Errorf("Expected the stale command in the last page to be deleted, got %v", api.changes)
+	}
+}
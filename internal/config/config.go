@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -16,8 +17,18 @@ type Config struct {
 	LogLevel     string
 	Environment  string
 	TavilyKey    string
+
+	LooperBaseURL string
+	LooperSecret  string
+
+	StatusInterval time.Duration
+	StatusOrder    string
 }
 
+// MinStatusInterval keeps the status rotator well inside Discord's presence
+// update rate limit
+const MinStatusInterval = 15 * time.Second
+
 func Load() (*Config, error) {
 	// wrapper to load .env file if it exists
 	_ = godotenv.Load()
@@ -31,6 +42,12 @@ func Load() (*Config, error) {
 		LogLevel:     os.Getenv("LOG_LEVEL"),
 		Environment:  os.Getenv("NODE_ENV"),
 		TavilyKey:    os.Getenv("TAVILY_API_KEY"),
+
+		LooperBaseURL: os.Getenv("LOOPER_BASE_URL"),
+		LooperSecret:  os.Getenv("LOOPER_SECRET"),
+
+		StatusInterval: 30 * time.Second,
+		StatusOrder:    os.Getenv("STATUS_ORDER"),
 	}
 
 	if cfg.Token == "" {
@@ -45,6 +62,23 @@ func Load() (*Config, error) {
 	if cfg.Environment == "" {
 		cfg.Environment = "development"
 	}
+	if raw := os.Getenv("STATUS_INTERVAL"); raw != "" {
+		interval, err := time.ParseDuration(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid STATUS_INTERVAL: %w", err)
+		}
+		if interval < MinStatusInterval {
+			return nil, fmt.Errorf("STATUS_INTERVAL must be at least %s", MinStatusInterval)
+		}
+		cfg.StatusInterval = interval
+	}
+	switch cfg.StatusOrder {
+	case "":
+		cfg.StatusOrder = "random"
+	case "random", "sequential":
+	default:
+		return nil, fmt.Errorf("STATUS_ORDER must be random or sequential, got %q", cfg.StatusOrder)
+	}
 
 	return cfg, nil
 }
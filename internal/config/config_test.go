@@ -3,6 +3,7 @@ package config_test
 import (
 	"os"
 	"testing"
+	"time"
 
 	"github.com/leeineian/minder/internal/config"
 )
@@ -107,4 +108,30 @@ func TestLoad(t *testing.T) {
 			t.Errorf("Expected TavilyKey 'tavily_key_123', got '%s'", cfg.TavilyKey)
 		}
 	})
+	t.Run("status rotation", func(t *testing.T) {
+		os.Setenv("DISCORD_TOKEN", "test_token")
+		defer os.Unsetenv("STATUS_INTERVAL")
+		defer os.Unsetenv("STATUS_ORDER")
+
+		os.Setenv("STATUS_INTERVAL", "2m")
+		os.Setenv("STATUS_ORDER", "sequential")
+		cfg, err := config.Load()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if cfg.StatusInterval != 2*time.Minute || cfg.StatusOrder != "sequential" {
+			t.Errorf("Expected a 2m sequential rotation, got %s %s", cfg.StatusInterval, cfg.StatusOrder)
+		}
+
+		os.Setenv("STATUS_INTERVAL", "1s")
+		if _, err := config.Load(); err == nil {
+			t.Error("Expected an error for an interval below the minimum")
+		}
+
+		os.Setenv("STATUS_INTERVAL", "1m")
+		os.Setenv("STATUS_ORDER", "shuffled")
+		if _, err := config.Load(); err == nil {
+			t.Error("Expected an error for an unknown order")
+		}
+	})
 }
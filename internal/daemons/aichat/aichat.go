@@ -1,6 +1,7 @@
 package aichat
 
 import (
+	"context"
 	"strings"
 
 	"github.com/bwmarrin/discordgo"
@@ -9,14 +10,34 @@ import (
 
 var botID string
 
-// Start registers the AI chat message listener
-func Start(s *discordgo.Session) {
-	botID = s.State.User.ID
+// Listener is the daemon that answers messages mentioning the bot
+type Listener struct {
+	session *discordgo.Session
+}
+
+// New creates an AI chat listener
+func New(s *discordgo.Session) *Listener {
+	return &Listener{session: s}
+}
+
+func (l *Listener) Name() string { return "aichat" }
 
-	s.AddHandler(onMessage)
+// Start listens for mentions until ctx is cancelled
+func (l *Listener) Start(ctx context.Context) error {
+	botID = l.session.State.User.ID
+
+	remove := l.session.AddHandler(onMessage)
+	defer remove()
 	logger.Info("AI chat listener started")
+
+	<-ctx.Done()
+	return nil
 }
 
+func (l *Listener) Stop() error { return nil }
+
+func (l *Listener) Health() error { return nil }
+
 func onMessage(s *discordgo.Session, m *discordgo.MessageCreate) {
 	// Ignore bot messages
 	if m.Author.ID == botID {
@@ -0,0 +1,178 @@
+package looper
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/leeineian/minder/internal/logger"
+)
+
+var (
+	// ErrLoopNotFound is returned for channel IDs the manager has no loop for
+	ErrLoopNotFound = errors.New("loop not found")
+	// ErrLoopNotRunning is returned when pausing or resuming a stopped loop
+	ErrLoopNotRunning = errors.New("loop is not running")
+	// ErrNoHooks is returned when starting a loop, or leaving a running one,
+	// without any webhooks to send through
+	ErrNoHooks = errors.New("loop has no webhooks")
+)
+
+// LoopPatch lists the settings Update changes; nil fields are left as they are
+type LoopPatch struct {
+	Interval *int          // ms; only affects fixed-interval loops
+	Message  *string       // replaces the message and drops any payload template
+	Hooks    []WebhookData // replaces the hook set
+}
+
+// runMessage is the request body of a run, swapped atomically by Update
+type runMessage struct {
+	body       []byte
+	payload    *payloadTemplate
+	payloadErr error // why payload could not be compiled
+}
+
+// loopControl is the state the loop goroutine applies when woken
+type loopControl struct {
+	paused   bool
+	version  int
+	interval int
+	hooks    []WebhookData
+}
+
+func (m *Manager) instance(channelID string) (*LoopInstance, error) {
+	val, ok := m.loops.Load(channelID)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrLoopNotFound, channelID)
+	}
+	return val.(*LoopInstance), nil
+}
+
+// Pause stops a running loop from sending without ending its run, so its
+// goroutine, statistics and schedule survive until Resume
+func (m *Manager) Pause(channelID string) error {
+	return m.setPaused(channelID, true)
+}
+
+// Resume continues a paused loop where its schedule left off
+func (m *Manager) Resume(channelID string) error {
+	return m.setPaused(channelID, false)
+}
+
+func (m *Manager) setPaused(channelID string, paused bool) error {
+	instance, err := m.instance(channelID)
+	if err != nil {
+		return err
+	}
+
+	instance.mu.Lock()
+	if !instance.running {
+		instance.mu.Unlock()
+		return fmt.Errorf("%w: %s", ErrLoopNotRunning, channelID)
+	}
+	if instance.paused == paused {
+		instance.mu.Unlock()
+		return nil
+	}
+	instance.paused = paused
+	if paused {
+		instance.pausedAt = time.Now()
+	}
+	run := instance.run
+	instance.mu.Unlock()
+
+	run.notify()
+	m.persist(instance)
+	logger.Info("Changed loop state", "channel", instance.Config.ChannelName, "paused", paused)
+	return nil
+}
+
+// Update applies a patch to a loop. A running loop picks the changes up
+// without restarting its goroutine or losing its statistics; a stopped loop
+// uses them the next time it starts.
+func (m *Manager) Update(channelID string, patch LoopPatch) error {
+	if patch.Interval != nil && *patch.Interval <= 0 {
+		return errors.New("interval must be positive")
+	}
+	instance, err := m.instance(channelID)
+	if err != nil {
+		return err
+	}
+
+	instance.mu.Lock()
+	if patch.Hooks != nil && len(patch.Hooks) == 0 && instance.running {
+		instance.mu.Unlock()
+		return fmt.Errorf("%w: %s", ErrNoHooks, channelID)
+	}
+	reschedule, message := false, false
+	if patch.Interval != nil {
+		instance.Config.Interval = *patch.Interval
+		reschedule = true
+	}
+	if patch.Message != nil {
+		instance.Config.Message = *patch.Message
+		instance.Config.Payload = nil
+		instance.Config.Preset = ""
+		message = true
+	}
+	if patch.Hooks != nil {
+		instance.Hooks = append([]WebhookData(nil), patch.Hooks...)
+		reschedule = true
+	}
+	if reschedule {
+		instance.version++
+	}
+
+	var run *loopRun
+	if instance.running {
+		run = instance.run
+	}
+	cfg, hooks := instance.Config, len(instance.Hooks)
+	instance.mu.Unlock()
+
+	if run != nil {
+		if message {
+			run.message.Store(newRunMessage(cfg))
+		}
+		if reschedule {
+			run.notify()
+		}
+	}
+	m.persist(instance)
+	logger.Info("Updated loop", "channel", cfg.ChannelName, "interval", cfg.Interval, "hooks", hooks)
+	return nil
+}
+
+// control returns the pause state and schedule the loop goroutine should apply
+func (l *LoopInstance) control() loopControl {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return loopControl{
+		paused:   l.paused,
+		version:  l.version,
+		interval: l.Config.Interval,
+		hooks:    append([]WebhookData(nil), l.Hooks...),
+	}
+}
+
+// notify wakes the loop goroutine so it applies pending control changes
+func (r *loopRun) notify() {
+	select {
+	case r.wake <- struct{}{}:
+	default: // A wake-up is already pending
+	}
+}
+
+func newRunMessage(cfg LoopConfig) *runMessage {
+	if cfg.Payload != nil {
+		payload, err := cfg.Payload.compile()
+		return &runMessage{payload: payload, payloadErr: err}
+	}
+	body, _ := json.Marshal(map[string]interface{}{
+		"content":    cfg.Message,
+		"username":   cfg.WebhookAuthor,
+		"avatar_url": cfg.WebhookAvatar,
+	})
+	return &runMessage{body: body}
+}
@@ -0,0 +1,137 @@
+package looper_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/leeineian/minder/internal/daemons/looper"
+	"github.com/leeineian/minder/internal/daemons/looper/sink"
+	"github.com/leeineian/minder/internal/database/dbtest"
+)
+
+func TestPauseResume(t *testing.T) {
+	m, s := newSinkManager(t, sink.Options{Seed: 1})
+	m.StartLoop(looper.LoopConfig{ChannelID: "c1", Interval: 10}, []looper.WebhookData{{HookID: "1", HookToken: "a"}})
+	defer m.StopLoop("c1")
+	waitFor(t, 2*time.Second, func() bool { return s.Stats().Requests >= 3 })
+
+	if err := m.Pause("c1"); err != nil {
+		t.Fatalf("Pause failed: %v", err)
+	}
+	time.Sleep(30 * time.Millisecond) // Let in-flight requests land
+	paused := s.Stats().Requests
+	before := m.List()[0]
+	if !before.Running || !before.Paused {
+		t.Fatalf("Expected a running, paused loop, got %+v", before)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if got := s.Stats().Requests; got != paused {
+		t.Errorf("Paused loop kept sending: %d -> %d", paused, got)
+	}
+
+	if err := m.Resume("c1"); err != nil {
+		t.Fatalf("Resume failed: %v", err)
+	}
+	waitFor(t, 2*time.Second, func() bool { return s.Stats().Requests >= paused+3 })
+
+	after := m.List()[0]
+	if after.Paused || !after.StartedAt.Equal(before.StartedAt) {
+		t.Errorf("Expected the same run to resume, got %+v", after)
+	}
+	if after.Iterations <= before.Iterations {
+		t.Errorf("Statistics were reset: %d -> %d iterations", before.Iterations, after.Iterations)
+	}
+}
+
+func TestUpdateRunningLoop(t *testing.T) {
+	m, s := newSinkManager(t, sink.Options{Seed: 1})
+	m.StartLoop(looper.LoopConfig{ChannelID: "c1", Interval: 60000, Message: "old"}, []looper.WebhookData{{HookID: "1", HookToken: "a"}})
+	defer m.StopLoop("c1")
+	waitFor(t, 2*time.Second, func() bool { return s.Stats().Requests >= 1 })
+	startedAt := m.List()[0].StartedAt
+
+	interval, message := 10, "new"
+	err := m.Update("c1", looper.LoopPatch{
+		Interval: &interval,
+		Message:  &message,
+		Hooks:    []looper.WebhookData{{HookID: "2", HookToken: "b"}},
+	})
+	if err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	waitFor(t, 2*time.Second, func() bool { return s.Stats().ByWebhook["2"] >= 3 })
+
+	reqs := s.Requests()
+	var body struct {
+		Content string `json:"content"`
+	}
+	json.Unmarshal(reqs[len(reqs)-1].Body, &body)
+	if body.Content != "new" {
+		t.Errorf("Expected the updated message, got %q", body.Content)
+	}
+
+	st := m.List()[0]
+	if !st.StartedAt.Equal(startedAt) || st.Interval != 10*time.Millisecond || st.Hooks != 1 {
+		t.Errorf("Unexpected status after update: %+v", st)
+	}
+	if cfg, hooks, _ := m.Get("c1"); cfg.Message != "new" || hooks[0].HookID != "2" {
+		t.Errorf("Update not stored: %+v %+v", cfg, hooks)
+	}
+}
+
+func TestControlErrors(t *testing.T) {
+	m, _ := newSinkManager(t, sink.Options{Seed: 1})
+	if err := m.Pause("missing"); !errors.Is(err, looper.ErrLoopNotFound) {
+		t.Errorf("Expected ErrLoopNotFound, got %v", err)
+	}
+	if err := m.StartLoop(looper.LoopConfig{ChannelID: "c1", Interval: 60000}, nil); !errors.Is(err, looper.ErrNoHooks) {
+		t.Errorf("Expected a loop without webhooks not to start, got %v", err)
+	}
+
+	m.StartLoop(looper.LoopConfig{ChannelID: "c1", Interval: 60000}, []looper.WebhookData{{HookID: "1", HookToken: "a"}})
+	if err := m.Update("c1", looper.LoopPatch{Hooks: []looper.WebhookData{}}); !errors.Is(err, looper.ErrNoHooks) {
+		t.Errorf("Expected a running loop to keep at least one webhook, got %v", err)
+	}
+	m.StopLoop("c1")
+	if err := m.Resume("c1"); !errors.Is(err, looper.ErrLoopNotRunning) {
+		t.Errorf("Expected ErrLoopNotRunning, got %v", err)
+	}
+
+	zero := 0
+	if err := m.Update("c1", looper.LoopPatch{Interval: &zero}); err == nil {
+		t.Error("Expected a zero interval to be rejected")
+	}
+	// Stopped loops accept updates for their next start
+	interval := 500
+	if err := m.Update("c1", looper.LoopPatch{Interval: &interval}); err != nil {
+		t.Errorf("Update failed: %v", err)
+	}
+}
+
+func TestPausedLoopStaysPausedAfterReload(t *testing.T) {
+	dbtest.Setup(t)
+	m, _ := newSinkManager(t, sink.Options{Seed: 1})
+	m.StartLoop(looper.LoopConfig{ChannelID: "c1", Interval: 10}, []looper.WebhookData{{HookID: "1", HookToken: "a"}})
+	if err := m.Pause("c1"); err != nil {
+		t.Fatalf("Pause failed: %v", err)
+	}
+	defer m.StopLoop("c1")
+
+	reloaded, s := newSinkManager(t, sink.Options{Seed: 1})
+	if err := reloaded.LoadFromDB(); err != nil {
+		t.Fatalf("LoadFromDB failed: %v", err)
+	}
+	defer reloaded.StopLoop("c1")
+
+	st := reloaded.List()[0]
+	if !st.Running || !st.Paused {
+		t.Fatalf("Expected the loop to resume paused, got %+v", st)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if got := s.Stats().Requests; got != 0 {
+		t.Errorf("Paused loop sent %d requests after reload", got)
+	}
+}
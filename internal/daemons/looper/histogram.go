@@ -0,0 +1,116 @@
+package looper
+
+import (
+	"math"
+	"math/bits"
+	"time"
+)
+
+// subBucketBits sets the histogram precision: each power of two is split into
+// 2^subBucketBits linear sub-buckets, giving ~6% worst-case relative error
+const subBucketBits = 4
+
+// histogram is an HDR-style log-linear latency histogram with microsecond resolution
+type histogram struct {
+	counts []int64
+	total  int64
+	sum    int64 // µs
+	min    int64 // µs
+	max    int64 // µs
+}
+
+func bucketIndex(v uint64) int {
+	if v < 1<<(subBucketBits+1) {
+		return int(v)
+	}
+	shift := bits.Len64(v) - subBucketBits - 1
+	sub := v >> shift // in [2^subBucketBits, 2^(subBucketBits+1))
+	return (shift+1)<<subBucketBits + int(sub-1<<subBucketBits)
+}
+
+// bucketBounds returns the [lower, upper) range in µs covered by a bucket index
+func bucketBounds(idx int) (uint64, uint64) {
+	shift := idx>>subBucketBits - 1
+	if shift <= 0 {
+		return uint64(idx), uint64(idx) + 1
+	}
+	sub := uint64(idx&(1<<subBucketBits-1)) + 1<<subBucketBits
+	return sub << shift, (sub + 1) << shift
+}
+
+func (h *histogram) record(d time.Duration) {
+	us := d.Microseconds()
+	if us < 0 {
+		us = 0
+	}
+
+	idx := bucketIndex(uint64(us))
+	if idx >= len(h.counts) {
+		grown := make([]int64, idx+1)
+		copy(grown, h.counts)
+		h.counts = grown
+	}
+	h.counts[idx]++
+
+	if h.total == 0 || us < h.min {
+		h.min = us
+	}
+	if us > h.max {
+		h.max = us
+	}
+	h.total++
+	h.sum += us
+}
+
+// quantile returns the latency at or below which a fraction q of samples fall
+func (h *histogram) quantile(q float64) time.Duration {
+	if h.total == 0 {
+		return 0
+	}
+	target := int64(math.Ceil(q * float64(h.total)))
+	if target < 1 {
+		target = 1
+	}
+
+	var seen int64
+	for idx, c := range h.counts {
+		seen += c
+		if seen >= target {
+			_, upper := bucketBounds(idx)
+			v := int64(upper) - 1
+			if v > h.max {
+				v = h.max
+			}
+			if v < h.min {
+				v = h.min
+			}
+			return time.Duration(v) * time.Microsecond
+		}
+	}
+	return time.Duration(h.max) * time.Microsecond
+}
+
+func (h *histogram) mean() time.Duration {
+	if h.total == 0 {
+		return 0
+	}
+	return time.Duration(h.sum/h.total) * time.Microsecond
+}
+
+// LatencyBucket is one non-empty histogram bucket in a report
+type LatencyBucket struct {
+	UpperMs float64 `json:"upperMs"`
+	Count   int64   `json:"count"`
+}
+
+func (h *histogram) buckets() []LatencyBucket {
+	var out []LatencyBucket
+	for idx, c := range h.counts {
+		if c == 0 {
+			continue
+		}
+		_, upper := bucketBounds(idx)
+		out = append(out, LatencyBucket{UpperMs: float64(upper) / 1000, Count: c})
+	}
+	return out
+}
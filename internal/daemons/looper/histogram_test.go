@@ -0,0 +1,46 @@
+package looper
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBucketBoundsRoundTrip(t *testing.T) {
+	for _, v := range []uint64{0, 1, 15, 16, 31, 32, 33, 100, 1000, 123456, 1 << 30} {
+		lower, upper := bucketBounds(bucketIndex(v))
+		if v < lower || v >= upper {
+			t.Errorf("Value %d outside its bucket [%d, %d)", v, lower, upper)
+		}
+	}
+}
+
+func TestHistogramQuantile(t *testing.T) {
+	var h histogram
+	for i := 1; i <= 1000; i++ {
+		h.record(time.Duration(i) * time.Millisecond)
+	}
+
+	tests := []struct {
+		q    float64
+		want time.Duration
+	}{
+		{0.50, 500 * time.Millisecond},
+		{0.95, 950 * time.Millisecond},
+		{0.99, 990 * time.Millisecond},
+		{1.00, 1000 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		got := h.quantile(tt.q)
+		// Log-linear buckets keep the relative error within 1/16
+		if diff := got - tt.want; diff < 0 || diff > tt.want/16 {
+			t.Errorf("quantile(%v) = %v, want ~%v", tt.q, got, tt.want)
+		}
+	}
+
+	if h.min != 1000 || h.max != 1000000 {
+		t.Errorf("Unexpected min/max: %d/%d", h.min, h.max)
+	}
+	if mean := h.mean(); mean != 500500*time.Microsecond {
+		t.Errorf("Unexpected mean: %v", mean)
+	}
+}
@@ -0,0 +1,144 @@
+package looper
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// LatePolicy decides what happens to requests that are due while every worker is busy
+type LatePolicy string
+
+const (
+	// LateQueue buffers late requests up to QueueSize and drops the rest
+	LateQueue LatePolicy = "queue"
+	// LateSkip drops late requests immediately
+	LateSkip LatePolicy = "skip"
+)
+
+const (
+	defaultMaxInFlight  = 50
+	defaultQueueSize    = 1000
+	defaultErrorRateMin = 50 // requests seen before MaxErrorRate is evaluated
+)
+
+// ParseLatePolicy validates a user-supplied policy, defaulting to queue
+func ParseLatePolicy(s string) (LatePolicy, bool) {
+	switch LatePolicy(s) {
+	case "", LateQueue:
+		return LateQueue, true
+	case LateSkip:
+		return LateSkip, true
+	}
+	return "", false
+}
+
+// job is a single webhook request scheduled by a run
+type job struct {
+	hook      WebhookData
+	hookIndex int   // position of hook in the run's hook list
+	iteration int64 // iteration that scheduled the request
+}
+
+// workerPool bounds the number of in-flight requests of a run
+type workerPool struct {
+	jobs    chan job
+	workers sync.WaitGroup
+}
+
+// newWorkerPool starts the workers of a run; they exit when ctx is cancelled
+// or once drain has closed the queue and they have emptied it
+func (m *Manager) newWorkerPool(ctx context.Context, run *loopRun) *workerPool {
+	workers := run.cfg.MaxInFlight
+	if workers <= 0 {
+		workers = defaultMaxInFlight
+	}
+
+	// An unbuffered channel only accepts a job when a worker is idle
+	queue := 0
+	if run.cfg.LatePolicy != LateSkip {
+		queue = run.cfg.QueueSize
+		if queue <= 0 {
+			queue = defaultQueueSize
+		}
+	}
+
+	p := &workerPool{jobs: make(chan job, queue)}
+	for n := 0; n < workers; n++ {
+		m.runs.Add(1)
+		p.workers.Add(1)
+		go func() {
+			defer m.runs.Done()
+			defer p.workers.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case j, ok := <-p.jobs:
+					if !ok {
+						return
+					}
+					m.executeWebhook(ctx, run, j)
+				}
+			}
+		}()
+	}
+	return p
+}
+
+// drain closes the queue and returns a channel that is closed once every
+// worker has finished the requests queued or in flight. Nothing may be
+// submitted afterwards.
+func (p *workerPool) drain() <-chan struct{} {
+	close(p.jobs)
+	done := make(chan struct{})
+	go func() {
+		p.workers.Wait()
+		close(done)
+	}()
+	return done
+}
+
+// submit hands a request to the pool without blocking the scheduler
+func (p *workerPool) submit(j job) bool {
+	select {
+	case p.jobs <- j:
+		return true
+	default:
+		return false
+	}
+}
+
+// complete reports whether every request of the last allowed iteration has
+// been scheduled, so the run only has to let its workers finish them
+func (r *loopRun) complete() bool {
+	limit := r.cfg.MaxIterations
+	return limit > 0 && r.round >= limit && r.pending == 0
+}
+
+// stopReason reports why a run should stop itself at once, or "" to keep going
+func (r *loopRun) stopReason() string {
+	cfg := r.cfg
+
+	r.stats.mu.Lock()
+	requests, errors := r.stats.requests, r.stats.errors
+	r.stats.mu.Unlock()
+
+	if cfg.MaxErrorRate > 0 && requests >= defaultErrorRateMin {
+		rate := float64(errors) / float64(requests)
+		if rate > cfg.MaxErrorRate {
+			return fmt.Sprintf("error rate %.1f%% exceeded %.1f%%", rate*100, cfg.MaxErrorRate*100)
+		}
+	}
+	return ""
+}
+
+// maxDuration returns a channel that fires when the run has lasted MaxDuration
+func (r *loopRun) maxDuration() (<-chan time.Time, func()) {
+	if r.cfg.MaxDuration <= 0 {
+		return nil, func() {}
+	}
+	t := time.NewTimer(time.Duration(r.cfg.MaxDuration) * time.Second)
+	return t.C, func() { t.Stop() }
+}
@@ -3,14 +3,19 @@ package looper
 import (
 	"bytes"
 	"context"
-	"encoding/json"
+	"crypto/cipher"
 	"fmt"
-	"log"
+	"io"
+	"math/rand"
 	"net/http"
+	"net/url"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/leeineian/minder/internal/database"
+	"github.com/leeineian/minder/internal/logger"
 )
 
 // LoopConfig matches the DB JSON structure
@@ -21,6 +26,29 @@ type LoopConfig struct {
 	Message       string `json:"message"`
 	WebhookAuthor string `json:"webhook_author"`
 	WebhookAvatar string `json:"webhook_avatar"`
+
+	RateLimitMode RateLimitMode `json:"rateLimitMode,omitempty"`
+	Profile       LoadProfile   `json:"profile,omitempty"`
+
+	MaxInFlight int        `json:"maxInFlight,omitempty"`
+	LatePolicy  LatePolicy `json:"latePolicy,omitempty"`
+	QueueSize   int        `json:"queueSize,omitempty"`
+
+	// Stop conditions; zero disables each
+	MaxIterations int64   `json:"maxIterations,omitempty"`
+	MaxDuration   int     `json:"maxDuration,omitempty"`  // seconds
+	MaxErrorRate  float64 `json:"maxErrorRate,omitempty"` // fraction, 0-1
+
+	// UseThreads posts into a thread of each hook's channel via thread_id,
+	// creating ThreadName threads where none is mapped yet
+	UseThreads bool   `json:"useThreads,omitempty"`
+	ThreadName string `json:"threadName,omitempty"`
+
+	// Payload replaces Message, WebhookAuthor and WebhookAvatar with a template
+	// rendered per request. Preset names where it came from; the loop keeps its
+	// own copy so later preset edits do not affect it.
+	Preset  string   `json:"preset,omitempty"`
+	Payload *Payload `json:"payload,omitempty"`
 }
 
 type ThreadMap map[string]string // channelId -> threadId
@@ -28,92 +56,395 @@ type ThreadMap map[string]string // channelId -> threadId
 type LoopInstance struct {
 	Config  LoopConfig
 	Hooks   []WebhookData `json:"hooks"` // simplified
-	cancel  context.CancelFunc
-	running bool
+	Threads ThreadMap
+
+	mu         sync.Mutex
+	cancel     context.CancelFunc
+	run        *loopRun
+	running    bool
+	startedAt  time.Time
+	stoppedAt  time.Time
+	stopReason string
+	stats      *loopStats
+
+	paused   bool
+	pausedAt time.Time
+	version  int // bumped when Update changes the interval or hooks
+}
+
+// LoopStatus is a point-in-time snapshot of a loop and its live statistics
+type LoopStatus struct {
+	ChannelID   string
+	ChannelName string
+	Preset      string
+	Interval    time.Duration
+	Profile     LoadProfile
+	Hooks       int
+	UseThreads  bool
+	Threads     int
+	Running     bool
+	Paused      bool
+	PausedAt    time.Time
+	StopReason  string
+	StartedAt   time.Time
+	Uptime      time.Duration
+	Iterations  int64
+	Success     int64
+	RateLimited int64
+	Errors      int64
+	Skipped     int64
+	P50         time.Duration
+	P95         time.Duration
+
+	RateLimitMode RateLimitMode
+	GlobalLimited int64         // 429s that applied to the global limit
+	Throttled     time.Duration // time spent waiting on buckets in respect mode
 }
 
 type WebhookData struct {
 	HookID      string `json:"id"`
 	HookToken   string `json:"token"`
+	ChannelID   string `json:"channelId,omitempty"`
 	ChannelName string `json:"channelName"`
 }
 
+// DefaultBaseURL is the Discord API root webhook requests are sent to
+const DefaultBaseURL = "https://discord.com/api"
+
 type Manager struct {
-	loops sync.Map // map[channelID]*LoopInstance
+	// BaseURL overrides the API root, e.g. to target a local webhook sink
+	BaseURL string
+	// Client overrides the HTTP client used to execute webhooks
+	Client *http.Client
+	// Threads creates threads for thread-targeted loops
+	Threads ThreadCreator
+	// Webhooks creates and deletes the webhooks loops post through
+	Webhooks WebhookProvisioner
+	// Secret is the key material webhook tokens are encrypted with at rest;
+	// tokens are stored as-is when it is empty
+	Secret string
+
+	loops sync.Map       // map[channelID]*LoopInstance
+	runs  sync.WaitGroup // loop and worker goroutines
+
+	limiterOnce sync.Once
+	limiter     *rateLimiter
+
+	cipherOnce sync.Once
+	aead       cipher.AEAD
+	cipherErr  error
 }
 
 var GlobalManager = &Manager{}
 
-func (m *Manager) LoadFromDB() error {
-	rows, err := database.DB.Query("SELECT channelId, config, threads FROM webhook_loops")
-	if err != nil {
-		return err
+// Get returns the stored configuration and hooks for a known loop
+func (m *Manager) Get(channelID string) (LoopConfig, []WebhookData, bool) {
+	val, ok := m.loops.Load(channelID)
+	if !ok {
+		return LoopConfig{}, nil, false
 	}
-	defer rows.Close()
+	instance := val.(*LoopInstance)
+	instance.mu.Lock()
+	defer instance.mu.Unlock()
+	return instance.Config, append([]WebhookData(nil), instance.Hooks...), true
+}
 
-	count := 0
-	for rows.Next() {
-		var id, configRaw, threadsRaw string
-		if err := rows.Scan(&id, &configRaw, &threadsRaw); err != nil {
-			log.Printf("Failed to scan loop: %v", err)
-			continue
-		}
-		// In a real implementation we would parse and populate the state.
-		// For now we just log it.
-		count++
+// StartLoop starts a loop for a given configuration. Starting a loop that is
+// already running does nothing.
+func (m *Manager) StartLoop(cfg LoopConfig, hooks []WebhookData) error {
+	if len(hooks) == 0 {
+		return fmt.Errorf("%w: %s", ErrNoHooks, cfg.ChannelID)
 	}
-	log.Printf("Loaded %d loop configurations from DB", count)
+
+	val, _ := m.loops.LoadOrStore(cfg.ChannelID, &LoopInstance{Threads: ThreadMap{}})
+	instance := val.(*LoopInstance)
+
+	instance.mu.Lock()
+	if instance.running {
+		instance.mu.Unlock()
+		return nil // Already running
+	}
+	instance.Config = cfg
+	instance.Hooks = hooks
+	ctx, run := instance.start()
+	instance.mu.Unlock()
+
+	m.persist(instance)
+	m.runs.Add(1)
+	go m.runLoop(ctx, run)
 	return nil
 }
 
-// StartLoop starts a loop for a given configuration
-func (m *Manager) StartLoop(cfg LoopConfig, hooks []WebhookData) {
-	if _, loaded := m.loops.Load(cfg.ChannelID); loaded {
-		return // Already running
-	}
+// loopRun holds the state owned by a single run of a loop
+type loopRun struct {
+	cfg     LoopConfig
+	message atomic.Pointer[runMessage]
+	stats   *loopStats
+
+	instance *LoopInstance
+	threads  threadTargets
+	wake     chan struct{}
+
+	// Owned by the loop goroutine
+	hooks   []WebhookData
+	version int   // instance version the schedule was built for
+	seq     int   // next hook to target
+	round   int64 // iteration the latest request belongs to
+	pending int   // requests left in the current round
+	pool    *workerPool
+}
 
+// start marks the instance as running with fresh statistics; callers hold mu
+func (l *LoopInstance) start() (context.Context, *loopRun) {
 	ctx, cancel := context.WithCancel(context.Background())
-	instance := &LoopInstance{
-		Config:  cfg,
-		Hooks:   hooks,
-		cancel:  cancel,
-		running: true,
-	}
-	m.loops.Store(cfg.ChannelID, instance)
+	l.cancel = cancel
+	l.running = true
+	l.startedAt = time.Now()
+	l.stopReason = ""
+	l.stats = newLoopStats()
+	l.paused = false
 
-	go m.runLoop(ctx, instance)
+	threads := make(ThreadMap, len(l.Threads))
+	for k, v := range l.Threads {
+		threads[k] = v
+	}
+	l.run = &loopRun{
+		cfg:      l.Config,
+		stats:    l.stats,
+		instance: l,
+		threads:  threadTargets{threads: threads},
+		wake:     make(chan struct{}, 1),
+		hooks:    append([]WebhookData(nil), l.Hooks...),
+		version:  l.version,
+	}
+	l.run.message.Store(newRunMessage(l.Config))
+	return ctx, l.run
 }
 
 func (m *Manager) StopLoop(channelID string) {
-	if val, ok := m.loops.Load(channelID); ok {
+	val, ok := m.loops.Load(channelID)
+	if !ok {
+		return
+	}
+	m.stop(val.(*LoopInstance), nil, "stopped manually")
+}
+
+// stop ends the instance's current run. If run is non-nil the stop only
+// applies while that run is still current, so a run that stops itself
+// cannot end a newer one.
+func (m *Manager) stop(instance *LoopInstance, run *loopRun, reason string) {
+	instance.mu.Lock()
+	if !instance.running || (run != nil && instance.run != run) {
+		instance.mu.Unlock()
+		return
+	}
+	instance.cancel()
+	instance.running = false
+	instance.paused = false
+	instance.stoppedAt = time.Now()
+	instance.stopReason = reason
+	instance.mu.Unlock()
+
+	m.persist(instance)
+	logger.Info("Stopped loop", "channel", instance.Config.ChannelName, "reason", reason)
+}
+
+// finish stops a run from inside its own goroutine once a stop condition is met
+func (m *Manager) finish(run *loopRun, reason string) {
+	if val, ok := m.loops.Load(run.cfg.ChannelID); ok {
+		m.stop(val.(*LoopInstance), run, reason)
+	}
+}
+
+// List returns a snapshot of every loop known to the manager, sorted by channel name
+func (m *Manager) List() []LoopStatus {
+	var list []LoopStatus
+	m.loops.Range(func(_, val any) bool {
+		list = append(list, val.(*LoopInstance).status())
+		return true
+	})
+
+	sort.Slice(list, func(a, b int) bool {
+		if list[a].ChannelName != list[b].ChannelName {
+			return list[a].ChannelName < list[b].ChannelName
+		}
+		return list[a].ChannelID < list[b].ChannelID
+	})
+	return list
+}
+
+// Wait blocks until the goroutines of every stopped run have exited
+func (m *Manager) Wait() {
+	m.runs.Wait()
+}
+
+// Shutdown cancels every running loop without marking it stopped, waits for
+// the loop goroutines until ctx expires and saves each loop's state, so the
+// loops running now resume on the next LoadFromDB
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.loops.Range(func(_, val any) bool {
 		instance := val.(*LoopInstance)
-		instance.cancel()
-		m.loops.Delete(channelID)
-		log.Printf("Stopped loop for %s", instance.Config.ChannelName)
+		instance.mu.Lock()
+		if instance.running {
+			instance.cancel()
+		}
+		instance.mu.Unlock()
+		return true
+	})
+
+	done := make(chan struct{})
+	go func() {
+		m.runs.Wait()
+		close(done)
+	}()
+	var err error
+	select {
+	case <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	m.loops.Range(func(_, val any) bool {
+		m.persist(val.(*LoopInstance))
+		return true
+	})
+	return err
+}
+
+// Status returns a snapshot of a single loop
+func (m *Manager) Status(channelID string) (LoopStatus, bool) {
+	val, ok := m.loops.Load(channelID)
+	if !ok {
+		return LoopStatus{}, false
 	}
+	return val.(*LoopInstance).status(), true
 }
 
-func (m *Manager) runLoop(ctx context.Context, instance *LoopInstance) {
-	log.Printf("Starting loop for %s with interval %dms", instance.Config.ChannelName, instance.Config.Interval)
+func (l *LoopInstance) status() LoopStatus {
+	l.mu.Lock()
+	st := LoopStatus{
+		ChannelID:   l.Config.ChannelID,
+		ChannelName: l.Config.ChannelName,
+		Preset:      l.Config.Preset,
+		Interval:    time.Duration(l.Config.Interval) * time.Millisecond,
+		Profile:     l.Config.Profile,
+		Hooks:       len(l.Hooks),
+		UseThreads:  l.Config.UseThreads,
+		Threads:     len(l.Threads),
+		Running:     l.running,
+		Paused:      l.paused,
+		PausedAt:    l.pausedAt,
+		StopReason:  l.stopReason,
+		StartedAt:   l.startedAt,
 
-	interval := time.Duration(instance.Config.Interval) * time.Millisecond
-	if interval == 0 {
-		interval = 1 * time.Second // Safety minimum set to 1 sec if 0
+		RateLimitMode: l.Config.RateLimitMode,
+	}
+	if st.RateLimitMode == "" {
+		st.RateLimitMode = RateLimitRespect
 	}
+	stats := l.stats
+	l.mu.Unlock()
 
-	ticker := time.NewTicker(interval)
-	defer ticker.Stop()
+	if st.Running {
+		st.Uptime = time.Since(st.StartedAt)
+	}
+	if stats != nil {
+		stats.fill(&st)
+	}
+	return st
+}
 
-	// Initial run
-	m.executeWebhooks(instance)
+// loopInterval converts a configured interval to a duration
+func loopInterval(ms int) time.Duration {
+	if ms <= 0 {
+		return 1 * time.Second // Safety minimum set to 1 sec if 0
+	}
+	return time.Duration(ms) * time.Millisecond
+}
+
+func (m *Manager) runLoop(ctx context.Context, run *loopRun) {
+	defer m.runs.Done()
+	interval := loopInterval(run.cfg.Interval)
+
+	logger.Info("Starting loop",
+		"channel", run.cfg.ChannelName,
+		"interval", interval,
+		"profile", run.cfg.Profile.String())
+
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+	sched := newScheduler(run.cfg.Profile, interval, len(run.hooks), rng)
+	run.pool = m.newWorkerPool(ctx, run)
+	deadline, stopDeadline := run.maxDuration()
+	defer stopDeadline()
+
+	start := time.Now()
+	next := sched.next()
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	paused := false
+	var pausedAt time.Time
+	var drained <-chan struct{} // set once the last iteration is scheduled
+
+	// apply picks up Pause, Resume and Update calls made since the last wake-up
+	apply := func() {
+		ctl := run.instance.control()
+		if ctl.version != run.version {
+			run.version = ctl.version
+			run.hooks = ctl.hooks
+			interval = loopInterval(ctl.interval)
+			sched = newScheduler(run.cfg.Profile, interval, len(run.hooks), rng)
+			start, next = time.Now(), sched.next()
+			// The new schedule starts now, so a pause that outlasts the
+			// update only shifts it by the time paused from here on
+			pausedAt = start
+		}
+		switch {
+		case ctl.paused && !paused:
+			paused, pausedAt = true, time.Now()
+		case !ctl.paused && paused:
+			// Shift the schedule by the pause so it resumes where it left off
+			paused = false
+			start = start.Add(time.Since(pausedAt))
+		}
+	}
+	apply()
 
 	for {
+		// Open loop: every tick is due at a fixed offset from the start, so
+		// slow responses never delay or thin out the requests that follow
+		var due <-chan time.Time
+		if !paused && drained == nil {
+			timer.Reset(time.Until(start.Add(next.at)))
+			due = timer.C
+		}
+
 		select {
 		case <-ctx.Done():
 			return
-		case <-ticker.C:
-			// Execute Webhooks
-			m.executeWebhooks(instance)
+		case <-deadline:
+			m.finish(run, fmt.Sprintf("ran for %ds", run.cfg.MaxDuration))
+			return
+		case <-run.wake:
+			apply()
+		case <-drained:
+			if ctx.Err() == nil {
+				m.finish(run, fmt.Sprintf("reached %d iterations", run.cfg.MaxIterations))
+			}
+			return
+		case <-due:
+			m.dispatch(run, next.count)
+			if reason := run.stopReason(); reason != "" {
+				m.finish(run, reason)
+				return
+			}
+			if run.complete() {
+				// Let the requests already queued or in flight finish so
+				// the last iteration is reported in full
+				drained = run.pool.drain()
+				continue
+			}
+			next = sched.next()
 		}
 	}
 }
@@ -128,41 +459,138 @@ var httpClient = &http.Client{
 	},
 }
 
-func (m *Manager) executeWebhooks(instance *LoopInstance) {
-	var wg sync.WaitGroup
+func (m *Manager) webhookURL(h WebhookData, threadID string) string {
+	base := m.BaseURL
+	if base == "" {
+		base = DefaultBaseURL
+	}
+	u := fmt.Sprintf("%s/webhooks/%s/%s", strings.TrimSuffix(base, "/"), h.HookID, h.HookToken)
+	if threadID != "" {
+		u += "?thread_id=" + url.QueryEscape(threadID)
+	}
+	return u
+}
 
-	payload := map[string]interface{}{
-		"content":    instance.Config.Message,
-		"username":   instance.Config.WebhookAuthor,
-		"avatar_url": instance.Config.WebhookAvatar,
+func (m *Manager) client() *http.Client {
+	if m.Client != nil {
+		return m.Client
 	}
+	return httpClient
+}
 
-	body, _ := json.Marshal(payload)
+// rateLimiter returns the limiter shared by every loop of the manager
+func (m *Manager) rateLimiter() *rateLimiter {
+	m.limiterOnce.Do(func() {
+		m.limiter = newRateLimiter()
+	})
+	return m.limiter
+}
 
-	for _, hook := range instance.Hooks {
-		wg.Add(1)
-		go func(h WebhookData) {
-			defer wg.Done()
+// dispatch hands count requests to the worker pool without waiting for them,
+// targeting hooks round-robin. An iteration is one round of requests to every
+// hook, whichever profile spreads them out; no round starts past
+// MaxIterations. Requests the pool cannot take are skipped.
+func (m *Manager) dispatch(run *loopRun, count int) {
+	if len(run.hooks) == 0 {
+		return
+	}
 
-			url := fmt.Sprintf("https://discord.com/api/webhooks/%s/%s", h.HookID, h.HookToken)
-			req, err := http.NewRequest("POST", url, bytes.NewBuffer(body))
-			if err != nil {
+	for n := 0; n < count; n++ {
+		if run.pending == 0 {
+			if limit := run.cfg.MaxIterations; limit > 0 && run.round >= limit {
 				return
 			}
-			req.Header.Set("Content-Type", "application/json")
+			run.round = run.stats.tick()
+			run.pending = len(run.hooks)
+		}
+		run.pending--
 
-			resp, err := httpClient.Do(req)
-			if err != nil {
-				// log.Println("Webhook failed:", err) // Commented out to avoid spam
-				return
-			}
-			defer resp.Body.Close()
+		idx := run.seq % len(run.hooks)
+		run.seq++
+		if !run.pool.submit(job{hook: run.hooks[idx], hookIndex: idx, iteration: run.round}) {
+			run.stats.skip()
+		}
+	}
+}
 
-			// Handle rate limits? For stress testing, we often ignore them or log them.
-			if resp.StatusCode == 429 {
-				// We hit a rate limit
-			}
-		}(hook)
+// requestBody returns the body and content type of a job's request
+func (r *loopRun) requestBody(j job, threadID string) ([]byte, string, error) {
+	msg := r.message.Load()
+	if msg.payloadErr != nil {
+		return nil, "", msg.payloadErr
+	}
+	if msg.payload == nil {
+		return msg.body, "application/json", nil
+	}
+
+	channelID := threadID
+	if channelID == "" {
+		channelID = r.hookChannel(j.hook)
+	}
+	now := time.Now()
+	return msg.payload.render(TemplateData{
+		Iteration: j.iteration,
+		Timestamp: now.Format(time.RFC3339),
+		Unix:      now.Unix(),
+		HookIndex: j.hookIndex,
+		HookID:    j.hook.HookID,
+		ChannelID: channelID,
+		Loop:      r.cfg.ChannelName,
+	})
+}
+
+func (m *Manager) executeWebhook(ctx context.Context, run *loopRun, j job) {
+	h := j.hook
+	var threadID string
+	if run.cfg.UseThreads {
+		var err error
+		if threadID, err = m.threadFor(run, h); err != nil {
+			run.stats.record(0, 0, err)
+			return
+		}
+	}
+
+	limiter := m.rateLimiter()
+	reserved := run.cfg.RateLimitMode != RateLimitMeasure
+	if reserved {
+		waited, err := limiter.wait(ctx, h.HookID)
+		run.stats.throttle(waited)
+		if err != nil {
+			return
+		}
+		defer limiter.release(h.HookID)
+	}
+
+	body, contentType, err := run.requestBody(j, threadID)
+	if err != nil {
+		run.stats.record(0, 0, err)
+		return
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", m.webhookURL(h, threadID), bytes.NewReader(body))
+	if err != nil {
+		run.stats.record(0, 0, err)
+		return
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	start := time.Now()
+	resp, err := m.client().Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return // Loop stopped mid-request
+		}
+		run.stats.record(0, 0, err)
+		return
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body) // Drain so the connection can be reused
+
+	run.stats.record(resp.StatusCode, time.Since(start), nil)
+	if threadID != "" && resp.StatusCode == http.StatusNotFound {
+		m.dropThread(run, h, threadID)
+	}
+	if limiter.update(h.HookID, reserved, resp.StatusCode, resp.Header, time.Now()) {
+		run.stats.globalLimit()
 	}
-	wg.Wait()
 }
@@ -0,0 +1,301 @@
+package looper_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/leeineian/minder/internal/daemons/looper"
+	"github.com/leeineian/minder/internal/daemons/looper/sink"
+)
+
+func newSinkManager(t *testing.T, opts sink.Options) (*looper.Manager, *sink.Sink) {
+	t.Helper()
+	s := sink.New(opts)
+	srv := httptest.NewServer(s)
+	m := &looper.Manager{BaseURL: srv.URL, Client: srv.Client()}
+	t.Cleanup(func() {
+		// Stop and drain every run so none outlives the test's database
+		for _, l := range m.List() {
+			m.StopLoop(l.ChannelID)
+		}
+		m.Wait()
+		srv.Close()
+	})
+	return m, s
+}
+
+// waitFor polls cond until it holds or the deadline passes
+func waitFor(t *testing.T, timeout time.Duration, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("Condition not met before timeout")
+}
+
+func TestLoopAgainstSink(t *testing.T) {
+	m, s := newSinkManager(t, sink.Options{Latency: 2 * time.Millisecond, Seed: 1})
+
+	hooks := []looper.WebhookData{
+		{HookID: "1", HookToken: "a"},
+		{HookID: "2", HookToken: "b"},
+	}
+	m.StartLoop(looper.LoopConfig{ChannelID: "c1", ChannelName: "sink", Interval: 10, Message: "hi"}, hooks)
+	waitFor(t, 2*time.Second, func() bool { return s.Stats().Requests >= 10 })
+	m.StopLoop("c1")
+
+	st := s.Stats()
+	if st.ByWebhook["1"] == 0 || st.ByWebhook["2"] == 0 {
+		t.Errorf("Expected both webhooks to be hit, got %v", st.ByWebhook)
+	}
+
+	list := m.List()
+	if len(list) != 1 {
+		t.Fatalf("Expected 1 loop, got %d", len(list))
+	}
+	if list[0].Running {
+		t.Error("Expected loop to be stopped")
+	}
+	if list[0].Success == 0 || list[0].Errors != 0 {
+		t.Errorf("Unexpected loop stats: %+v", list[0])
+	}
+	if list[0].P50 < 2*time.Millisecond {
+		t.Errorf("Expected p50 to include sink latency, got %v", list[0].P50)
+	}
+}
+
+func TestLoopRespectsSinkBuckets(t *testing.T) {
+	m, s := newSinkManager(t, sink.Options{BucketLimit: 2, BucketWindow: 200 * time.Millisecond, Seed: 1})
+
+	hooks := []looper.WebhookData{{HookID: "1", HookToken: "a"}}
+	m.StartLoop(looper.LoopConfig{ChannelID: "c1", Interval: 5}, hooks)
+	time.Sleep(500 * time.Millisecond)
+	m.StopLoop("c1")
+
+	st := s.Stats()
+	if st.Success == 0 {
+		t.Fatal("Expected some requests to succeed")
+	}
+	// The first request learns the bucket, so at most one 429 per window is expected
+	if st.RateLimited > 3 {
+		t.Errorf("Expected the limiter to stay within the bucket, got %d 429s", st.RateLimited)
+	}
+}
+
+func TestLoopMeasureModeProbesLimits(t *testing.T) {
+	m, s := newSinkManager(t, sink.Options{BucketLimit: 1, BucketWindow: time.Second, Seed: 1})
+
+	hooks := []looper.WebhookData{{HookID: "1", HookToken: "a"}}
+	m.StartLoop(looper.LoopConfig{ChannelID: "c1", Interval: 5, RateLimitMode: looper.RateLimitMeasure}, hooks)
+	waitFor(t, 2*time.Second, func() bool { return m.List()[0].RateLimited >= 5 })
+	m.StopLoop("c1")
+
+	if got := s.Stats().RateLimited; got < 5 {
+		t.Errorf("Expected the sink to answer with 429s, got %d", got)
+	}
+}
+
+func TestLoopReport(t *testing.T) {
+	m, s := newSinkManager(t, sink.Options{ErrorRate: 0.5, Seed: 7})
+
+	if _, ok := m.Report("c1"); ok {
+		t.Fatal("Expected no report for an unknown loop")
+	}
+
+	hooks := []looper.WebhookData{{HookID: "1", HookToken: "a"}}
+	m.StartLoop(looper.LoopConfig{ChannelID: "c1", ChannelName: "report", Interval: 5}, hooks)
+	waitFor(t, 2*time.Second, func() bool { return s.Stats().Requests >= 20 })
+	m.StopLoop("c1")
+
+	r, ok := m.Report("c1")
+	if !ok {
+		t.Fatal("Expected a report for a stopped loop")
+	}
+	if r.Running || r.StoppedAt == nil {
+		t.Error("Expected the report to describe a finished run")
+	}
+	if r.StatusCodes[204] == 0 || r.StatusCodes[500] == 0 {
+		t.Errorf("Expected both 204 and 500 responses, got %v", r.StatusCodes)
+	}
+	if r.ErrorCategories[looper.ErrServer] != r.StatusCodes[500] {
+		t.Errorf("Expected every 500 to be categorized, got %v", r.ErrorCategories)
+	}
+	if r.Requests != r.Success+r.Errors+r.RateLimited {
+		t.Errorf("Counters do not add up: %+v", r)
+	}
+	if r.ThroughputRPS <= 0 || r.Latency.Samples != r.Requests {
+		t.Errorf("Unexpected throughput or latency samples: %+v", r)
+	}
+
+	data, err := r.JSON()
+	if err != nil {
+		t.Fatalf("Failed to encode JSON: %v", err)
+	}
+	var decoded looper.Report
+	if err := json.Unmarshal(data, &decoded); err != nil || decoded.Requests != r.Requests {
+		t.Errorf("JSON report does not round-trip: %v", err)
+	}
+
+	csvData, err := r.CSV()
+	if err != nil {
+		t.Fatalf("Failed to encode CSV: %v", err)
+	}
+	if !bytes.Contains(csvData, []byte("status,500,")) || !bytes.Contains(csvData, []byte("latency,p95_ms,")) {
+		t.Errorf("CSV report is missing rows:\n%s", csvData)
+	}
+}
+
+func TestLoopIsOpenLoop(t *testing.T) {
+	// Responses take far longer than the interval; the offered load must not drop
+	m, s := newSinkManager(t, sink.Options{Latency: 300 * time.Millisecond, Seed: 1})
+
+	hooks := []looper.WebhookData{{HookID: "1", HookToken: "a"}}
+	m.StartLoop(looper.LoopConfig{ChannelID: "c1", Interval: 10, RateLimitMode: looper.RateLimitMeasure}, hooks)
+	time.Sleep(250 * time.Millisecond)
+	requests := s.Stats().Requests
+	m.StopLoop("c1")
+
+	if requests < 15 {
+		t.Errorf("Expected ~25 requests in flight despite slow responses, got %d", requests)
+	}
+}
+
+func TestLoopBoundsInFlight(t *testing.T) {
+	m, s := newSinkManager(t, sink.Options{Latency: 100 * time.Millisecond, Seed: 1})
+
+	hooks := []looper.WebhookData{{HookID: "1", HookToken: "a"}}
+	m.StartLoop(looper.LoopConfig{
+		ChannelID:     "c1",
+		Interval:      2,
+		RateLimitMode: looper.RateLimitMeasure,
+		MaxInFlight:   3,
+		LatePolicy:    looper.LateSkip,
+	}, hooks)
+	time.Sleep(300 * time.Millisecond)
+	m.StopLoop("c1")
+
+	if peak := s.Stats().PeakInFlight; peak > 3 {
+		t.Errorf("Expected at most 3 requests in flight, got %d", peak)
+	}
+	if skipped := m.List()[0].Skipped; skipped == 0 {
+		t.Error("Expected late requests to be skipped")
+	}
+}
+
+func TestLoopStopConditions(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   sink.Options
+		cfg    looper.LoopConfig
+		reason string
+	}{
+		{"max iterations", sink.Options{}, looper.LoopConfig{Interval: 1, MaxIterations: 5}, "iterations"},
+		{"max error rate", sink.Options{ErrorRate: 1}, looper.LoopConfig{Interval: 1, MaxErrorRate: 0.5}, "error rate"},
+		{"max duration", sink.Options{}, looper.LoopConfig{Interval: 50, MaxDuration: 1}, "ran for 1s"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, _ := newSinkManager(t, tt.opts)
+
+			tt.cfg.ChannelID = "c1"
+			m.StartLoop(tt.cfg, []looper.WebhookData{{HookID: "1", HookToken: "a"}})
+			waitFor(t, 3*time.Second, func() bool { return !m.List()[0].Running })
+
+			st := m.List()[0]
+			if !strings.Contains(st.StopReason, tt.reason) {
+				t.Errorf("Expected stop reason containing %q, got %q", tt.reason, st.StopReason)
+			}
+			if tt.cfg.MaxIterations > 0 && st.Iterations != tt.cfg.MaxIterations {
+				t.Errorf("Expected exactly %d iterations, got %d", tt.cfg.MaxIterations, st.Iterations)
+			}
+		})
+	}
+}
+
+func TestIterationsCountRounds(t *testing.T) {
+	profiles := map[string]looper.LoadProfile{
+		"fixed": {Type: looper.ProfileFixed},
+		"soak":  {Type: looper.ProfileSoak, RPS: 200},
+	}
+	for name, profile := range profiles {
+		t.Run(name, func(t *testing.T) {
+			m, s := newSinkManager(t, sink.Options{})
+
+			hooks := []looper.WebhookData{{HookID: "1", HookToken: "a"}, {HookID: "2", HookToken: "b"}}
+			m.StartLoop(looper.LoopConfig{
+				ChannelID:     "c1",
+				Interval:      1,
+				Profile:       profile,
+				MaxIterations: 3,
+				Payload:       &looper.Payload{Body: `{"content": "{{.Iteration}}"}`},
+			}, hooks)
+			waitFor(t, 3*time.Second, func() bool { return !m.List()[0].Running })
+			m.Wait()
+
+			if st := m.List()[0]; st.Iterations != 3 {
+				t.Errorf("Expected 3 iterations, got %d", st.Iterations)
+			}
+			rounds := map[string]int{}
+			for _, req := range s.Requests() {
+				var body struct {
+					Content string `json:"content"`
+				}
+				json.Unmarshal(req.Body, &body)
+				rounds[body.Content]++
+			}
+			if want := map[string]int{"1": 2, "2": 2, "3": 2}; !reflect.DeepEqual(rounds, want) {
+				t.Errorf("Expected every iteration to reach both hooks once, got %v", rounds)
+			}
+		})
+	}
+}
+
+func TestMaxIterationsFinishesLastRound(t *testing.T) {
+	// Responses outlast the whole schedule, so the last rounds are still in
+	// flight when the final iteration is scheduled
+	m, s := newSinkManager(t, sink.Options{Latency: 100 * time.Millisecond, Seed: 1})
+
+	hooks := []looper.WebhookData{{HookID: "1", HookToken: "a"}, {HookID: "2", HookToken: "b"}}
+	m.StartLoop(looper.LoopConfig{
+		ChannelID:     "c1",
+		Interval:      1,
+		RateLimitMode: looper.RateLimitMeasure,
+		MaxIterations: 5,
+	}, hooks)
+	waitFor(t, 3*time.Second, func() bool { return !m.List()[0].Running })
+	m.Wait()
+
+	if got := s.Stats().Requests; got != 10 {
+		t.Errorf("Expected the sink to receive 10 requests, got %d", got)
+	}
+	r, _ := m.Report("c1")
+	if r.Requests != 10 || r.Success != 10 {
+		t.Errorf("Expected 10 successful requests in the report, got %+v", r)
+	}
+	if !strings.Contains(r.StopReason, "reached 5 iterations") {
+		t.Errorf("Unexpected stop reason %q", r.StopReason)
+	}
+}
+
+func TestInvalidRequestIsRecorded(t *testing.T) {
+	m, s := newSinkManager(t, sink.Options{Seed: 1})
+
+	// A control character in the token makes the request URL invalid
+	hooks := []looper.WebhookData{{HookID: "1", HookToken: "a\nb"}}
+	m.StartLoop(looper.LoopConfig{ChannelID: "c1", Interval: 1, RateLimitMode: looper.RateLimitMeasure, MaxIterations: 3}, hooks)
+	waitFor(t, 2*time.Second, func() bool { return !m.List()[0].Running })
+
+	r, _ := m.Report("c1")
+	if r.Requests != 3 || r.Errors != 3 || s.Stats().Requests != 0 {
+		t.Errorf("Expected 3 failed requests that never reached the sink, got %+v", r)
+	}
+}
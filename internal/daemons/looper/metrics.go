@@ -0,0 +1,183 @@
+package looper
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Error categories reported for failed webhook requests
+const (
+	ErrTimeout    = "timeout"
+	ErrConnection = "connection"
+	ErrClient     = "http_4xx"
+	ErrServer     = "http_5xx"
+	ErrThread     = "thread"
+	ErrTemplate   = "template"
+	ErrOther      = "other"
+)
+
+// loopStats accumulates request metrics for a single run of a loop
+type loopStats struct {
+	mu          sync.Mutex
+	iterations  int64
+	requests    int64
+	success     int64
+	rateLimited int64
+	errors      int64
+	skipped     int64
+	global      int64
+	throttled   time.Duration
+	statusCodes map[int]int64
+	errorKinds  map[string]int64
+	latency     histogram
+}
+
+func newLoopStats() *loopStats {
+	return &loopStats{
+		statusCodes: make(map[int]int64),
+		errorKinds:  make(map[string]int64),
+	}
+}
+
+// record adds the outcome of a single webhook request
+func (s *loopStats) record(status int, latency time.Duration, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests++
+	if err != nil {
+		s.errors++
+		s.errorKinds[classifyError(err)]++
+		return
+	}
+
+	s.statusCodes[status]++
+	s.latency.record(latency)
+
+	switch {
+	case status == http.StatusTooManyRequests:
+		s.rateLimited++
+	case status >= 200 && status < 300:
+		s.success++
+	case status >= 500:
+		s.errors++
+		s.errorKinds[ErrServer]++
+	default:
+		s.errors++
+		s.errorKinds[ErrClient]++
+	}
+}
+
+// classifyError maps a transport error to a report category
+func classifyError(err error) string {
+	if errors.Is(err, errThread) {
+		return ErrThread
+	}
+	if errors.Is(err, errTemplate) {
+		return ErrTemplate
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrTimeout
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return ErrTimeout
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return ErrConnection
+	}
+	return ErrOther
+}
+
+// globalLimit counts a 429 that applied to the global rate limit
+func (s *loopStats) globalLimit() {
+	s.mu.Lock()
+	s.global++
+	s.mu.Unlock()
+}
+
+// throttle adds time spent waiting for a rate limit bucket to reset
+func (s *loopStats) throttle(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	s.mu.Lock()
+	s.throttled += d
+	s.mu.Unlock()
+}
+
+// skip counts a request dropped because the worker pool was saturated
+func (s *loopStats) skip() {
+	s.mu.Lock()
+	s.skipped++
+	s.mu.Unlock()
+}
+
+// tick counts one iteration (a round over the hooks) and returns its number, starting at 1
+func (s *loopStats) tick() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.iterations++
+	return s.iterations
+}
+
+// fill copies the counters and latency percentiles into a status snapshot
+func (s *loopStats) fill(st *LoopStatus) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st.Iterations = s.iterations
+	st.Success = s.success
+	st.RateLimited = s.rateLimited
+	st.Errors = s.errors
+	st.Skipped = s.skipped
+	st.GlobalLimited = s.global
+	st.Throttled = s.throttled
+	st.P50 = s.latency.quantile(0.50)
+	st.P95 = s.latency.quantile(0.95)
+}
+
+// report fills the metric sections of a run report
+func (s *loopStats) report(r *Report) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r.Iterations = s.iterations
+	r.Requests = s.requests
+	r.Success = s.success
+	r.RateLimited = s.rateLimited
+	r.GlobalLimited = s.global
+	r.Errors = s.errors
+	r.Skipped = s.skipped
+	r.ThrottledSeconds = s.throttled.Seconds()
+
+	r.StatusCodes = make(map[int]int64, len(s.statusCodes))
+	for code, n := range s.statusCodes {
+		r.StatusCodes[code] = n
+	}
+	r.ErrorCategories = make(map[string]int64, len(s.errorKinds))
+	for kind, n := range s.errorKinds {
+		r.ErrorCategories[kind] = n
+	}
+
+	r.Latency = LatencySummary{
+		Samples: s.latency.total,
+		MinMs:   ms(time.Duration(s.latency.min) * time.Microsecond),
+		MeanMs:  ms(s.latency.mean()),
+		P50Ms:   ms(s.latency.quantile(0.50)),
+		P90Ms:   ms(s.latency.quantile(0.90)),
+		P95Ms:   ms(s.latency.quantile(0.95)),
+		P99Ms:   ms(s.latency.quantile(0.99)),
+		MaxMs:   ms(time.Duration(s.latency.max) * time.Microsecond),
+		Buckets: s.latency.buckets(),
+	}
+}
+
+func ms(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
@@ -0,0 +1,179 @@
+package looper
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"mime/multipart"
+	"net/textproto"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// errTemplate marks requests whose payload template failed to render
+var errTemplate = errors.New("payload template failed")
+
+// Payload is a webhook message template. Body is rendered with text/template
+// for every request and must produce the JSON accepted by Discord's execute
+// webhook endpoint, so it can carry embeds, components and allowed_mentions.
+// Files are rendered the same way and uploaded as multipart attachments.
+type Payload struct {
+	Body  string        `json:"body"`
+	Files []PayloadFile `json:"files,omitempty"`
+}
+
+// PayloadFile is an attachment whose content is a template
+type PayloadFile struct {
+	Name        string `json:"name"`
+	ContentType string `json:"contentType,omitempty"`
+	Content     string `json:"content"`
+}
+
+// TemplateData holds the variables available to payload templates
+type TemplateData struct {
+	Iteration int64  // round over the hooks the request belongs to, starting at 1
+	Timestamp string // RFC 3339 time the request was rendered
+	Unix      int64  // Timestamp as Unix seconds
+	HookIndex int    // position of the webhook in the loop's hook list
+	HookID    string
+	ChannelID string // channel the webhook belongs to
+	Loop      string // name of the loop's channel
+}
+
+const randomAlphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// templateFuncs are available to payload templates in addition to the builtins:
+//
+//	{{random 8}}        8 random alphanumeric characters
+//	{{randInt 1 100}}   a random integer in [1, 100]
+//	{{json .Loop}}      a value encoded as JSON, for embedding strings safely
+var templateFuncs = template.FuncMap{
+	"random": func(n int) string {
+		if n <= 0 {
+			return ""
+		}
+		b := make([]byte, n)
+		for i := range b {
+			b[i] = randomAlphabet[rand.Intn(len(randomAlphabet))]
+		}
+		return string(b)
+	},
+	"randInt": func(min, max int) int {
+		if max <= min {
+			return min
+		}
+		return min + rand.Intn(max-min+1)
+	},
+	"json": func(v any) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+// payloadTemplate is a compiled Payload
+type payloadTemplate struct {
+	body  *template.Template
+	files []fileTemplate
+}
+
+type fileTemplate struct {
+	name, contentType string
+	content           *template.Template
+}
+
+// compile parses the body and file templates
+func (p Payload) compile() (*payloadTemplate, error) {
+	if strings.TrimSpace(p.Body) == "" {
+		return nil, fmt.Errorf("%w: body is empty", errTemplate)
+	}
+	body, err := template.New("body").Funcs(templateFuncs).Parse(p.Body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errTemplate, err)
+	}
+
+	t := &payloadTemplate{body: body}
+	for idx, f := range p.Files {
+		if f.Name == "" {
+			return nil, fmt.Errorf("%w: file %d has no name", errTemplate, idx)
+		}
+		content, err := template.New(f.Name).Funcs(templateFuncs).Parse(f.Content)
+		if err != nil {
+			return nil, fmt.Errorf("%w: file %s: %v", errTemplate, f.Name, err)
+		}
+		contentType := f.ContentType
+		if contentType == "" {
+			contentType = "text/plain"
+		}
+		t.files = append(t.files, fileTemplate{name: f.Name, contentType: contentType, content: content})
+	}
+	return t, nil
+}
+
+// Validate compiles the payload and renders it once with sample data,
+// checking that the body produces valid JSON
+func (p Payload) Validate() error {
+	t, err := p.compile()
+	if err != nil {
+		return err
+	}
+	_, _, err = t.render(TemplateData{
+		Iteration: 1,
+		Timestamp: time.Now().Format(time.RFC3339),
+		Unix:      time.Now().Unix(),
+		HookID:    "0",
+		ChannelID: "0",
+		Loop:      "preview",
+	})
+	return err
+}
+
+// render produces the request body and its content type. Payloads without
+// files are sent as JSON; with files they become multipart/form-data with the
+// JSON in payload_json and the attachments in files[n].
+func (t *payloadTemplate) render(data TemplateData) ([]byte, string, error) {
+	var body bytes.Buffer
+	if err := t.body.Execute(&body, data); err != nil {
+		return nil, "", fmt.Errorf("%w: %v", errTemplate, err)
+	}
+	if !json.Valid(body.Bytes()) {
+		return nil, "", fmt.Errorf("%w: body is not valid JSON", errTemplate)
+	}
+	if len(t.files) == 0 {
+		return body.Bytes(), "application/json", nil
+	}
+
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", `form-data; name="payload_json"`)
+	header.Set("Content-Type", "application/json")
+	part, err := w.CreatePart(header)
+	if err != nil {
+		return nil, "", err
+	}
+	part.Write(body.Bytes())
+
+	for idx, f := range t.files {
+		header := make(textproto.MIMEHeader)
+		header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="files[%d]"; filename="%s"`, idx, quoteEscaper.Replace(f.name)))
+		header.Set("Content-Type", f.contentType)
+		part, err := w.CreatePart(header)
+		if err != nil {
+			return nil, "", err
+		}
+		if err := f.content.Execute(part, data); err != nil {
+			return nil, "", fmt.Errorf("%w: file %s: %v", errTemplate, f.name, err)
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), w.FormDataContentType(), nil
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
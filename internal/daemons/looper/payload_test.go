@@ -0,0 +1,213 @@
+package looper_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/leeineian/minder/internal/daemons/looper"
+	"github.com/leeineian/minder/internal/daemons/looper/sink"
+	"github.com/leeineian/minder/internal/database/dbtest"
+)
+
+func TestPayloadValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload looper.Payload
+		wantErr bool
+	}{
+		{"plain", looper.Payload{Body: `{"content": "hi"}`}, false},
+		{"variables", looper.Payload{Body: `{"content": "#{{.Iteration}} {{random 8}} {{randInt 1 6}}", "embeds": [{"title": {{json .Loop}}, "timestamp": "{{.Timestamp}}"}]}`}, false},
+		{"empty", looper.Payload{}, true},
+		{"bad syntax", looper.Payload{Body: `{"content": "{{.Iteration"}`}, true},
+		{"unknown field", looper.Payload{Body: `{"content": "{{.Missing}}"}`}, true},
+		{"not json", looper.Payload{Body: `content: {{.Iteration}}`}, true},
+		{"unnamed file", looper.Payload{Body: `{}`, Files: []looper.PayloadFile{{Content: "x"}}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.payload.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoopRendersPayloadTemplate(t *testing.T) {
+	m, s := newSinkManager(t, sink.Options{Seed: 1})
+
+	hooks := []looper.WebhookData{{HookID: "1", HookToken: "a"}, {HookID: "2", HookToken: "b"}}
+	m.StartLoop(looper.LoopConfig{
+		ChannelID:   "c1",
+		ChannelName: "payload",
+		Interval:    10,
+		Payload: &looper.Payload{Body: `{
+			"content": "{{.Iteration}}/{{.HookIndex}}/{{.HookID}}/{{random 12}}",
+			"embeds": [{"title": {{json .Loop}}, "timestamp": "{{.Timestamp}}"}],
+			"allowed_mentions": {"parse": []}
+		}`},
+	}, hooks)
+	waitFor(t, 2*time.Second, func() bool { return s.Stats().Requests >= 4 })
+	m.StopLoop("c1")
+
+	randoms := map[string]bool{}
+	for _, req := range s.Requests() {
+		var body struct {
+			Content string `json:"content"`
+			Embeds  []struct {
+				Title     string `json:"title"`
+				Timestamp string `json:"timestamp"`
+			} `json:"embeds"`
+			AllowedMentions struct {
+				Parse []string `json:"parse"`
+			} `json:"allowed_mentions"`
+		}
+		if err := json.Unmarshal(req.Body, &body); err != nil {
+			t.Fatalf("Request body is not JSON: %v (%s)", err, req.Body)
+		}
+
+		parts := strings.Split(body.Content, "/")
+		if len(parts) != 4 || parts[0] == "0" || len(parts[3]) != 12 {
+			t.Fatalf("Unexpected rendered content %q", body.Content)
+		}
+		// Hooks are targeted round-robin, so the index must match the hook
+		if (parts[1] == "0") != (parts[2] == "1") || parts[2] != req.WebhookID {
+			t.Errorf("Content %q does not match webhook %s", body.Content, req.WebhookID)
+		}
+		randoms[parts[3]] = true
+
+		if len(body.Embeds) != 1 || body.Embeds[0].Title != "payload" {
+			t.Errorf("Unexpected embeds %+v", body.Embeds)
+		}
+		if _, err := time.Parse(time.RFC3339, body.Embeds[0].Timestamp); err != nil {
+			t.Errorf("Timestamp not RFC 3339: %v", err)
+		}
+		if body.AllowedMentions.Parse == nil {
+			t.Error("allowed_mentions was not sent")
+		}
+	}
+	if len(randoms) < 2 {
+		t.Errorf("Random strings were not rendered per request: %v", randoms)
+	}
+}
+
+func TestLoopSendsFilesAsMultipart(t *testing.T) {
+	m, s := newSinkManager(t, sink.Options{Seed: 1})
+
+	m.StartLoop(looper.LoopConfig{
+		ChannelID: "c1",
+		Interval:  10,
+		Payload: &looper.Payload{
+			Body:  `{"content": "see attachment", "embeds": [{"image": {"url": "attachment://report.txt"}}]}`,
+			Files: []looper.PayloadFile{{Name: "report.txt", Content: "iteration {{.Iteration}}"}},
+		},
+	}, []looper.WebhookData{{HookID: "1", HookToken: "a"}})
+	waitFor(t, 2*time.Second, func() bool { return s.Stats().Success >= 1 })
+	m.StopLoop("c1")
+
+	req := s.Requests()[0]
+	mediaType, params, err := mime.ParseMediaType(req.ContentType)
+	if err != nil || mediaType != "multipart/form-data" {
+		t.Fatalf("Expected multipart/form-data, got %q (%v)", req.ContentType, err)
+	}
+
+	parts := map[string]string{}
+	filenames := map[string]string{}
+	r := multipart.NewReader(strings.NewReader(string(req.Body)), params["boundary"])
+	for {
+		part, err := r.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Failed to read part: %v", err)
+		}
+		data, _ := io.ReadAll(part)
+		parts[part.FormName()] = string(data)
+		filenames[part.FormName()] = part.FileName()
+	}
+
+	if !json.Valid([]byte(parts["payload_json"])) {
+		t.Errorf("payload_json is not valid JSON: %q", parts["payload_json"])
+	}
+	if parts["files[0]"] != "iteration 1" || filenames["files[0]"] != "report.txt" {
+		t.Errorf("Unexpected file part %q named %q", parts["files[0]"], filenames["files[0]"])
+	}
+}
+
+func TestLoopReportsTemplateErrors(t *testing.T) {
+	m, s := newSinkManager(t, sink.Options{Seed: 1})
+
+	// Renders invalid JSON, so every request fails before it is sent
+	m.StartLoop(looper.LoopConfig{
+		ChannelID:     "c1",
+		Interval:      10,
+		MaxIterations: 3,
+		Payload:       &looper.Payload{Body: `{"content": {{.Iteration}`},
+	}, []looper.WebhookData{{HookID: "1", HookToken: "a"}})
+	waitFor(t, 2*time.Second, func() bool { return !m.List()[0].Running })
+
+	report, _ := m.Report("c1")
+	if report.ErrorCategories[looper.ErrTemplate] == 0 {
+		t.Errorf("Expected template errors, got %v", report.ErrorCategories)
+	}
+	if got := s.Stats().Requests; got != 0 {
+		t.Errorf("Expected no requests to reach the sink, got %d", got)
+	}
+}
+
+func TestPresets(t *testing.T) {
+	dbtest.Setup(t)
+
+	if err := looper.SavePreset("bad", looper.Payload{Body: "not json"}); err == nil {
+		t.Error("Expected an invalid payload to be rejected")
+	}
+
+	first := looper.Payload{Body: `{"content": "one"}`}
+	second := looper.Payload{
+		Body:  `{"content": "two {{.Iteration}}"}`,
+		Files: []looper.PayloadFile{{Name: "a.txt", Content: "{{random 4}}"}},
+	}
+	for name, p := range map[string]looper.Payload{"b": first, "a": first} {
+		if err := looper.SavePreset(name, p); err != nil {
+			t.Fatalf("SavePreset(%s) failed: %v", name, err)
+		}
+	}
+	// Saving again replaces the payload
+	if err := looper.SavePreset("b", second); err != nil {
+		t.Fatalf("SavePreset failed: %v", err)
+	}
+
+	preset, err := looper.GetPreset("b")
+	if err != nil {
+		t.Fatalf("GetPreset failed: %v", err)
+	}
+	if preset.Payload.Body != second.Body || len(preset.Payload.Files) != 1 {
+		t.Errorf("Unexpected preset %+v", preset)
+	}
+
+	presets, err := looper.ListPresets()
+	if err != nil {
+		t.Fatalf("ListPresets failed: %v", err)
+	}
+	if len(presets) != 2 || presets[0].Name != "a" || presets[1].Name != "b" {
+		t.Errorf("Unexpected presets %+v", presets)
+	}
+
+	if err := looper.DeletePreset("a"); err != nil {
+		t.Fatalf("DeletePreset failed: %v", err)
+	}
+	if err := looper.DeletePreset("a"); !errors.Is(err, looper.ErrPresetNotFound) {
+		t.Errorf("Expected ErrPresetNotFound, got %v", err)
+	}
+	if _, err := looper.GetPreset("a"); !errors.Is(err, looper.ErrPresetNotFound) {
+		t.Errorf("Expected ErrPresetNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,104 @@
+package looper
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/leeineian/minder/internal/database"
+)
+
+// ErrPresetNotFound is returned when no preset has the requested name
+var ErrPresetNotFound = errors.New("preset not found")
+
+// Preset is a named payload template stored in looper_presets
+type Preset struct {
+	Name      string
+	Payload   Payload
+	UpdatedAt time.Time
+}
+
+// SavePreset validates a payload and stores it under name, replacing any
+// existing preset with the same name
+func SavePreset(name string, p Payload) error {
+	if name == "" {
+		return errors.New("preset name is empty")
+	}
+	if err := p.Validate(); err != nil {
+		return err
+	}
+
+	raw, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	_, err = database.DB.Exec(`
+		INSERT INTO looper_presets (name, payload, updatedAt) VALUES (?, ?, ?)
+		ON CONFLICT(name) DO UPDATE SET
+			payload = excluded.payload,
+			updatedAt = excluded.updatedAt`,
+		name, string(raw), time.Now().Unix(),
+	)
+	if err != nil {
+		return fmt.Errorf("save preset %s: %w", name, err)
+	}
+	return nil
+}
+
+// GetPreset loads a preset by name
+func GetPreset(name string) (Preset, error) {
+	var raw string
+	var updated int64
+	err := database.DB.QueryRow("SELECT payload, updatedAt FROM looper_presets WHERE name = ?", name).Scan(&raw, &updated)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Preset{}, fmt.Errorf("%w: %s", ErrPresetNotFound, name)
+	}
+	if err != nil {
+		return Preset{}, fmt.Errorf("load preset %s: %w", name, err)
+	}
+
+	preset := Preset{Name: name, UpdatedAt: time.Unix(updated, 0)}
+	if err := json.Unmarshal([]byte(raw), &preset.Payload); err != nil {
+		return Preset{}, fmt.Errorf("decode preset %s: %w", name, err)
+	}
+	return preset, nil
+}
+
+// ListPresets returns every stored preset sorted by name
+func ListPresets() ([]Preset, error) {
+	rows, err := database.DB.Query("SELECT name, payload, updatedAt FROM looper_presets ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var presets []Preset
+	for rows.Next() {
+		var raw string
+		var updated int64
+		var preset Preset
+		if err := rows.Scan(&preset.Name, &raw, &updated); err != nil {
+			return nil, err
+		}
+		preset.UpdatedAt = time.Unix(updated, 0)
+		if err := json.Unmarshal([]byte(raw), &preset.Payload); err != nil {
+			return nil, fmt.Errorf("decode preset %s: %w", preset.Name, err)
+		}
+		presets = append(presets, preset)
+	}
+	return presets, rows.Err()
+}
+
+// DeletePreset removes a preset. Loops started from it keep their copy of the payload.
+func DeletePreset(name string) error {
+	res, err := database.DB.Exec("DELETE FROM looper_presets WHERE name = ?", name)
+	if err != nil {
+		return fmt.Errorf("delete preset %s: %w", name, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("%w: %s", ErrPresetNotFound, name)
+	}
+	return nil
+}
@@ -0,0 +1,199 @@
+package looper
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// ProfileType names a load profile
+type ProfileType string
+
+const (
+	// ProfileFixed posts to every hook once per Interval (the legacy behaviour)
+	ProfileFixed ProfileType = "fixed"
+	// ProfileRamp rises linearly from RPS to TargetRPS over Duration, then holds
+	ProfileRamp ProfileType = "ramp"
+	// ProfileBurst sends BurstSize requests at once every Period
+	ProfileBurst ProfileType = "burst"
+	// ProfileStep starts at RPS and adds StepRPS every Period, capped at TargetRPS
+	ProfileStep ProfileType = "step"
+	// ProfileSoak holds RPS with each gap randomly stretched or shrunk by Jitter
+	ProfileSoak ProfileType = "soak"
+)
+
+// LoadProfile describes the load offered by a loop. Rates are total requests
+// per second across all hooks; hooks are targeted round-robin.
+type LoadProfile struct {
+	Type      ProfileType `json:"type"`
+	RPS       float64     `json:"rps,omitempty"`
+	TargetRPS float64     `json:"targetRps,omitempty"`
+	StepRPS   float64     `json:"stepRps,omitempty"`
+	BurstSize int         `json:"burstSize,omitempty"`
+	Duration  int         `json:"duration,omitempty"` // seconds
+	Period    int         `json:"period,omitempty"`   // seconds
+	Jitter    float64     `json:"jitter,omitempty"`   // fraction of the gap, 0-1
+}
+
+// ParseProfileType validates a user-supplied profile name, defaulting to fixed
+func ParseProfileType(s string) (ProfileType, bool) {
+	switch t := ProfileType(s); t {
+	case "":
+		return ProfileFixed, true
+	case ProfileFixed, ProfileRamp, ProfileBurst, ProfileStep, ProfileSoak:
+		return t, true
+	}
+	return "", false
+}
+
+// Validate reports whether the profile has the parameters its type needs
+func (p LoadProfile) Validate() error {
+	switch p.Type {
+	case "", ProfileFixed:
+		return nil
+	case ProfileRamp:
+		if p.RPS < 0 || p.TargetRPS <= 0 || p.Duration <= 0 {
+			return fmt.Errorf("ramp needs rps >= 0, target-rps > 0 and duration > 0")
+		}
+	case ProfileBurst:
+		if p.BurstSize <= 0 || p.Period <= 0 {
+			return fmt.Errorf("burst needs burst > 0 and period > 0")
+		}
+	case ProfileStep:
+		if p.RPS <= 0 || p.StepRPS <= 0 || p.Period <= 0 {
+			return fmt.Errorf("step needs rps > 0, step-rps > 0 and period > 0")
+		}
+	case ProfileSoak:
+		if p.RPS <= 0 || p.Jitter < 0 || p.Jitter >= 1 {
+			return fmt.Errorf("soak needs rps > 0 and jitter between 0 and 1")
+		}
+	default:
+		return fmt.Errorf("unknown profile %q", p.Type)
+	}
+	return nil
+}
+
+// String describes the profile for list output
+func (p LoadProfile) String() string {
+	switch p.Type {
+	case ProfileRamp:
+		return fmt.Sprintf("ramp %.4g→%.4g rps over %ds", p.RPS, p.TargetRPS, p.Duration)
+	case ProfileBurst:
+		return fmt.Sprintf("burst %d every %ds", p.BurstSize, p.Period)
+	case ProfileStep:
+		s := fmt.Sprintf("step %.4g+%.4g rps every %ds", p.RPS, p.StepRPS, p.Period)
+		if p.TargetRPS > 0 {
+			s += fmt.Sprintf(" up to %.4g", p.TargetRPS)
+		}
+		return s
+	case ProfileSoak:
+		return fmt.Sprintf("soak %.4g rps ±%.0f%%", p.RPS, p.Jitter*100)
+	}
+	return "fixed"
+}
+
+// tick is one scheduled dispatch: count requests sent at offset at from the run start
+type tick struct {
+	at    time.Duration
+	count int
+}
+
+// scheduler produces an open-loop send schedule. Tick times depend only on
+// the profile, never on how quickly earlier requests completed.
+type scheduler interface {
+	next() tick
+}
+
+func newScheduler(p LoadProfile, interval time.Duration, hooks int, rng *rand.Rand) scheduler {
+	switch p.Type {
+	case ProfileRamp:
+		return &rampScheduler{p: p}
+	case ProfileBurst:
+		return &fixedScheduler{every: seconds(float64(p.Period)), count: p.BurstSize}
+	case ProfileStep:
+		return &stepScheduler{p: p}
+	case ProfileSoak:
+		return &soakScheduler{gap: seconds(1 / p.RPS), jitter: p.Jitter, rng: rng}
+	}
+	if hooks < 1 {
+		hooks = 1
+	}
+	return &fixedScheduler{every: interval, count: hooks}
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// fixedScheduler fires count requests every interval, starting immediately
+type fixedScheduler struct {
+	every time.Duration
+	count int
+	n     int64
+}
+
+func (s *fixedScheduler) next() tick {
+	t := tick{at: time.Duration(s.n) * s.every, count: s.count}
+	s.n++
+	return t
+}
+
+// rampScheduler places request k where the integral of the linear rate reaches k
+type rampScheduler struct {
+	p LoadProfile
+	n float64
+}
+
+func (s *rampScheduler) next() tick {
+	t := tick{at: seconds(s.arrival(s.n)), count: 1}
+	s.n++
+	return t
+}
+
+// arrival solves r0*t + (r1-r0)*t²/(2D) = k for t, continuing at r1 after D
+func (s *rampScheduler) arrival(k float64) float64 {
+	r0, r1, d := s.p.RPS, s.p.TargetRPS, float64(s.p.Duration)
+	rampTotal := (r0 + r1) / 2 * d
+	if k >= rampTotal {
+		return d + (k-rampTotal)/r1
+	}
+
+	a := (r1 - r0) / (2 * d)
+	if math.Abs(a) < 1e-12 {
+		return k / r0
+	}
+	return (-r0 + math.Sqrt(r0*r0+4*a*k)) / (2 * a)
+}
+
+// stepScheduler spaces requests by the rate of the step they fall in
+type stepScheduler struct {
+	p  LoadProfile
+	at float64 // seconds
+}
+
+func (s *stepScheduler) next() tick {
+	t := tick{at: seconds(s.at), count: 1}
+
+	rate := s.p.RPS + s.p.StepRPS*math.Floor(s.at/float64(s.p.Period))
+	if s.p.TargetRPS > 0 && rate > s.p.TargetRPS {
+		rate = s.p.TargetRPS
+	}
+	s.at += 1 / rate
+	return t
+}
+
+// soakScheduler holds a constant mean rate with jittered gaps
+type soakScheduler struct {
+	gap    time.Duration
+	jitter float64
+	rng    *rand.Rand
+	at     time.Duration
+}
+
+func (s *soakScheduler) next() tick {
+	t := tick{at: s.at, count: 1}
+	factor := 1 + s.jitter*(2*s.rng.Float64()-1)
+	s.at += time.Duration(float64(s.gap) * factor)
+	return t
+}
@@ -0,0 +1,80 @@
+package looper
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+// countUntil returns how many requests a scheduler offers before the deadline
+func countUntil(s scheduler, until time.Duration) int {
+	total := 0
+	for {
+		t := s.next()
+		if t.at >= until {
+			return total
+		}
+		total += t.count
+	}
+}
+
+func TestSchedulers(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	tests := []struct {
+		name    string
+		profile LoadProfile
+		until   time.Duration
+		want    int
+	}{
+		// 3 hooks every 100ms: ticks at 0..900ms
+		{"fixed", LoadProfile{}, time.Second, 30},
+		// Average of 10 and 30 rps over 10s, then 30 rps for 5s
+		{"ramp", LoadProfile{Type: ProfileRamp, RPS: 10, TargetRPS: 30, Duration: 10}, 15 * time.Second, 350},
+		{"ramp from zero", LoadProfile{Type: ProfileRamp, TargetRPS: 20, Duration: 10}, 10 * time.Second, 100},
+		// Bursts at 0, 5s, 10s, 15s
+		{"burst", LoadProfile{Type: ProfileBurst, BurstSize: 50, Period: 5}, 20 * time.Second, 200},
+		// 10 rps, 20 rps, then capped at 25 rps
+		{"step", LoadProfile{Type: ProfileStep, RPS: 10, StepRPS: 10, TargetRPS: 25, Period: 2}, 6 * time.Second, 110},
+		{"soak", LoadProfile{Type: ProfileSoak, RPS: 50, Jitter: 0.5}, 20 * time.Second, 1000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.profile.Validate(); err != nil {
+				t.Fatalf("Unexpected validation error: %v", err)
+			}
+			got := countUntil(newScheduler(tt.profile, 100*time.Millisecond, 3, rng), tt.until)
+			if math.Abs(float64(got-tt.want)) > float64(tt.want)/50+1 {
+				t.Errorf("Expected ~%d requests, got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSchedulerIsMonotonic(t *testing.T) {
+	s := newScheduler(LoadProfile{Type: ProfileSoak, RPS: 100, Jitter: 0.9}, 0, 1, rand.New(rand.NewSource(2)))
+	prev := time.Duration(-1)
+	for n := 0; n < 1000; n++ {
+		at := s.next().at
+		if at <= prev {
+			t.Fatalf("Tick %d at %v is not after %v", n, at, prev)
+		}
+		prev = at
+	}
+}
+
+func TestLoadProfileValidate(t *testing.T) {
+	invalid := []LoadProfile{
+		{Type: ProfileRamp, RPS: 1, Duration: 10},
+		{Type: ProfileBurst, BurstSize: 10},
+		{Type: ProfileStep, RPS: 1, Period: 5},
+		{Type: ProfileSoak, RPS: 10, Jitter: 1},
+		{Type: "chaos"},
+	}
+	for _, p := range invalid {
+		if err := p.Validate(); err == nil {
+			t.Errorf("Expected %+v to be invalid", p)
+		}
+	}
+}
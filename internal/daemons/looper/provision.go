@@ -0,0 +1,281 @@
+package looper
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/leeineian/minder/internal/database"
+	"github.com/leeineian/minder/internal/logger"
+)
+
+// WebhookName is the name of every webhook the looper creates
+const WebhookName = "minder-looper"
+
+// DefaultMessage is the message of loops created by Provision
+const DefaultMessage = "🔁 minder-looper"
+
+// WebhookProvisioner lists, creates and deletes webhooks. *discordgo.Session satisfies it.
+type WebhookProvisioner interface {
+	User(userID string, options ...discordgo.RequestOption) (*discordgo.User, error)
+	Channel(channelID string, options ...discordgo.RequestOption) (*discordgo.Channel, error)
+	GuildChannels(guildID string, options ...discordgo.RequestOption) ([]*discordgo.Channel, error)
+	ChannelWebhooks(channelID string, options ...discordgo.RequestOption) ([]*discordgo.Webhook, error)
+	GuildWebhooks(guildID string, options ...discordgo.RequestOption) ([]*discordgo.Webhook, error)
+	WebhookCreate(channelID, name, avatar string, options ...discordgo.RequestOption) (*discordgo.Webhook, error)
+	WebhookDelete(webhookID string, options ...discordgo.RequestOption) error
+}
+
+// Provision creates or reuses a WebhookName webhook in every text channel of a
+// category and saves them as the hook set of the category's loop, creating the
+// loop stopped if it does not exist yet. Channels that fail are skipped and
+// reported in the returned error alongside the hooks that succeeded.
+func (m *Manager) Provision(categoryID string) ([]WebhookData, error) {
+	if m.Webhooks == nil {
+		return nil, errors.New("no Discord session available to provision webhooks")
+	}
+
+	category, err := m.Webhooks.Channel(categoryID)
+	if err != nil {
+		return nil, fmt.Errorf("look up category %s: %w", categoryID, err)
+	}
+	if category.Type != discordgo.ChannelTypeGuildCategory {
+		return nil, fmt.Errorf("channel %s is not a category", categoryID)
+	}
+	channels, err := m.Webhooks.GuildChannels(category.GuildID)
+	if err != nil {
+		return nil, fmt.Errorf("list channels of guild %s: %w", category.GuildID, err)
+	}
+	botID, err := m.botUserID()
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(channels, func(a, b int) bool { return channels[a].Position < channels[b].Position })
+
+	var hooks []WebhookData
+	var errs []error
+	for _, ch := range channels {
+		if ch.ParentID != categoryID || !supportsWebhooks(ch.Type) {
+			continue
+		}
+		hook, err := m.channelWebhook(ch, botID)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		hooks = append(hooks, hook)
+	}
+	if len(hooks) == 0 {
+		if len(errs) == 0 {
+			return nil, fmt.Errorf("category %s has no text channels", category.Name)
+		}
+		return nil, errors.Join(errs...)
+	}
+
+	if err := m.saveProvisioned(category, hooks); err != nil {
+		errs = append(errs, err)
+	}
+	logger.Info("Provisioned looper webhooks", "category", category.Name, "hooks", len(hooks), "failed", len(errs))
+	return hooks, errors.Join(errs...)
+}
+
+// channelWebhook reuses the bot's looper webhook in a channel or creates one
+func (m *Manager) channelWebhook(ch *discordgo.Channel, botID string) (WebhookData, error) {
+	existing, err := m.Webhooks.ChannelWebhooks(ch.ID)
+	if err != nil {
+		return WebhookData{}, fmt.Errorf("list webhooks of #%s: %w", ch.Name, err)
+	}
+
+	var hook *discordgo.Webhook
+	for _, w := range existing {
+		if isLooperWebhook(w, botID) && w.Token != "" {
+			hook = w
+			break
+		}
+	}
+	if hook == nil {
+		if hook, err = m.Webhooks.WebhookCreate(ch.ID, WebhookName, ""); err != nil {
+			return WebhookData{}, fmt.Errorf("create webhook in #%s: %w", ch.Name, err)
+		}
+		logger.Debug("Created looper webhook", "channel", ch.Name, "webhookID", hook.ID)
+	}
+
+	if err := m.recordWebhook(hook, ch.GuildID); err != nil {
+		logger.Warn("Failed to record looper webhook", "webhookID", hook.ID, "error", err)
+	}
+	return WebhookData{HookID: hook.ID, HookToken: hook.Token, ChannelID: ch.ID, ChannelName: ch.Name}, nil
+}
+
+// saveProvisioned stores hooks on the category's loop
+func (m *Manager) saveProvisioned(category *discordgo.Channel, hooks []WebhookData) error {
+	instance := &LoopInstance{
+		Config: LoopConfig{
+			ChannelID:   category.ID,
+			ChannelName: category.Name,
+			Interval:    1000,
+			Message:     DefaultMessage,
+		},
+		Hooks:   hooks,
+		Threads: ThreadMap{},
+	}
+	if _, loaded := m.loops.LoadOrStore(category.ID, instance); loaded {
+		return m.Update(category.ID, LoopPatch{Hooks: hooks})
+	}
+	m.persist(instance)
+	return nil
+}
+
+// recordWebhook remembers a webhook the looper created or reuses so cleanup can find it
+func (m *Manager) recordWebhook(w *discordgo.Webhook, guildID string) error {
+	if database.DB == nil {
+		return nil
+	}
+	token, err := m.sealToken(w.Token)
+	if err != nil {
+		return err
+	}
+	_, err = database.DB.Exec(`
+		INSERT INTO looper_webhooks (webhookId, guildId, channelId, token, createdAt) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(webhookId) DO UPDATE SET token = excluded.token`,
+		w.ID, guildID, w.ChannelID, token, time.Now().Unix(),
+	)
+	return err
+}
+
+// Cleanup deletes every webhook the looper created: the ones it recorded and
+// any WebhookName webhooks made by the bot in the given guilds or in guilds it
+// has recorded webhooks in, which catches webhooks orphaned by a crash before
+// they were recorded. Loops using a deleted webhook are stopped and lose it.
+func (m *Manager) Cleanup(guildIDs ...string) (int, error) {
+	if m.Webhooks == nil {
+		return 0, errors.New("no Discord session available to clean up webhooks")
+	}
+	botID, err := m.botUserID()
+	if err != nil {
+		return 0, err
+	}
+
+	targets, guilds, err := recordedWebhooks()
+	if err != nil {
+		return 0, err
+	}
+	for _, g := range guildIDs {
+		if g != "" {
+			guilds[g] = true
+		}
+	}
+
+	var errs []error
+	for guildID := range guilds {
+		webhooks, err := m.Webhooks.GuildWebhooks(guildID)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("list webhooks of guild %s: %w", guildID, err))
+			continue
+		}
+		for _, w := range webhooks {
+			if isLooperWebhook(w, botID) {
+				targets[w.ID] = true
+			}
+		}
+	}
+
+	m.detachWebhooks(targets)
+
+	deleted := 0
+	for id := range targets {
+		if err := m.Webhooks.WebhookDelete(id); err != nil && !isNotFound(err) {
+			errs = append(errs, fmt.Errorf("delete webhook %s: %w", id, err))
+			continue
+		}
+		deleted++
+		if database.DB != nil {
+			if _, err := database.DB.Exec("DELETE FROM looper_webhooks WHERE webhookId = ?", id); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	logger.Info("Cleaned up looper webhooks", "deleted", deleted, "failed", len(errs))
+	return deleted, errors.Join(errs...)
+}
+
+// recordedWebhooks returns the IDs and guilds of every recorded looper webhook
+func recordedWebhooks() (map[string]bool, map[string]bool, error) {
+	ids, guilds := map[string]bool{}, map[string]bool{}
+	if database.DB == nil {
+		return ids, guilds, nil
+	}
+
+	rows, err := database.DB.Query("SELECT webhookId, guildId FROM looper_webhooks")
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id, guildID string
+		if err := rows.Scan(&id, &guildID); err != nil {
+			return nil, nil, err
+		}
+		ids[id] = true
+		if guildID != "" {
+			guilds[guildID] = true
+		}
+	}
+	return ids, guilds, rows.Err()
+}
+
+// detachWebhooks stops loops that use any of the webhooks and removes them from their hook sets
+func (m *Manager) detachWebhooks(ids map[string]bool) {
+	for _, l := range m.List() {
+		_, hooks, _ := m.Get(l.ChannelID)
+		kept := make([]WebhookData, 0, len(hooks))
+		for _, h := range hooks {
+			if !ids[h.HookID] {
+				kept = append(kept, h)
+			}
+		}
+		if len(kept) == len(hooks) {
+			continue
+		}
+		if l.Running {
+			if val, ok := m.loops.Load(l.ChannelID); ok {
+				m.stop(val.(*LoopInstance), nil, "webhooks cleaned up")
+			}
+		}
+		m.Update(l.ChannelID, LoopPatch{Hooks: kept})
+	}
+}
+
+// botUserID returns the ID of the bot user, which owns the webhooks it creates
+func (m *Manager) botUserID() (string, error) {
+	u, err := m.Webhooks.User("@me")
+	if err != nil {
+		return "", fmt.Errorf("look up bot user: %w", err)
+	}
+	return u.ID, nil
+}
+
+// isLooperWebhook reports whether a webhook was created by the looper
+func isLooperWebhook(w *discordgo.Webhook, botID string) bool {
+	return w.Name == WebhookName && w.User != nil && w.User.ID == botID
+}
+
+// supportsWebhooks reports whether loops can post through webhooks in a
+// channel type. Forum and media channels are left out: their webhooks can
+// only post into threads, so every plain request would fail.
+func supportsWebhooks(t discordgo.ChannelType) bool {
+	switch t {
+	case discordgo.ChannelTypeGuildText, discordgo.ChannelTypeGuildNews:
+		return true
+	}
+	return false
+}
+
+func isNotFound(err error) bool {
+	var restErr *discordgo.RESTError
+	return errors.As(err, &restErr) && restErr.Response != nil && restErr.Response.StatusCode == http.StatusNotFound
+}
@@ -0,0 +1,256 @@
+package looper_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/leeineian/minder/internal/daemons/looper"
+	"github.com/leeineian/minder/internal/database"
+	"github.com/leeineian/minder/internal/database/dbtest"
+)
+
+// fakeDiscord is an in-memory guild with channels and webhooks
+type fakeDiscord struct {
+	mu       sync.Mutex
+	channels []*discordgo.Channel
+	webhooks map[string]*discordgo.Webhook
+	created  int
+	deleted  []string
+}
+
+const botID = "bot"
+
+func newFakeDiscord() *fakeDiscord {
+	return &fakeDiscord{
+		channels: []*discordgo.Channel{
+			{ID: "cat", GuildID: "g1", Name: "load", Type: discordgo.ChannelTypeGuildCategory},
+			{ID: "t1", GuildID: "g1", Name: "one", ParentID: "cat", Type: discordgo.ChannelTypeGuildText, Position: 1},
+			{ID: "t2", GuildID: "g1", Name: "two", ParentID: "cat", Type: discordgo.ChannelTypeGuildText, Position: 2},
+			{ID: "v1", GuildID: "g1", Name: "voice", ParentID: "cat", Type: discordgo.ChannelTypeGuildVoice},
+			{ID: "f1", GuildID: "g1", Name: "forum", ParentID: "cat", Type: discordgo.ChannelTypeGuildForum},
+			{ID: "t3", GuildID: "g1", Name: "elsewhere", Type: discordgo.ChannelTypeGuildText},
+		},
+		webhooks: map[string]*discordgo.Webhook{
+			// Reused: created by the bot on an earlier run
+			"w-old": {ID: "w-old", ChannelID: "t1", GuildID: "g1", Name: looper.WebhookName, Token: "old-token", User: &discordgo.User{ID: botID}},
+			// Same name but someone else's: never reused or deleted
+			"w-foreign": {ID: "w-foreign", ChannelID: "t2", GuildID: "g1", Name: looper.WebhookName, Token: "foreign", User: &discordgo.User{ID: "human"}},
+		},
+	}
+}
+
+func (f *fakeDiscord) User(userID string, _ ...discordgo.RequestOption) (*discordgo.User, error) {
+	return &discordgo.User{ID: botID}, nil
+}
+
+func (f *fakeDiscord) Channel(channelID string, _ ...discordgo.RequestOption) (*discordgo.Channel, error) {
+	for _, c := range f.channels {
+		if c.ID == channelID {
+			return c, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown channel %s", channelID)
+}
+
+func (f *fakeDiscord) GuildChannels(guildID string, _ ...discordgo.RequestOption) ([]*discordgo.Channel, error) {
+	return append([]*discordgo.Channel(nil), f.channels...), nil
+}
+
+func (f *fakeDiscord) ChannelWebhooks(channelID string, _ ...discordgo.RequestOption) ([]*discordgo.Webhook, error) {
+	return f.filter(func(w *discordgo.Webhook) bool { return w.ChannelID == channelID }), nil
+}
+
+func (f *fakeDiscord) GuildWebhooks(guildID string, _ ...discordgo.RequestOption) ([]*discordgo.Webhook, error) {
+	return f.filter(func(w *discordgo.Webhook) bool { return w.GuildID == guildID }), nil
+}
+
+func (f *fakeDiscord) WebhookCreate(channelID, name, _ string, _ ...discordgo.RequestOption) (*discordgo.Webhook, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.created++
+	w := &discordgo.Webhook{
+		ID: fmt.Sprintf("w-new-%d", f.created), ChannelID: channelID, GuildID: "g1",
+		Name: name, Token: fmt.Sprintf("secret-token-%d", f.created), User: &discordgo.User{ID: botID},
+	}
+	f.webhooks[w.ID] = w
+	return w, nil
+}
+
+func (f *fakeDiscord) WebhookDelete(webhookID string, _ ...discordgo.RequestOption) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.webhooks[webhookID]; !ok {
+		return &discordgo.RESTError{Response: &http.Response{StatusCode: http.StatusNotFound}}
+	}
+	delete(f.webhooks, webhookID)
+	f.deleted = append(f.deleted, webhookID)
+	return nil
+}
+
+func (f *fakeDiscord) filter(keep func(*discordgo.Webhook) bool) []*discordgo.Webhook {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []*discordgo.Webhook
+	for _, w := range f.webhooks {
+		if keep(w) {
+			out = append(out, w)
+		}
+	}
+	return out
+}
+
+func TestProvisionCategory(t *testing.T) {
+	dbtest.Setup(t)
+	discord := newFakeDiscord()
+	m := &looper.Manager{Webhooks: discord, Secret: "s3cret"}
+
+	hooks, err := m.Provision("cat")
+	if err != nil {
+		t.Fatalf("Provision failed: %v", err)
+	}
+	if len(hooks) != 2 || hooks[0].HookID != "w-old" || hooks[0].ChannelID != "t1" || hooks[1].ChannelID != "t2" {
+		t.Fatalf("Unexpected hooks %+v", hooks)
+	}
+	if discord.created != 1 {
+		t.Errorf("Expected one new webhook, created %d", discord.created)
+	}
+
+	// Provisioning again reuses everything
+	if _, err := m.Provision("cat"); err != nil {
+		t.Fatalf("Second Provision failed: %v", err)
+	}
+	if discord.created != 1 {
+		t.Errorf("Expected webhooks to be reused, created %d", discord.created)
+	}
+
+	st, ok := m.Status("cat")
+	if !ok || st.Running || st.Hooks != 2 || st.ChannelName != "load" {
+		t.Errorf("Expected a stopped loop with 2 hooks, got %+v", st)
+	}
+
+	if _, err := m.Provision("t1"); err == nil {
+		t.Error("Expected provisioning a text channel to fail")
+	}
+}
+
+func TestWebhookTokensEncryptedAtRest(t *testing.T) {
+	dbtest.Setup(t)
+	m := &looper.Manager{Webhooks: newFakeDiscord(), Secret: "s3cret"}
+	if _, err := m.Provision("cat"); err != nil {
+		t.Fatalf("Provision failed: %v", err)
+	}
+
+	var hooksRaw, recorded string
+	database.DB.QueryRow("SELECT hooks FROM webhook_loops WHERE channelId = ?", "cat").Scan(&hooksRaw)
+	database.DB.QueryRow("SELECT group_concat(token) FROM looper_webhooks").Scan(&recorded)
+	for _, stored := range []string{hooksRaw, recorded} {
+		if strings.Contains(stored, "old-token") || strings.Contains(stored, "secret-token") {
+			t.Errorf("Plaintext token stored: %s", stored)
+		}
+		if !strings.Contains(stored, "enc:v1:") {
+			t.Errorf("Expected encrypted tokens, got %s", stored)
+		}
+	}
+
+	reloaded := &looper.Manager{Secret: "s3cret"}
+	if err := reloaded.LoadFromDB(); err != nil {
+		t.Fatalf("LoadFromDB failed: %v", err)
+	}
+	_, hooks, _ := reloaded.Get("cat")
+	if len(hooks) != 2 || hooks[0].HookToken != "old-token" || hooks[1].HookToken != "secret-token-1" {
+		t.Errorf("Tokens not decrypted on load: %+v", hooks)
+	}
+
+	// A different key cannot read the tokens; the loop is skipped but kept
+	wrongKey := &looper.Manager{Secret: "other"}
+	if err := wrongKey.LoadFromDB(); err != nil {
+		t.Fatalf("LoadFromDB failed: %v", err)
+	}
+	if _, _, ok := wrongKey.Get("cat"); ok {
+		t.Error("Expected the loop not to load with the wrong key")
+	}
+}
+
+func TestWrongSecretKeepsLoops(t *testing.T) {
+	dbtest.Setup(t)
+	m := &looper.Manager{Webhooks: newFakeDiscord(), Secret: "s3cret"}
+	if _, err := m.Provision("cat"); err != nil {
+		t.Fatalf("Provision failed: %v", err)
+	}
+
+	for _, secret := range []string{"typo", ""} {
+		wrong := &looper.Manager{Secret: secret}
+		if err := wrong.LoadFromDB(); err != nil {
+			t.Fatalf("LoadFromDB failed: %v", err)
+		}
+		if _, _, ok := wrong.Get("cat"); ok {
+			t.Errorf("Expected the loop not to load with secret %q", secret)
+		}
+	}
+
+	var stored, quarantined int
+	database.DB.QueryRow("SELECT COUNT(*) FROM webhook_loops WHERE channelId = ?", "cat").Scan(&stored)
+	database.DB.QueryRow("SELECT COUNT(*) FROM webhook_loops_quarantine").Scan(&quarantined)
+	if stored != 1 || quarantined != 0 {
+		t.Fatalf("Expected the loop to stay stored and unquarantined, got %d stored and %d quarantined", stored, quarantined)
+	}
+
+	// Fixing the secret brings the loop back
+	fixed := &looper.Manager{Secret: "s3cret"}
+	if err := fixed.LoadFromDB(); err != nil {
+		t.Fatalf("LoadFromDB failed: %v", err)
+	}
+	if _, hooks, ok := fixed.Get("cat"); !ok || len(hooks) != 2 || hooks[0].HookToken != "old-token" {
+		t.Errorf("Expected the loop to load with the right secret, got %+v", hooks)
+	}
+}
+
+func TestCleanupDeletesCreatedWebhooks(t *testing.T) {
+	dbtest.Setup(t)
+	discord := newFakeDiscord()
+	m := &looper.Manager{Webhooks: discord}
+	if _, err := m.Provision("cat"); err != nil {
+		t.Fatalf("Provision failed: %v", err)
+	}
+
+	// Orphan left by a crash between creating and recording it
+	discord.webhooks["w-orphan"] = &discordgo.Webhook{
+		ID: "w-orphan", ChannelID: "t3", GuildID: "g1", Name: looper.WebhookName, User: &discordgo.User{ID: botID},
+	}
+
+	deleted, err := m.Cleanup()
+	if err != nil {
+		t.Fatalf("Cleanup failed: %v", err)
+	}
+	if deleted != 3 {
+		t.Errorf("Expected 3 deleted webhooks, got %d (%v)", deleted, discord.deleted)
+	}
+	if _, ok := discord.webhooks["w-foreign"]; !ok {
+		t.Error("Cleanup deleted a webhook the bot did not create")
+	}
+	if len(discord.webhooks) != 1 {
+		t.Errorf("Expected only the foreign webhook to remain, got %v", discord.webhooks)
+	}
+
+	if st, _ := m.Status("cat"); st.Hooks != 0 {
+		t.Errorf("Expected the loop to lose its deleted hooks, got %d", st.Hooks)
+	}
+	if cfg, hooks, _ := m.Get("cat"); !errors.Is(m.StartLoop(cfg, hooks), looper.ErrNoHooks) {
+		t.Error("Expected a loop left without webhooks not to start")
+	}
+	var remaining int
+	database.DB.QueryRow("SELECT COUNT(*) FROM looper_webhooks").Scan(&remaining)
+	if remaining != 0 {
+		t.Errorf("Expected no recorded webhooks left, got %d", remaining)
+	}
+
+	// Nothing left to delete
+	if deleted, err := m.Cleanup("g1"); err != nil || deleted != 0 {
+		t.Errorf("Expected an empty second cleanup, got %d, %v", deleted, err)
+	}
+}
@@ -0,0 +1,226 @@
+package looper
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimitMode controls how a loop reacts to Discord rate limits
+type RateLimitMode string
+
+const (
+	// RateLimitRespect waits for exhausted buckets to reset and honors Retry-After
+	RateLimitRespect RateLimitMode = "respect"
+	// RateLimitMeasure sends regardless of limits and only records what Discord reports
+	RateLimitMeasure RateLimitMode = "measure"
+)
+
+// ParseRateLimitMode validates a user-supplied mode, defaulting to respect
+func ParseRateLimitMode(s string) (RateLimitMode, bool) {
+	switch RateLimitMode(s) {
+	case "", RateLimitRespect:
+		return RateLimitRespect, true
+	case RateLimitMeasure:
+		return RateLimitMeasure, true
+	}
+	return "", false
+}
+
+// probeWait is how often requests to a webhook whose bucket is still unknown
+// check whether the first response has arrived
+const probeWait = 25 * time.Millisecond
+
+// bucket mirrors the X-RateLimit-* state Discord reports for one webhook
+type bucket struct {
+	hash      string
+	limit     int
+	remaining int
+	reset     time.Time
+	window    time.Duration // longest Reset-After seen, approximating the bucket window
+	inflight  int           // reserved requests still waiting for a response
+	seen      bool          // a response has been recorded, so the limits are known
+}
+
+// rateLimiter tracks per-webhook buckets and the global limit shared by all loops
+type rateLimiter struct {
+	mu          sync.Mutex
+	globalUntil time.Time
+	routes      map[string]string  // webhook ID -> key of its bucket
+	buckets     map[string]*bucket // bucket key -> bucket
+}
+
+func newRateLimiter() *rateLimiter {
+	return &rateLimiter{routes: make(map[string]string), buckets: make(map[string]*bucket)}
+}
+
+// bucketKey identifies the bucket Discord reported as hash for a webhook.
+// Hashes leave out top-level resources, so the webhook ID is part of the key.
+func bucketKey(hash, hookID string) string {
+	return hash + ":" + hookID
+}
+
+// bucket returns the webhook's bucket and its key, creating an unknown
+// bucket keyed by the webhook ID until Discord reports its hash
+func (r *rateLimiter) bucket(hookID string) (string, *bucket) {
+	key, ok := r.routes[hookID]
+	if !ok {
+		key = hookID
+	}
+	b, ok := r.buckets[key]
+	if !ok {
+		b = &bucket{}
+		r.buckets[key] = b
+	}
+	return key, b
+}
+
+// reserve claims a request slot for the webhook, returning how long to wait
+// before trying again when no slot is available. Until the first response
+// reports the bucket's limits, only one request is let through at a time.
+func (r *rateLimiter) reserve(hookID string, now time.Time) time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if now.Before(r.globalUntil) {
+		return r.globalUntil.Sub(now)
+	}
+
+	_, b := r.bucket(hookID)
+	if !b.seen {
+		if b.inflight > 0 {
+			return probeWait
+		}
+		b.inflight++
+		return 0
+	}
+	if !now.Before(b.reset) {
+		if b.limit == 0 || b.window == 0 {
+			// Nothing reported to limit by
+			b.inflight++
+			return 0
+		}
+		// Window elapsed; start a new one so concurrent callers share its slots
+		b.remaining = b.limit
+		b.reset = now.Add(b.window)
+	}
+	if b.remaining > 0 {
+		b.remaining--
+		b.inflight++
+		return 0
+	}
+	return b.reset.Sub(now)
+}
+
+// release hands back a slot claimed by reserve once its request has been
+// answered or has failed
+func (r *rateLimiter) release(hookID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, b := r.bucket(hookID); b.inflight > 0 {
+		b.inflight--
+	}
+}
+
+// wait blocks until a request to the webhook may be sent and returns the time spent waiting
+func (r *rateLimiter) wait(ctx context.Context, hookID string) (time.Duration, error) {
+	var waited time.Duration
+	for {
+		delay := r.reserve(hookID, time.Now())
+		if delay <= 0 {
+			return waited, nil
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return waited, ctx.Err()
+		case <-timer.C:
+			waited += delay
+		}
+	}
+}
+
+// update records the rate limit headers of a webhook response and reports
+// whether a 429 applied to the global limit. reserved tells whether the
+// request holds a slot from reserve, which the caller releases afterwards.
+// Other requests still in flight are taken off the remaining count Discord
+// reports, since it has not seen them yet.
+func (r *rateLimiter) update(hookID string, reserved bool, status int, h http.Header, now time.Time) (global bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key, b := r.bucket(hookID)
+	if hash := h.Get("X-RateLimit-Bucket"); hash != "" && hash != b.hash {
+		delete(r.buckets, key)
+		key = bucketKey(hash, hookID)
+		r.buckets[key] = b
+		r.routes[hookID] = key
+		b.hash = hash
+	}
+
+	known := b.seen && now.Before(b.reset)
+	b.seen = true
+	if limit, err := strconv.Atoi(h.Get("X-RateLimit-Limit")); err == nil {
+		b.limit = limit
+	}
+	reset := b.reset
+	if after, ok := parseSeconds(h.Get("X-RateLimit-Reset-After")); ok {
+		reset = now.Add(after)
+		if after > b.window {
+			b.window = after
+		}
+	}
+	if remaining, err := strconv.Atoi(h.Get("X-RateLimit-Remaining")); err == nil {
+		others := b.inflight
+		if reserved {
+			others--
+		}
+		remaining = max(remaining-others, 0)
+		// Within the current window, slots reserved since are already counted
+		if known {
+			remaining = min(remaining, b.remaining)
+		}
+		b.remaining = remaining
+	}
+	b.reset = reset
+
+	if status != http.StatusTooManyRequests {
+		return false
+	}
+
+	retryAfter, ok := parseSeconds(h.Get("Retry-After"))
+	if !ok {
+		retryAfter = time.Second
+	}
+
+	global = h.Get("X-RateLimit-Global") == "true" || h.Get("X-RateLimit-Scope") == "global"
+	if global {
+		if until := now.Add(retryAfter); until.After(r.globalUntil) {
+			r.globalUntil = until
+		}
+		return true
+	}
+
+	b.remaining = 0
+	if until := now.Add(retryAfter); until.After(b.reset) {
+		b.reset = until
+	}
+	return false
+}
+
+// parseSeconds parses a header value holding (possibly fractional) seconds
+func parseSeconds(v string) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	secs, err := strconv.ParseFloat(v, 64)
+	if err != nil || secs < 0 {
+		return 0, false
+	}
+	return time.Duration(secs * float64(time.Second)), true
+}
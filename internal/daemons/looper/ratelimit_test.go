@@ -0,0 +1,173 @@
+package looper
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterBucket(t *testing.T) {
+	r := newRateLimiter()
+	now := time.Now()
+
+	if d := r.reserve("hook", now); d != 0 {
+		t.Fatalf("Expected unknown bucket to allow immediately, got %v", d)
+	}
+
+	h := http.Header{}
+	h.Set("X-RateLimit-Bucket", "abc")
+	h.Set("X-RateLimit-Limit", "2")
+	h.Set("X-RateLimit-Remaining", "1")
+	h.Set("X-RateLimit-Reset-After", "1.5")
+	if r.update("hook", true, http.StatusOK, h, now) {
+		t.Fatal("Did not expect a global limit")
+	}
+	r.release("hook")
+
+	if d := r.reserve("hook", now); d != 0 {
+		t.Fatalf("Expected remaining slot to be available, got %v", d)
+	}
+	if d := r.reserve("hook", now); d != 1500*time.Millisecond {
+		t.Fatalf("Expected to wait for bucket reset, got %v", d)
+	}
+	if d := r.reserve("other", now); d != 0 {
+		t.Fatalf("Expected other webhooks to be unaffected, got %v", d)
+	}
+	if d := r.reserve("hook", now.Add(2*time.Second)); d != 0 {
+		t.Fatalf("Expected bucket to refill after reset, got %v", d)
+	}
+}
+
+func TestRateLimiterRetryAfter(t *testing.T) {
+	r := newRateLimiter()
+	now := time.Now()
+
+	h := http.Header{}
+	h.Set("Retry-After", "3")
+	if r.update("hook", false, http.StatusTooManyRequests, h, now) {
+		t.Fatal("Did not expect a global limit")
+	}
+	if d := r.reserve("hook", now); d != 3*time.Second {
+		t.Fatalf("Expected to honor Retry-After, got %v", d)
+	}
+	if d := r.reserve("hook", now.Add(3*time.Second)); d != 0 {
+		t.Fatalf("Expected request to be allowed after Retry-After, got %v", d)
+	}
+}
+
+func TestRateLimiterGlobal(t *testing.T) {
+	r := newRateLimiter()
+	now := time.Now()
+
+	h := http.Header{}
+	h.Set("Retry-After", "0.5")
+	h.Set("X-RateLimit-Global", "true")
+	if !r.update("hook", false, http.StatusTooManyRequests, h, now) {
+		t.Fatal("Expected a global limit")
+	}
+	if d := r.reserve("other", now); d != 500*time.Millisecond {
+		t.Fatalf("Expected global limit to block every webhook, got %v", d)
+	}
+}
+
+// limits builds the headers of a response that leaves remaining of limit requests
+func limits(hash string, limit, remaining int, resetAfter string) http.Header {
+	h := http.Header{}
+	h.Set("X-RateLimit-Bucket", hash)
+	h.Set("X-RateLimit-Limit", strconv.Itoa(limit))
+	h.Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+	h.Set("X-RateLimit-Reset-After", resetAfter)
+	return h
+}
+
+func TestRateLimiterFirstRequest(t *testing.T) {
+	r := newRateLimiter()
+	now := time.Now()
+
+	if d := r.reserve("hook", now); d != 0 {
+		t.Fatalf("Expected the first request to go, got %v", d)
+	}
+	if d := r.reserve("hook", now); d != probeWait {
+		t.Fatalf("Expected requests to wait for the first response, got %v", d)
+	}
+
+	// A request that fails without a response lets the next one probe
+	r.release("hook")
+	if d := r.reserve("hook", now); d != 0 {
+		t.Fatalf("Expected another probe after a failed request, got %v", d)
+	}
+
+	// A response without limits lifts the serialization
+	r.update("hook", true, http.StatusOK, http.Header{}, now)
+	r.release("hook")
+	for range 3 {
+		if d := r.reserve("hook", now); d != 0 {
+			t.Fatalf("Expected an unlimited webhook to allow requests, got %v", d)
+		}
+	}
+}
+
+func TestRateLimiterInFlight(t *testing.T) {
+	r := newRateLimiter()
+	now := time.Now()
+
+	r.reserve("hook", now)
+	r.update("hook", true, http.StatusOK, limits("abc", 5, 4, "2"), now)
+	r.release("hook")
+
+	// Three requests go out; the first answer has not counted the other two
+	for range 3 {
+		if d := r.reserve("hook", now); d != 0 {
+			t.Fatalf("Expected a slot, got %v", d)
+		}
+	}
+	r.update("hook", true, http.StatusOK, limits("abc", 5, 3, "2"), now)
+	r.release("hook")
+
+	if d := r.reserve("hook", now); d != 0 {
+		t.Fatalf("Expected the last slot, got %v", d)
+	}
+	if d := r.reserve("hook", now); d != 2*time.Second {
+		t.Fatalf("Expected in-flight requests to keep their slots, got %v", d)
+	}
+}
+
+func TestRateLimiterBucketPerWebhook(t *testing.T) {
+	r := newRateLimiter()
+	now := time.Now()
+
+	for _, hook := range []string{"a", "b"} {
+		r.reserve(hook, now)
+		r.update(hook, true, http.StatusOK, limits("abc", 1, 0, "1"), now)
+		r.release(hook)
+	}
+	if d := r.reserve("a", now); d != time.Second {
+		t.Fatalf("Expected an exhausted bucket to wait, got %v", d)
+	}
+	if r.buckets[bucketKey("abc", "a")] == r.buckets[bucketKey("abc", "b")] {
+		t.Error("Expected webhooks reporting the same hash to keep their own buckets")
+	}
+	if len(r.buckets) != 2 {
+		t.Errorf("Expected the provisional buckets to be rekeyed, got %d buckets", len(r.buckets))
+	}
+}
+
+func TestParseRateLimitMode(t *testing.T) {
+	tests := []struct {
+		in   string
+		want RateLimitMode
+		ok   bool
+	}{
+		{"", RateLimitRespect, true},
+		{"respect", RateLimitRespect, true},
+		{"measure", RateLimitMeasure, true},
+		{"ignore", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := ParseRateLimitMode(tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("ParseRateLimitMode(%q) = %q, %v; want %q, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
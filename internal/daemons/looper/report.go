@@ -0,0 +1,171 @@
+package looper
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// LatencySummary describes the latency distribution of a run in milliseconds
+type LatencySummary struct {
+	Samples int64           `json:"samples"`
+	MinMs   float64         `json:"minMs"`
+	MeanMs  float64         `json:"meanMs"`
+	P50Ms   float64         `json:"p50Ms"`
+	P90Ms   float64         `json:"p90Ms"`
+	P95Ms   float64         `json:"p95Ms"`
+	P99Ms   float64         `json:"p99Ms"`
+	MaxMs   float64         `json:"maxMs"`
+	Buckets []LatencyBucket `json:"buckets"`
+}
+
+// Report is the exportable summary of a loop's current or most recent run
+type Report struct {
+	ChannelID        string           `json:"channelId"`
+	ChannelName      string           `json:"channelName"`
+	Running          bool             `json:"running"`
+	RateLimitMode    RateLimitMode    `json:"rateLimitMode"`
+	Hooks            int              `json:"hooks"`
+	StartedAt        time.Time        `json:"startedAt"`
+	StoppedAt        *time.Time       `json:"stoppedAt,omitempty"`
+	StopReason       string           `json:"stopReason,omitempty"`
+	DurationSeconds  float64          `json:"durationSeconds"`
+	Iterations       int64            `json:"iterations"`
+	Requests         int64            `json:"requests"`
+	Success          int64            `json:"success"`
+	RateLimited      int64            `json:"rateLimited"`
+	GlobalLimited    int64            `json:"globalLimited"`
+	Errors           int64            `json:"errors"`
+	Skipped          int64            `json:"skipped"`
+	ThroughputRPS    float64          `json:"throughputRps"`
+	SuccessRPS       float64          `json:"successRps"`
+	ThrottledSeconds float64          `json:"throttledSeconds"`
+	StatusCodes      map[int]int64    `json:"statusCodes"`
+	ErrorCategories  map[string]int64 `json:"errorCategories"`
+	Latency          LatencySummary   `json:"latency"`
+}
+
+// Report builds the run summary for a loop; ok is false if the loop is
+// unknown or has never been started
+func (m *Manager) Report(channelID string) (*Report, bool) {
+	val, ok := m.loops.Load(channelID)
+	if !ok {
+		return nil, false
+	}
+	instance := val.(*LoopInstance)
+
+	instance.mu.Lock()
+	if instance.stats == nil {
+		instance.mu.Unlock()
+		return nil, false
+	}
+	r := &Report{
+		ChannelID:     instance.Config.ChannelID,
+		ChannelName:   instance.Config.ChannelName,
+		Running:       instance.running,
+		RateLimitMode: instance.Config.RateLimitMode,
+		Hooks:         len(instance.Hooks),
+		StartedAt:     instance.startedAt,
+		StopReason:    instance.stopReason,
+	}
+	end := time.Now()
+	if !instance.running {
+		stopped := instance.stoppedAt
+		r.StoppedAt = &stopped
+		end = stopped
+	}
+	stats := instance.stats
+	instance.mu.Unlock()
+
+	if r.RateLimitMode == "" {
+		r.RateLimitMode = RateLimitRespect
+	}
+	stats.report(r)
+
+	elapsed := end.Sub(r.StartedAt).Seconds()
+	r.DurationSeconds = elapsed
+	if elapsed > 0 {
+		r.ThroughputRPS = float64(r.Requests) / elapsed
+		r.SuccessRPS = float64(r.Success) / elapsed
+	}
+	return r, true
+}
+
+// JSON encodes the report as indented JSON
+func (r *Report) JSON() ([]byte, error) {
+	return json.MarshalIndent(r, "", "  ")
+}
+
+// CSV encodes the report as section,name,value rows
+func (r *Report) CSV() ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	stoppedAt := ""
+	if r.StoppedAt != nil {
+		stoppedAt = r.StoppedAt.Format(time.RFC3339)
+	}
+
+	f := func(v float64) string { return strconv.FormatFloat(v, 'f', 3, 64) }
+	i := func(v int64) string { return strconv.FormatInt(v, 10) }
+
+	rows := [][]string{
+		{"section", "name", "value"},
+		{"summary", "channel_id", r.ChannelID},
+		{"summary", "channel_name", r.ChannelName},
+		{"summary", "rate_limit_mode", string(r.RateLimitMode)},
+		{"summary", "hooks", strconv.Itoa(r.Hooks)},
+		{"summary", "started_at", r.StartedAt.Format(time.RFC3339)},
+		{"summary", "stopped_at", stoppedAt},
+		{"summary", "stop_reason", r.StopReason},
+		{"summary", "duration_seconds", f(r.DurationSeconds)},
+		{"summary", "iterations", i(r.Iterations)},
+		{"summary", "requests", i(r.Requests)},
+		{"summary", "success", i(r.Success)},
+		{"summary", "rate_limited", i(r.RateLimited)},
+		{"summary", "global_limited", i(r.GlobalLimited)},
+		{"summary", "errors", i(r.Errors)},
+		{"summary", "skipped", i(r.Skipped)},
+		{"summary", "throughput_rps", f(r.ThroughputRPS)},
+		{"summary", "success_rps", f(r.SuccessRPS)},
+		{"summary", "throttled_seconds", f(r.ThrottledSeconds)},
+		{"latency", "samples", i(r.Latency.Samples)},
+		{"latency", "min_ms", f(r.Latency.MinMs)},
+		{"latency", "mean_ms", f(r.Latency.MeanMs)},
+		{"latency", "p50_ms", f(r.Latency.P50Ms)},
+		{"latency", "p90_ms", f(r.Latency.P90Ms)},
+		{"latency", "p95_ms", f(r.Latency.P95Ms)},
+		{"latency", "p99_ms", f(r.Latency.P99Ms)},
+		{"latency", "max_ms", f(r.Latency.MaxMs)},
+	}
+	codes := make([]int, 0, len(r.StatusCodes))
+	for code := range r.StatusCodes {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+	for _, code := range codes {
+		rows = append(rows, []string{"status", strconv.Itoa(code), i(r.StatusCodes[code])})
+	}
+
+	kinds := make([]string, 0, len(r.ErrorCategories))
+	for kind := range r.ErrorCategories {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	for _, kind := range kinds {
+		rows = append(rows, []string{"error", kind, i(r.ErrorCategories[kind])})
+	}
+
+	for _, b := range r.Latency.Buckets {
+		rows = append(rows, []string{"latency_bucket", fmt.Sprintf("le_%sms", f(b.UpperMs)), i(b.Count)})
+	}
+
+	if err := w.WriteAll(rows); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
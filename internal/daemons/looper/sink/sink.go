@@ -0,0 +1,247 @@
+// Package sink implements a local stand-in for Discord's webhook execute
+// endpoint so the looper can be exercised without touching the network.
+package sink
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Options configures how the sink responds
+type Options struct {
+	Latency time.Duration // fixed delay before every response
+	Jitter  time.Duration // extra random delay in [0, Jitter)
+
+	ErrorRate     float64 // fraction of requests answered with 500
+	RateLimitRate float64 // fraction of requests answered with a random 429
+
+	// BucketLimit enables a per-webhook bucket of BucketLimit requests per
+	// BucketWindow, reported through X-RateLimit-* headers like Discord does
+	BucketLimit  int
+	BucketWindow time.Duration
+
+	RetryAfter time.Duration // Retry-After sent with 429 responses (default 1s)
+	Seed       int64         // random seed; 0 uses the current time
+}
+
+// Stats summarizes what the sink has received
+type Stats struct {
+	Requests     int64
+	Success      int64
+	RateLimited  int64
+	Errors       int64
+	PeakInFlight int64
+	ByWebhook    map[string]int64
+	ByThread     map[string]int64
+}
+
+// Request is a single webhook execution as received by the sink
+type Request struct {
+	WebhookID   string
+	Token       string
+	ThreadID    string
+	ContentType string
+	Body        []byte
+}
+
+type bucket struct {
+	remaining int
+	reset     time.Time
+}
+
+// Sink is an http.Handler mimicking POST /webhooks/{id}/{token}
+type Sink struct {
+	opts Options
+	mux  *http.ServeMux
+
+	mu       sync.Mutex
+	rng      *rand.Rand
+	stats    Stats
+	buckets  map[string]*bucket
+	requests []Request
+	keep     int
+	inFlight int64
+}
+
+// New creates a sink with the given options
+func New(opts Options) *Sink {
+	seed := opts.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	if opts.RetryAfter <= 0 {
+		opts.RetryAfter = time.Second
+	}
+	if opts.BucketLimit > 0 && opts.BucketWindow <= 0 {
+		opts.BucketWindow = 2 * time.Second
+	}
+
+	s := &Sink{
+		opts:    opts,
+		mux:     http.NewServeMux(),
+		rng:     rand.New(rand.NewSource(seed)),
+		buckets: make(map[string]*bucket),
+		keep:    1000,
+		stats: Stats{
+			ByWebhook: make(map[string]int64),
+			ByThread:  make(map[string]int64),
+		},
+	}
+	s.mux.HandleFunc("POST /webhooks/{id}/{token}", s.handleExecute)
+	s.mux.HandleFunc("POST /api/webhooks/{id}/{token}", s.handleExecute)
+	return s
+}
+
+func (s *Sink) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// Stats returns a copy of the counters collected so far
+func (s *Sink) Stats() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st := s.stats
+	st.ByWebhook = make(map[string]int64, len(s.stats.ByWebhook))
+	for k, v := range s.stats.ByWebhook {
+		st.ByWebhook[k] = v
+	}
+	st.ByThread = make(map[string]int64, len(s.stats.ByThread))
+	for k, v := range s.stats.ByThread {
+		st.ByThread[k] = v
+	}
+	return st
+}
+
+// Requests returns the most recent requests received, oldest first
+func (s *Sink) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+func (s *Sink) handleExecute(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	req := Request{
+		WebhookID:   r.PathValue("id"),
+		Token:       r.PathValue("token"),
+		ThreadID:    r.URL.Query().Get("thread_id"),
+		ContentType: r.Header.Get("Content-Type"),
+		Body:        body,
+	}
+
+	delay, outcome, headers := s.decide(req)
+	defer func() {
+		s.mu.Lock()
+		s.inFlight--
+		s.mu.Unlock()
+	}()
+	if delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	for k, v := range headers {
+		w.Header().Set(k, v)
+	}
+
+	switch outcome {
+	case http.StatusTooManyRequests:
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusTooManyRequests)
+		json.NewEncoder(w).Encode(map[string]any{
+			"message":     "You are being rate limited.",
+			"retry_after": s.opts.RetryAfter.Seconds(),
+			"global":      false,
+		})
+	case http.StatusInternalServerError:
+		http.Error(w, `{"message": "500: Internal Server Error", "code": 0}`, http.StatusInternalServerError)
+	default:
+		if r.URL.Query().Get("wait") == "true" {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]any{
+				"id":         strconv.FormatInt(time.Now().UnixNano(), 10),
+				"channel_id": req.ThreadID,
+				"webhook_id": req.WebhookID,
+			})
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// decide records the request and picks its latency, status and rate limit headers
+func (s *Sink) decide(req Request) (time.Duration, int, map[string]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stats.Requests++
+	s.inFlight++
+	if s.inFlight > s.stats.PeakInFlight {
+		s.stats.PeakInFlight = s.inFlight
+	}
+	s.stats.ByWebhook[req.WebhookID]++
+	if req.ThreadID != "" {
+		s.stats.ByThread[req.ThreadID]++
+	}
+	s.requests = append(s.requests, req)
+	if len(s.requests) > s.keep {
+		s.requests = s.requests[len(s.requests)-s.keep:]
+	}
+
+	delay := s.opts.Latency
+	if s.opts.Jitter > 0 {
+		delay += time.Duration(s.rng.Int63n(int64(s.opts.Jitter)))
+	}
+
+	headers := map[string]string{}
+	retryAfter := fmt.Sprintf("%.3f", s.opts.RetryAfter.Seconds())
+
+	if s.opts.BucketLimit > 0 {
+		now := time.Now()
+		b, ok := s.buckets[req.WebhookID]
+		if !ok || !now.Before(b.reset) {
+			b = &bucket{remaining: s.opts.BucketLimit, reset: now.Add(s.opts.BucketWindow)}
+			s.buckets[req.WebhookID] = b
+		}
+
+		resetAfter := fmt.Sprintf("%.3f", b.reset.Sub(now).Seconds())
+		headers["X-RateLimit-Bucket"] = "sink-" + req.WebhookID
+		headers["X-RateLimit-Limit"] = strconv.Itoa(s.opts.BucketLimit)
+		headers["X-RateLimit-Reset-After"] = resetAfter
+
+		if b.remaining == 0 {
+			headers["X-RateLimit-Remaining"] = "0"
+			headers["X-RateLimit-Scope"] = "user"
+			headers["Retry-After"] = resetAfter
+			s.stats.RateLimited++
+			return delay, http.StatusTooManyRequests, headers
+		}
+		b.remaining--
+		headers["X-RateLimit-Remaining"] = strconv.Itoa(b.remaining)
+	}
+
+	if s.opts.RateLimitRate > 0 && s.rng.Float64() < s.opts.RateLimitRate {
+		headers["Retry-After"] = retryAfter
+		headers["X-RateLimit-Scope"] = "shared"
+		s.stats.RateLimited++
+		return delay, http.StatusTooManyRequests, headers
+	}
+
+	if s.opts.ErrorRate > 0 && s.rng.Float64() < s.opts.ErrorRate {
+		s.stats.Errors++
+		return delay, http.StatusInternalServerError, headers
+	}
+
+	s.stats.Success++
+	return delay, http.StatusNoContent, headers
+}
@@ -0,0 +1,177 @@
+package looper
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/leeineian/minder/internal/database"
+	"github.com/leeineian/minder/internal/logger"
+)
+
+// LoadFromDB hydrates every stored loop and resumes the ones that were running
+// at shutdown, keeping paused loops paused. Rows that cannot be decoded are
+// moved to webhook_loops_quarantine; rows whose webhook tokens cannot be
+// decrypted with the configured secret are left in place and skipped.
+func (m *Manager) LoadFromDB() error {
+	rows, err := database.DB.Query("SELECT channelId, config, threads, hooks, running, paused FROM webhook_loops")
+	if err != nil {
+		return err
+	}
+
+	type storedLoop struct {
+		id                           string
+		configRaw, threadsRaw, hooks sql.NullString
+		running, paused              sql.NullBool
+	}
+
+	var stored []storedLoop
+	for rows.Next() {
+		var row storedLoop
+		if err := rows.Scan(&row.id, &row.configRaw, &row.threadsRaw, &row.hooks, &row.running, &row.paused); err != nil {
+			logger.Warn("Failed to scan loop", "error", err)
+			continue
+		}
+		stored = append(stored, row)
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return err
+	}
+
+	loaded, resumed, locked := 0, 0, 0
+	for _, row := range stored {
+		instance, err := decodeLoop(row.id, row.configRaw.String, row.threadsRaw.String, row.hooks.String)
+		if err != nil {
+			logger.Warn("Quarantining corrupt loop", "channelID", row.id, "error", err)
+			if qerr := quarantine(row.id, row.configRaw.String, row.threadsRaw.String, row.hooks.String, err); qerr != nil {
+				logger.Error("Failed to quarantine loop", "channelID", row.id, "error", qerr)
+			}
+			continue
+		}
+
+		// A missing or wrong secret is a configuration mistake, not corruption:
+		// keep the row so the loop loads once the right secret is set
+		if err := m.openHooks(instance.Hooks); err != nil {
+			logger.Error("Skipping loop whose webhook tokens cannot be decrypted", "channelID", row.id, "error", err)
+			locked++
+			continue
+		}
+
+		if _, exists := m.loops.LoadOrStore(row.id, instance); exists {
+			continue
+		}
+		loaded++
+
+		if row.running.Bool && len(instance.Hooks) == 0 {
+			logger.Warn("Not resuming loop without webhooks", "channelID", row.id)
+		} else if row.running.Bool {
+			instance.mu.Lock()
+			ctx, run := instance.start()
+			if row.paused.Bool {
+				instance.paused = true
+				instance.pausedAt = time.Now()
+			}
+			instance.mu.Unlock()
+			m.runs.Add(1)
+			go m.runLoop(ctx, run)
+			resumed++
+		}
+	}
+
+	if locked > 0 {
+		logger.Error("Some loops were not loaded because their webhook tokens cannot be decrypted; check LOOPER_SECRET", "skipped", locked)
+	}
+	logger.Info("Loaded loop configurations from DB", "loaded", loaded, "resumed", resumed)
+	return nil
+}
+
+// decodeLoop builds a stopped loop instance from the raw database columns
+func decodeLoop(id, configRaw, threadsRaw, hooksRaw string) (*LoopInstance, error) {
+	instance := &LoopInstance{Threads: ThreadMap{}}
+
+	if err := json.Unmarshal([]byte(configRaw), &instance.Config); err != nil {
+		return nil, fmt.Errorf("decode config: %w", err)
+	}
+	if instance.Config.ChannelID == "" {
+		instance.Config.ChannelID = id
+	}
+	if instance.Config.ChannelID != id {
+		return nil, fmt.Errorf("config channel %q does not match row %q", instance.Config.ChannelID, id)
+	}
+
+	if threadsRaw != "" {
+		if err := json.Unmarshal([]byte(threadsRaw), &instance.Threads); err != nil {
+			return nil, fmt.Errorf("decode threads: %w", err)
+		}
+		if instance.Threads == nil {
+			instance.Threads = ThreadMap{}
+		}
+	}
+
+	if hooksRaw != "" {
+		if err := json.Unmarshal([]byte(hooksRaw), &instance.Hooks); err != nil {
+			return nil, fmt.Errorf("decode hooks: %w", err)
+		}
+	}
+
+	return instance, nil
+}
+
+// quarantine moves a corrupt row out of webhook_loops so it is not retried on every start
+func quarantine(id, configRaw, threadsRaw, hooksRaw string, reason error) error {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(
+		"INSERT INTO webhook_loops_quarantine (channelId, config, threads, hooks, reason, quarantinedAt) VALUES (?, ?, ?, ?, ?, ?)",
+		id, configRaw, threadsRaw, hooksRaw, reason.Error(), time.Now().Unix(),
+	); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM webhook_loops WHERE channelId = ?", id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// persist writes the loop's configuration, hooks, threads and running state
+func (m *Manager) persist(instance *LoopInstance) {
+	if database.DB == nil {
+		return
+	}
+
+	instance.mu.Lock()
+	id := instance.Config.ChannelID
+	configRaw, _ := json.Marshal(instance.Config)
+	threadsRaw, _ := json.Marshal(instance.Threads)
+	hooks := append([]WebhookData(nil), instance.Hooks...)
+	running, paused := instance.running, instance.paused
+	instance.mu.Unlock()
+
+	sealed, err := m.sealHooks(hooks)
+	if err != nil {
+		logger.Error("Failed to encrypt webhook tokens", "channelID", id, "error", err)
+		return
+	}
+	hooksRaw, _ := json.Marshal(sealed)
+
+	_, err = database.DB.Exec(`
+		INSERT INTO webhook_loops (channelId, config, threads, hooks, running, paused) VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(channelId) DO UPDATE SET
+			config = excluded.config,
+			threads = excluded.threads,
+			hooks = excluded.hooks,
+			running = excluded.running,
+			paused = excluded.paused`,
+		id, string(configRaw), string(threadsRaw), string(hooksRaw), running, paused,
+	)
+	if err != nil {
+		logger.Warn("Failed to persist loop", "channelID", id, "error", err)
+	}
+}
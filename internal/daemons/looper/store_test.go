@@ -0,0 +1,122 @@
+package looper_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/leeineian/minder/internal/daemons/looper"
+	"github.com/leeineian/minder/internal/daemons/looper/sink"
+	"github.com/leeineian/minder/internal/database"
+	"github.com/leeineian/minder/internal/database/dbtest"
+	"github.com/leeineian/minder/internal/logger"
+)
+
+func TestMain(m *testing.M) {
+	logger.Init("error")
+	os.Exit(m.Run())
+}
+
+func TestLoadFromDB(t *testing.T) {
+	dbtest.Setup(t)
+
+	rows := []struct {
+		id, config, threads, hooks string
+		running                    bool
+	}{
+		{"100", `{"channelName":"stopped","interval":500}`, `{"100":"900"}`, `[{"id":"1","token":"a"}]`, false},
+		{"200", `{"channelId":"200","channelName":"resumed","interval":60000}`, ``, `[{"id":"2","token":"b"}]`, true},
+		{"250", `{"channelName":"unhooked","interval":60000}`, ``, `[]`, true},
+		{"300", `{not json`, ``, ``, true},
+		{"400", `{"channelId":"999"}`, ``, ``, false},
+	}
+	for _, r := range rows {
+		_, err := database.DB.Exec(
+			"INSERT INTO webhook_loops (channelId, config, threads, hooks, running) VALUES (?, ?, ?, ?, ?)",
+			r.id, r.config, r.threads, r.hooks, r.running,
+		)
+		if err != nil {
+			t.Fatalf("Failed to insert loop %s: %v", r.id, err)
+		}
+	}
+
+	m, _ := newSinkManager(t, sink.Options{Seed: 1})
+	if err := m.LoadFromDB(); err != nil {
+		t.Fatalf("LoadFromDB failed: %v", err)
+	}
+
+	list := m.List()
+	if len(list) != 3 {
+		t.Fatalf("Expected 3 loops, got %d", len(list))
+	}
+	if list[0].ChannelName != "resumed" || !list[0].Running {
+		t.Errorf("Expected loop 200 to be resumed, got %+v", list[0])
+	}
+	if list[1].ChannelName != "stopped" || list[1].Running || list[1].Hooks != 1 {
+		t.Errorf("Expected loop 100 to be stopped with 1 hook, got %+v", list[1])
+	}
+	if list[2].ChannelName != "unhooked" || list[2].Running {
+		t.Errorf("Expected loop 250 without webhooks to stay stopped, got %+v", list[2])
+	}
+
+	cfg, hooks, ok := m.Get("100")
+	if !ok || cfg.ChannelID != "100" || cfg.Interval != 500 || len(hooks) != 1 || hooks[0].HookToken != "a" {
+		t.Errorf("Unexpected hydrated loop: %+v %+v", cfg, hooks)
+	}
+
+	var quarantined int
+	if err := database.DB.QueryRow("SELECT COUNT(*) FROM webhook_loops_quarantine").Scan(&quarantined); err != nil {
+		t.Fatalf("Failed to count quarantined rows: %v", err)
+	}
+	if quarantined != 2 {
+		t.Errorf("Expected 2 quarantined rows, got %d", quarantined)
+	}
+
+	var remaining int
+	if err := database.DB.QueryRow("SELECT COUNT(*) FROM webhook_loops").Scan(&remaining); err != nil {
+		t.Fatalf("Failed to count loops: %v", err)
+	}
+	if remaining != 3 {
+		t.Errorf("Expected corrupt rows to be removed, %d rows remain", remaining)
+	}
+}
+
+func TestStopLoopPersistsState(t *testing.T) {
+	dbtest.Setup(t)
+
+	m, _ := newSinkManager(t, sink.Options{Seed: 1})
+	m.StartLoop(looper.LoopConfig{ChannelID: "500", Interval: 60000}, []looper.WebhookData{{HookID: "1", HookToken: "a"}})
+
+	var running bool
+	database.DB.QueryRow("SELECT running FROM webhook_loops WHERE channelId = ?", "500").Scan(&running)
+	if !running {
+		t.Error("Expected loop to be persisted as running")
+	}
+
+	m.StopLoop("500")
+	database.DB.QueryRow("SELECT running FROM webhook_loops WHERE channelId = ?", "500").Scan(&running)
+	if running {
+		t.Error("Expected loop to be persisted as stopped")
+	}
+}
+
+func TestShutdownResumesOnLoad(t *testing.T) {
+	dbtest.Setup(t)
+
+	m, _ := newSinkManager(t, sink.Options{Seed: 1})
+	m.StartLoop(looper.LoopConfig{ChannelID: "600", Interval: 60000}, []looper.WebhookData{{HookID: "1", HookToken: "a"}})
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := m.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+
+	restarted, _ := newSinkManager(t, sink.Options{Seed: 1})
+	if err := restarted.LoadFromDB(); err != nil {
+		t.Fatalf("LoadFromDB failed: %v", err)
+	}
+	if st, ok := restarted.Status("600"); !ok || !st.Running {
+		t.Errorf("Expected the loop to resume after shutdown, got %+v", st)
+	}
+}
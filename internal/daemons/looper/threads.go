@@ -0,0 +1,139 @@
+package looper
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/leeineian/minder/internal/logger"
+)
+
+// DefaultThreadName is used for auto-created threads when a loop sets none
+const DefaultThreadName = "minder-looper"
+
+// threadArchiveMinutes is the auto-archive duration of auto-created threads
+const threadArchiveMinutes = 1440
+
+// threadRetryDelay throttles thread creation attempts after a failure
+const threadRetryDelay = 10 * time.Second
+
+// errThread marks failures to resolve a loop's target thread
+var errThread = errors.New("thread unavailable")
+
+// ThreadCreator looks up channels and creates the threads that thread-targeted
+// loops post into. *discordgo.Session satisfies it.
+type ThreadCreator interface {
+	Channel(channelID string, options ...discordgo.RequestOption) (*discordgo.Channel, error)
+	ThreadStart(channelID, name string, typ discordgo.ChannelType, archiveDuration int, options ...discordgo.RequestOption) (*discordgo.Channel, error)
+	ForumThreadStart(channelID, name string, archiveDuration int, content string, options ...discordgo.RequestOption) (*discordgo.Channel, error)
+}
+
+// threadTargets resolves and caches the thread each hook's channel posts into
+type threadTargets struct {
+	mu      sync.Mutex
+	threads ThreadMap
+	retryAt map[string]time.Time // channel ID -> earliest next creation attempt
+}
+
+// hookChannel returns the channel a webhook belongs to, defaulting to the loop's channel
+func (r *loopRun) hookChannel(h WebhookData) string {
+	if h.ChannelID != "" {
+		return h.ChannelID
+	}
+	return r.cfg.ChannelID
+}
+
+// threadFor returns the thread a hook should post into, creating and
+// persisting one if the hook's channel has no live thread yet
+func (m *Manager) threadFor(run *loopRun, h WebhookData) (string, error) {
+	channelID := run.hookChannel(h)
+
+	run.threads.mu.Lock()
+	defer run.threads.mu.Unlock()
+
+	if id, ok := run.threads.threads[channelID]; ok && id != "" {
+		return id, nil
+	}
+
+	if m.Threads == nil {
+		return "", fmt.Errorf("%w: no Discord session available to create threads", errThread)
+	}
+	if time.Now().Before(run.threads.retryAt[channelID]) {
+		return "", fmt.Errorf("%w: waiting to retry thread creation in %s", errThread, channelID)
+	}
+
+	thread, err := m.createThread(channelID, run.cfg.ThreadName)
+	if err != nil {
+		if run.threads.retryAt == nil {
+			run.threads.retryAt = make(map[string]time.Time)
+		}
+		run.threads.retryAt[channelID] = time.Now().Add(threadRetryDelay)
+		return "", err
+	}
+
+	run.threads.threads[channelID] = thread.ID
+	m.saveThreads(run.instance, run.threads.snapshot())
+	logger.Info("Created looper thread", "channelID", channelID, "threadID", thread.ID, "loop", run.cfg.ChannelName)
+	return thread.ID, nil
+}
+
+// createThread starts a thread in a text channel or a post in a forum channel
+func (m *Manager) createThread(channelID, name string) (*discordgo.Channel, error) {
+	if name == "" {
+		name = DefaultThreadName
+	}
+
+	var thread *discordgo.Channel
+	parent, err := m.Threads.Channel(channelID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: look up channel %s: %v", errThread, channelID, err)
+	}
+	switch parent.Type {
+	case discordgo.ChannelTypeGuildForum, discordgo.ChannelTypeGuildMedia:
+		thread, err = m.Threads.ForumThreadStart(channelID, name, threadArchiveMinutes, "Webhook looper target thread")
+	default:
+		thread, err = m.Threads.ThreadStart(channelID, name, discordgo.ChannelTypeGuildPublicThread, threadArchiveMinutes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: create thread in %s: %v", errThread, channelID, err)
+	}
+	return thread, nil
+}
+
+// dropThread forgets a thread Discord no longer accepts posts in, so the next
+// request creates a replacement
+func (m *Manager) dropThread(run *loopRun, h WebhookData, threadID string) {
+	channelID := run.hookChannel(h)
+
+	run.threads.mu.Lock()
+	if run.threads.threads[channelID] != threadID {
+		run.threads.mu.Unlock()
+		return
+	}
+	delete(run.threads.threads, channelID)
+	threads := run.threads.snapshot()
+	run.threads.mu.Unlock()
+
+	m.saveThreads(run.instance, threads)
+	logger.Warn("Dropped unusable looper thread", "channelID", channelID, "threadID", threadID)
+}
+
+// snapshot copies the thread mapping; callers hold mu
+func (t *threadTargets) snapshot() ThreadMap {
+	threads := make(ThreadMap, len(t.threads))
+	for k, v := range t.threads {
+		threads[k] = v
+	}
+	return threads
+}
+
+// saveThreads stores a thread mapping on the loop instance and persists it
+func (m *Manager) saveThreads(instance *LoopInstance, threads ThreadMap) {
+	instance.mu.Lock()
+	instance.Threads = threads
+	instance.mu.Unlock()
+
+	m.persist(instance)
+}
@@ -0,0 +1,113 @@
+package looper_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/leeineian/minder/internal/daemons/looper"
+	"github.com/leeineian/minder/internal/daemons/looper/sink"
+	"github.com/leeineian/minder/internal/database/dbtest"
+)
+
+// fakeThreads creates numbered threads and records which API was used
+type fakeThreads struct {
+	mu      sync.Mutex
+	forums  map[string]bool
+	created []string // "thread:<channel>" or "forum:<channel>"
+}
+
+func (f *fakeThreads) Channel(channelID string, _ ...discordgo.RequestOption) (*discordgo.Channel, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	typ := discordgo.ChannelTypeGuildText
+	if f.forums[channelID] {
+		typ = discordgo.ChannelTypeGuildForum
+	}
+	return &discordgo.Channel{ID: channelID, Type: typ}, nil
+}
+
+func (f *fakeThreads) ThreadStart(channelID, name string, _ discordgo.ChannelType, _ int, _ ...discordgo.RequestOption) (*discordgo.Channel, error) {
+	return f.create("thread", channelID)
+}
+
+func (f *fakeThreads) ForumThreadStart(channelID, name string, _ int, _ string, _ ...discordgo.RequestOption) (*discordgo.Channel, error) {
+	return f.create("forum", channelID)
+}
+
+func (f *fakeThreads) create(kind, channelID string) (*discordgo.Channel, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.created = append(f.created, kind+":"+channelID)
+	return &discordgo.Channel{ID: fmt.Sprintf("t-%s-%d", channelID, len(f.created))}, nil
+}
+
+func TestThreadTargetedLoop(t *testing.T) {
+	dbtest.Setup(t)
+	m, s := newSinkManager(t, sink.Options{Seed: 1})
+	threads := &fakeThreads{forums: map[string]bool{"forum": true}}
+	m.Threads = threads
+
+	hooks := []looper.WebhookData{
+		{HookID: "1", HookToken: "a", ChannelID: "text"},
+		{HookID: "2", HookToken: "b", ChannelID: "forum"},
+		{HookID: "3", HookToken: "c", ChannelID: "mapped"},
+	}
+	m.StartLoop(looper.LoopConfig{ChannelID: "category", Interval: 10, UseThreads: true}, hooks)
+	waitFor(t, 2*time.Second, func() bool { return s.Stats().Requests >= 9 })
+	m.StopLoop("category")
+
+	st := s.Stats()
+	if len(st.ByThread) != 3 {
+		t.Fatalf("Expected posts into 3 threads, got %v", st.ByThread)
+	}
+
+	threads.mu.Lock()
+	created := append([]string(nil), threads.created...)
+	threads.mu.Unlock()
+	if len(created) != 3 {
+		t.Fatalf("Expected one thread per channel, got %v", created)
+	}
+	forumPosts := 0
+	for _, c := range created {
+		if c == "forum:forum" {
+			forumPosts++
+		}
+	}
+	if forumPosts != 1 {
+		t.Errorf("Expected the forum channel to get a forum post, got %v", created)
+	}
+
+	// The mapping must survive a restart
+	reloaded := &looper.Manager{}
+	if err := reloaded.LoadFromDB(); err != nil {
+		t.Fatalf("LoadFromDB failed: %v", err)
+	}
+	if got := reloaded.List()[0].Threads; got != 3 {
+		t.Errorf("Expected 3 persisted threads, got %d", got)
+	}
+}
+
+func TestThreadLoopReusesMapping(t *testing.T) {
+	m, s := newSinkManager(t, sink.Options{Seed: 1})
+	threads := &fakeThreads{}
+	m.Threads = threads
+
+	hooks := []looper.WebhookData{{HookID: "1", HookToken: "a"}}
+	m.StartLoop(looper.LoopConfig{ChannelID: "c1", Interval: 10, UseThreads: true}, hooks)
+	waitFor(t, 2*time.Second, func() bool { return s.Stats().Requests >= 3 })
+	m.StopLoop("c1")
+
+	cfg, hooks, _ := m.Get("c1")
+	m.StartLoop(cfg, hooks)
+	waitFor(t, 2*time.Second, func() bool { return s.Stats().Requests >= 6 })
+	m.StopLoop("c1")
+
+	threads.mu.Lock()
+	defer threads.mu.Unlock()
+	if len(threads.created) != 1 {
+		t.Errorf("Expected the thread to be reused across runs, created %v", threads.created)
+	}
+}
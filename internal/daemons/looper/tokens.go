@@ -0,0 +1,100 @@
+package looper
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// sealedPrefix marks webhook tokens encrypted with the manager's Secret
+const sealedPrefix = "enc:v1:"
+
+// tokenCipher returns the AES-GCM cipher derived from Secret, or nil when no
+// secret is configured and tokens are stored as-is
+func (m *Manager) tokenCipher() (cipher.AEAD, error) {
+	m.cipherOnce.Do(func() {
+		if m.Secret == "" {
+			return
+		}
+		key := sha256.Sum256([]byte(m.Secret))
+		block, err := aes.NewCipher(key[:])
+		if err != nil {
+			m.cipherErr = err
+			return
+		}
+		m.aead, m.cipherErr = cipher.NewGCM(block)
+	})
+	return m.aead, m.cipherErr
+}
+
+// sealToken encrypts a webhook token for storage
+func (m *Manager) sealToken(token string) (string, error) {
+	aead, err := m.tokenCipher()
+	if err != nil || aead == nil || token == "" || strings.HasPrefix(token, sealedPrefix) {
+		return token, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(token), nil)
+	return sealedPrefix + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// openToken decrypts a stored webhook token. Tokens stored before encryption
+// was enabled are returned unchanged and sealed on the next save.
+func (m *Manager) openToken(stored string) (string, error) {
+	if !strings.HasPrefix(stored, sealedPrefix) {
+		return stored, nil
+	}
+	aead, err := m.tokenCipher()
+	if err != nil {
+		return "", err
+	}
+	if aead == nil {
+		return "", errors.New("webhook token is encrypted but no secret is configured")
+	}
+
+	sealed, err := base64.RawStdEncoding.DecodeString(strings.TrimPrefix(stored, sealedPrefix))
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", errors.New("malformed encrypted webhook token")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	token, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", fmt.Errorf("decrypt webhook token: %w", err)
+	}
+	return string(token), nil
+}
+
+// sealHooks returns a copy of hooks with their tokens encrypted
+func (m *Manager) sealHooks(hooks []WebhookData) ([]WebhookData, error) {
+	sealed := make([]WebhookData, len(hooks))
+	for idx, h := range hooks {
+		token, err := m.sealToken(h.HookToken)
+		if err != nil {
+			return nil, err
+		}
+		h.HookToken = token
+		sealed[idx] = h
+	}
+	return sealed, nil
+}
+
+// openHooks decrypts the tokens of hooks in place
+func (m *Manager) openHooks(hooks []WebhookData) error {
+	for idx := range hooks {
+		token, err := m.openToken(hooks[idx].HookToken)
+		if err != nil {
+			return fmt.Errorf("hook %s: %w", hooks[idx].HookID, err)
+		}
+		hooks[idx].HookToken = token
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package looper
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSealToken(t *testing.T) {
+	m := &Manager{Secret: "key"}
+
+	sealed, err := m.sealToken("token")
+	if err != nil || !strings.HasPrefix(sealed, sealedPrefix) || strings.Contains(sealed, "token") {
+		t.Fatalf("sealToken() = %q, %v", sealed, err)
+	}
+	if again, _ := m.sealToken("token"); again == sealed {
+		t.Error("Expected a fresh nonce per seal")
+	}
+	if resealed, _ := m.sealToken(sealed); resealed != sealed {
+		t.Error("Sealed tokens must not be sealed twice")
+	}
+
+	tests := []struct {
+		name    string
+		m       *Manager
+		stored  string
+		want    string
+		wantErr bool
+	}{
+		{"round trip", m, sealed, "token", false},
+		{"legacy plaintext", m, "plain", "plain", false},
+		{"no secret keeps plaintext", &Manager{}, "plain", "plain", false},
+		{"wrong key", &Manager{Secret: "other"}, sealed, "", true},
+		{"no secret for sealed", &Manager{}, sealed, "", true},
+		{"malformed", m, sealedPrefix + "!!", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.m.openToken(tt.stored)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("openToken() = %q, %v; want %q, error %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,263 @@
+// Package daemons supervises the bot's background services
+package daemons
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/leeineian/minder/internal/logger"
+)
+
+// Daemon is a background service run by a Manager
+type Daemon interface {
+	// Name identifies the daemon in logs and /debug daemons
+	Name() string
+	// Start runs the daemon until ctx is cancelled. Returning an error or
+	// panicking restarts it after a backoff; returning nil early means it
+	// has nothing to do, such as when it is not configured.
+	Start(ctx context.Context) error
+	// Stop releases what the daemon holds after Start has returned
+	Stop() error
+	// Health reports why a running daemon is not doing its work, or nil
+	Health() error
+}
+
+// State is where a daemon is in its lifecycle
+type State string
+
+const (
+	StateRunning State = "running"
+	StateBackoff State = "backoff" // Failed and waiting to restart
+	StateStopped State = "stopped"
+)
+
+// Status describes a supervised daemon
+type Status struct {
+	Name      string
+	State     State
+	Since     time.Time // When the daemon entered State
+	Restarts  int
+	LastError error // Why the daemon last failed
+	Health    error
+}
+
+// supervised is a daemon and its supervisor's bookkeeping
+type supervised struct {
+	daemon Daemon
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	state    State
+	since    time.Time
+	restarts int
+	lastErr  error
+}
+
+// Manager starts daemons, restarts them when they fail and stops them on shutdown
+type Manager struct {
+	// MinBackoff and MaxBackoff bound the delay before restarting a failed
+	// daemon; it doubles with every failure in a row
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// StableAfter is how long a daemon must run before its backoff resets
+	StableAfter time.Duration
+
+	mu      sync.Mutex
+	daemons []*supervised
+	ctx     context.Context
+}
+
+// GlobalManager supervises the bot's daemons
+var GlobalManager = NewManager()
+
+// NewManager creates a manager with the default backoff
+func NewManager() *Manager {
+	return &Manager{
+		MinBackoff:  time.Second,
+		MaxBackoff:  5 * time.Minute,
+		StableAfter: time.Minute,
+	}
+}
+
+// Add registers a daemon. It starts right away if the manager is running.
+func (m *Manager) Add(d Daemon) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sd := &supervised{daemon: d, state: StateStopped, since: time.Now()}
+	m.daemons = append(m.daemons, sd)
+	if m.ctx != nil {
+		m.launch(sd)
+	}
+}
+
+// Start runs every registered daemon until ctx is cancelled or Stop is called
+func (m *Manager) Start(ctx context.Context) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.ctx = ctx
+	for _, sd := range m.daemons {
+		m.launch(sd)
+	}
+}
+
+// launch starts supervising sd; m.mu must be held
+func (m *Manager) launch(sd *supervised) {
+	ctx, cancel := context.WithCancel(m.ctx)
+	sd.cancel = cancel
+	sd.done = make(chan struct{})
+	go m.supervise(ctx, sd)
+}
+
+// Stop stops the daemons in the reverse of the order they were added,
+// waiting for each to return from Start until ctx expires
+func (m *Manager) Stop(ctx context.Context) error {
+	type stopping struct {
+		daemon Daemon
+		cancel context.CancelFunc
+		done   chan struct{}
+	}
+	m.mu.Lock()
+	var running []stopping
+	for _, sd := range m.daemons {
+		if sd.cancel != nil {
+			running = append(running, stopping{sd.daemon, sd.cancel, sd.done})
+			sd.cancel = nil
+		}
+	}
+	m.ctx = nil
+	m.mu.Unlock()
+
+	var errs []error
+	for idx := len(running) - 1; idx >= 0; idx-- {
+		sd := running[idx]
+		name := sd.daemon.Name()
+		sd.cancel()
+
+		select {
+		case <-sd.done:
+		case <-ctx.Done():
+			logger.Warn("Daemon did not stop in time", "daemon", name)
+			errs = append(errs, fmt.Errorf("%s: %w", name, ctx.Err()))
+			continue
+		}
+		if err := sd.daemon.Stop(); err != nil {
+			logger.Warn("Failed to stop daemon", "daemon", name, "error", err)
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+		logger.Info("Daemon stopped", "daemon", name)
+	}
+	return errors.Join(errs...)
+}
+
+// Status reports on every registered daemon in the order they were added
+func (m *Manager) Status() []Status {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	out := make([]Status, len(m.daemons))
+	for idx, sd := range m.daemons {
+		out[idx] = Status{
+			Name:      sd.daemon.Name(),
+			State:     sd.state,
+			Since:     sd.since,
+			Restarts:  sd.restarts,
+			LastError: sd.lastErr,
+		}
+		if sd.state == StateRunning {
+			out[idx].Health = sd.daemon.Health()
+		}
+	}
+	return out
+}
+
+// supervise runs sd until ctx is cancelled, restarting it with backoff
+// whenever it fails
+func (m *Manager) supervise(ctx context.Context, sd *supervised) {
+	defer close(sd.done)
+	name := sd.daemon.Name()
+	backoff := m.MinBackoff
+
+	for {
+		m.setState(sd, StateRunning, nil)
+		logger.Info("Daemon started", "daemon", name)
+		started := time.Now()
+		err := run(ctx, sd.daemon)
+
+		if ctx.Err() != nil || err == nil {
+			m.setState(sd, StateStopped, err)
+			if ctx.Err() == nil {
+				logger.Info("Daemon finished", "daemon", name)
+			}
+			return
+		}
+
+		if time.Since(started) >= m.StableAfter {
+			backoff = m.MinBackoff
+		}
+		m.mu.Lock()
+		sd.restarts++
+		m.mu.Unlock()
+		m.setState(sd, StateBackoff, err)
+		logger.Warn("Daemon failed; restarting", "daemon", name, "error", err, "backoff", backoff)
+
+		select {
+		case <-ctx.Done():
+			m.setState(sd, StateStopped, err)
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, m.MaxBackoff)
+	}
+}
+
+func (m *Manager) setState(sd *supervised, state State, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	sd.state = state
+	sd.since = time.Now()
+	if err != nil {
+		sd.lastErr = err
+	}
+}
+
+// run starts d, turning a panic into an error
+func run(ctx context.Context, d Daemon) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Error("Panic in daemon",
+				"daemon", d.Name(),
+				"panic", r,
+				"stack", string(debug.Stack()))
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return d.Start(ctx)
+}
+
+// HealthReporter remembers the outcome of a daemon's latest unit of work.
+// Embed it in a daemon to implement Health.
+type HealthReporter struct {
+	mu  sync.Mutex
+	err error
+}
+
+// Report records the outcome of the latest unit of work
+func (h *HealthReporter) Report(err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.err = err
+}
+
+// Health returns the error last reported, or nil
+func (h *HealthReporter) Health() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.err
+}
@@ -0,0 +1,168 @@
+package daemons_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/leeineian/minder/internal/daemons"
+	"github.com/leeineian/minder/internal/logger"
+)
+
+func TestMain(m *testing.M) {
+	logger.Init("error")
+	os.Exit(m.Run())
+}
+
+// fake is a daemon whose Start runs fn
+type fake struct {
+	daemons.HealthReporter
+
+	name    string
+	fn      func(ctx context.Context, run int) error
+	runs    atomic.Int32
+	stopped chan string
+}
+
+func (f *fake) Name() string { return f.name }
+
+func (f *fake) Start(ctx context.Context) error {
+	return f.fn(ctx, int(f.runs.Add(1)))
+}
+
+func (f *fake) Stop() error {
+	if f.stopped != nil {
+		f.stopped <- f.name
+	}
+	return nil
+}
+
+func untilCancelled(ctx context.Context, _ int) error {
+	<-ctx.Done()
+	return nil
+}
+
+func newManager() *daemons.Manager {
+	m := daemons.NewManager()
+	m.MinBackoff = time.Millisecond
+	m.MaxBackoff = 10 * time.Millisecond
+	return m
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for condition")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestRestartsAfterPanic(t *testing.T) {
+	m := newManager()
+	d := &fake{name: "flaky", fn: func(ctx context.Context, run int) error {
+		if run < 3 {
+			panic("boom")
+		}
+		return untilCancelled(ctx, run)
+	}}
+	m.Add(d)
+	m.Start(context.Background())
+	defer m.Stop(context.Background())
+
+	waitFor(t, func() bool { return m.Status()[0].State == daemons.StateRunning && d.runs.Load() == 3 })
+
+	st := m.Status()[0]
+	if st.Restarts != 2 {
+		t.Errorf("Expected 2 restarts, got %d", st.Restarts)
+	}
+	if st.LastError == nil || st.LastError.Error() != "panic: boom" {
+		t.Errorf("Expected the panic as last error, got %v", st.LastError)
+	}
+}
+
+func TestFinishedDaemonIsNotRestarted(t *testing.T) {
+	m := newManager()
+	d := &fake{name: "idle", fn: func(context.Context, int) error { return nil }}
+	m.Add(d)
+	m.Start(context.Background())
+	defer m.Stop(context.Background())
+
+	waitFor(t, func() bool { return m.Status()[0].State == daemons.StateStopped })
+	time.Sleep(20 * time.Millisecond)
+	if runs := d.runs.Load(); runs != 1 {
+		t.Errorf("Expected one run, got %d", runs)
+	}
+}
+
+func TestStopInReverseOrder(t *testing.T) {
+	m := newManager()
+	stopped := make(chan string, 3)
+	for _, name := range []string{"a", "b", "c"} {
+		m.Add(&fake{name: name, fn: untilCancelled, stopped: stopped})
+	}
+	m.Start(context.Background())
+	waitFor(t, func() bool {
+		for _, st := range m.Status() {
+			if st.State != daemons.StateRunning {
+				return false
+			}
+		}
+		return true
+	})
+
+	if err := m.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+	close(stopped)
+	var order string
+	for name := range stopped {
+		order += name
+	}
+	if order != "cba" {
+		t.Errorf("Expected daemons to stop in reverse order, got %q", order)
+	}
+	for _, st := range m.Status() {
+		if st.State != daemons.StateStopped {
+			t.Errorf("Expected %s to be stopped, got %s", st.Name, st.State)
+		}
+	}
+}
+
+func TestStopDeadline(t *testing.T) {
+	m := newManager()
+	var release sync.WaitGroup
+	release.Add(1)
+	m.Add(&fake{name: "stuck", fn: func(context.Context, int) error {
+		release.Wait()
+		return nil
+	}})
+	m.Start(context.Background())
+	defer release.Done()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := m.Stop(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the deadline to be exceeded, got %v", err)
+	}
+}
+
+func TestHealth(t *testing.T) {
+	m := newManager()
+	d := &fake{name: "sick", fn: untilCancelled}
+	m.Add(d)
+	m.Start(context.Background())
+	defer m.Stop(context.Background())
+	waitFor(t, func() bool { return m.Status()[0].State == daemons.StateRunning })
+
+	d.Report(errors.New("rate limited"))
+	if st := m.Status()[0]; st.Health == nil || st.Health.Error() != "rate limited" {
+		t.Errorf("Expected the reported error as health, got %v", st.Health)
+	}
+}
@@ -1,10 +1,13 @@
 package rolecolor
 
 import (
+	"context"
 	"math/rand"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
+	"github.com/leeineian/minder/internal/daemons"
+	"github.com/leeineian/minder/internal/discord"
 	"github.com/leeineian/minder/internal/logger"
 )
 
@@ -19,31 +22,59 @@ func Init(guild, role string) {
 	roleID = role
 }
 
-// Start begins the role color rotation daemon
-func Start(s *discordgo.Session) {
+// Rotator is the daemon that recolors the configured role
+type Rotator struct {
+	daemons.HealthReporter
+
+	session discord.Session
+}
+
+// New creates a role color rotator
+func New(s discord.Session) *Rotator {
+	return &Rotator{session: s}
+}
+
+func (r *Rotator) Name() string { return "rolecolor" }
+
+// Start recolors the role every 10 minutes until ctx is cancelled
+func (r *Rotator) Start(ctx context.Context) error {
 	if guildID == "" || roleID == "" {
 		logger.Info("Role color rotator not configured, skipping")
-		return
+		return nil
 	}
+	logger.Info("Role color rotator started", "guildID", guildID, "roleID", roleID)
 
-	go func() {
-		ticker := time.NewTicker(10 * time.Minute)
-		defer ticker.Stop()
-
-		for range ticker.C {
-			color := rand.Intn(0xFFFFFF)
+	ticker := time.NewTicker(10 * time.Minute)
+	defer ticker.Stop()
 
-			_, err := s.GuildRoleEdit(guildID, roleID, &discordgo.RoleParams{
-				Color: &color,
-			})
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
 
-			if err != nil {
-				logger.Warn("Failed to update role color", "error", err, "guildID", guildID, "roleID", roleID)
-			} else {
-				logger.Debug("Updated role color", "color", color, "guildID", guildID, "roleID", roleID)
-			}
+		err := Recolor(r.session)
+		if err != nil {
+			logger.Warn("Failed to update role color", "error", err, "guildID", guildID, "roleID", roleID)
 		}
-	}()
+		r.Report(err)
+	}
+}
 
-	logger.Info("Role color rotator started", "guildID", guildID, "roleID", roleID)
+func (r *Rotator) Stop() error { return nil }
+
+// Recolor gives the configured role a random color
+func Recolor(s discord.Session) error {
+	color := rand.Intn(0xFFFFFF)
+
+	_, err := s.GuildRoleEdit(guildID, roleID, &discordgo.RoleParams{
+		Color: &color,
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.Debug("Updated role color", "color", color, "guildID", guildID, "roleID", roleID)
+	return nil
 }
@@ -0,0 +1,47 @@
+package rolecolor_test
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/leeineian/minder/internal/daemons/rolecolor"
+	"github.com/leeineian/minder/internal/discord/discordtest"
+	"github.com/leeineian/minder/internal/logger"
+)
+
+func TestMain(m *testing.M) {
+	logger.Init("error")
+	os.Exit(m.Run())
+}
+
+func TestRecolor(t *testing.T) {
+	rolecolor.Init("g1", "r1")
+	s := discordtest.NewSession()
+
+	if err := rolecolor.Recolor(s); err != nil {
+		t.Fatalf("Recolor failed: %v", err)
+	}
+
+	edits := s.CallsTo("GuildRoleEdit")
+	if len(edits) != 1 {
+		t.Fatalf("Expected one role edit, got %d", len(edits))
+	}
+	edit := edits[0]
+	if edit.GuildID != "g1" || edit.RoleID != "r1" {
+		t.Errorf("Expected role r1 in guild g1 to be edited, got %s in %s", edit.RoleID, edit.GuildID)
+	}
+	if edit.Role.Color == nil || *edit.Role.Color < 0 || *edit.Role.Color > 0xFFFFFF {
+		t.Errorf("Expected a valid RGB color, got %v", edit.Role.Color)
+	}
+}
+
+func TestRecolorFailure(t *testing.T) {
+	rolecolor.Init("g1", "r1")
+	s := discordtest.NewSession()
+	s.FailOn("GuildRoleEdit", errors.New("missing permissions"))
+
+	if err := rolecolor.Recolor(s); err == nil {
+		t.Error("Expected the API error to be returned")
+	}
+}
@@ -1,6 +1,7 @@
 package scheduler
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"sync"
@@ -8,6 +9,7 @@ import (
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/leeineian/minder/internal/database"
+	"github.com/leeineian/minder/internal/discord"
 )
 
 type ReminderJob struct {
@@ -22,10 +24,23 @@ type ReminderJob struct {
 var (
 	jobs   = make(map[int]*ReminderJob)
 	jobsMu sync.Mutex
+
+	// sending counts reminders being delivered; no new deliveries start
+	// once shuttingDown is set
+	sending      sync.WaitGroup
+	shuttingDown bool
 )
 
+// SnoozeWindow is how long a delivered reminder can still be snoozed. Inactive
+// rows are pruned once it has passed.
+const SnoozeWindow = 7 * 24 * time.Hour
+
+// ReminderComponents returns the components, such as snooze buttons, sent
+// with a delivered reminder. It is set by the reminder command.
+var ReminderComponents func(id int) []discordgo.MessageComponent
+
 // ScheduleReminder schedules a reminder for delivery
-func ScheduleReminder(s *discordgo.Session, userID, channelID, message string, id int, dueAt time.Time) {
+func ScheduleReminder(s discord.Session, userID, channelID, message string, id int, dueAt time.Time) {
 	jobsMu.Lock()
 	defer jobsMu.Unlock()
 
@@ -48,6 +63,15 @@ func ScheduleReminder(s *discordgo.Session, userID, channelID, message string, i
 	}
 
 	job.timer = time.AfterFunc(delay, func() {
+		jobsMu.Lock()
+		if shuttingDown {
+			jobsMu.Unlock()
+			return
+		}
+		sending.Add(1)
+		jobsMu.Unlock()
+		defer sending.Done()
+
 		sendReminder(s, job)
 	})
 
@@ -67,19 +91,44 @@ func CancelReminder(id int) {
 	}
 }
 
-func sendReminder(s *discordgo.Session, job *ReminderJob) {
-	content := fmt.Sprintf("⏰ **Time's Up, <@%s>!**\nReminder: \"%s\"", job.UserID, job.Message)
+// Shutdown stops every pending reminder timer and waits until reminders
+// being delivered have been sent or ctx expires. Pending reminders stay active
+// in the database and are restored on the next start.
+func Shutdown(ctx context.Context) error {
+	jobsMu.Lock()
+	shuttingDown = true
+	for _, job := range jobs {
+		job.timer.Stop()
+	}
+	jobsMu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		sending.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func sendReminder(s discord.Session, job *ReminderJob) {
+	msg := &discordgo.MessageSend{
+		Content: fmt.Sprintf("⏰ **Time's Up, <@%s>!**\nReminder: \"%s\"", job.UserID, job.Message),
+	}
+	if ReminderComponents != nil {
+		msg.Components = ReminderComponents(job.ID)
+	}
 
 	// Try to send DM to user
 	channel, err := s.UserChannelCreate(job.UserID)
 	if err == nil {
-		_, err = s.ChannelMessageSend(channel.ID, content)
+		_, err = s.ChannelMessageSendComplex(channel.ID, msg)
 		if err == nil {
-			// Success - delete from DB
-			database.DB.Exec("DELETE FROM reminders WHERE id = ?", job.ID)
-			jobsMu.Lock()
-			delete(jobs, job.ID)
-			jobsMu.Unlock()
+			markDelivered(job)
 			log.Printf("Reminder %d delivered via DM", job.ID)
 			return
 		}
@@ -88,25 +137,49 @@ func sendReminder(s *discordgo.Session, job *ReminderJob) {
 
 	// Fallback to channel if DM fails
 	if job.ChannelID != "" {
-		_, err = s.ChannelMessageSend(job.ChannelID, content)
+		_, err = s.ChannelMessageSendComplex(job.ChannelID, msg)
 		if err != nil {
 			log.Printf("Failed to send reminder %d to channel: %v", job.ID, err)
 			return
 		}
 
-		// Delete from DB
-		database.DB.Exec("DELETE FROM reminders WHERE id = ?", job.ID)
-		jobsMu.Lock()
-		delete(jobs, job.ID)
-		jobsMu.Unlock()
+		markDelivered(job)
 		log.Printf("Reminder %d delivered via channel fallback", job.ID)
 	} else {
 		log.Printf("Reminder %d failed: no DM and no channel fallback", job.ID)
 	}
 }
 
+// markDelivered deactivates a delivered reminder. The row is kept for
+// SnoozeWindow so the reminder can be snoozed from its message.
+func markDelivered(job *ReminderJob) {
+	now := time.Now()
+	database.DB.Exec("UPDATE reminders SET active = 0, deliveredAt = ? WHERE id = ?", now.Unix(), job.ID)
+	jobsMu.Lock()
+	delete(jobs, job.ID)
+	jobsMu.Unlock()
+
+	if err := PruneReminders(now); err != nil {
+		log.Printf("Failed to prune reminders: %v", err)
+	}
+}
+
+// PruneReminders deletes deleted reminders and delivered reminders whose
+// snooze window has passed
+func PruneReminders(now time.Time) error {
+	_, err := database.DB.Exec(
+		"DELETE FROM reminders WHERE active = 0 AND (deliveredAt IS NULL OR deliveredAt < ?)",
+		now.Add(-SnoozeWindow).Unix(),
+	)
+	return err
+}
+
 // RestoreReminders loads pending reminders from DB on startup
-func RestoreReminders(s *discordgo.Session) error {
+func RestoreReminders(s discord.Session) error {
+	if err := PruneReminders(time.Now()); err != nil {
+		log.Printf("Failed to prune reminders: %v", err)
+	}
+
 	rows, err := database.DB.Query(
 		"SELECT id, userId, channelId, message, time FROM reminders WHERE active = 1",
 	)
@@ -1,37 +1,141 @@
 package status
 
 import (
+	"context"
+	"fmt"
 	"math/rand"
 	"time"
 
-	"github.com/bwmarrin/discordgo"
+	"github.com/leeineian/minder/internal/daemons"
+	"github.com/leeineian/minder/internal/daemons/looper"
+	"github.com/leeineian/minder/internal/database"
+	"github.com/leeineian/minder/internal/discord"
 	"github.com/leeineian/minder/internal/logger"
 )
 
-var statuses = []string{
-	"with webhooks",
-	"Go routines",
-	"/reminder | /cat",
-	"stress testing Discord",
+// Order is how the rotator walks through the entries
+type Order string
+
+const (
+	Random     Order = "random"
+	Sequential Order = "sequential"
+)
+
+// Values are the live values status templates can use, e.g. {{.Guilds}}
+type Values struct {
+	Guilds          int
+	ActiveReminders int
+	RunningLoops    int
+}
+
+// GuildCount returns how many guilds the bot is in. It is set by the bot.
+var GuildCount func() int
+
+// CurrentValues collects the values templates render with
+func CurrentValues() Values {
+	var v Values
+	if GuildCount != nil {
+		v.Guilds = GuildCount()
+	}
+	if database.DB != nil {
+		database.DB.QueryRow("SELECT COUNT(*) FROM reminders WHERE active = 1").Scan(&v.ActiveReminders)
+	}
+	for _, l := range looper.GlobalManager.List() {
+		if l.Running {
+			v.RunningLoops++
+		}
+	}
+	return v
+}
+
+// Rotator is the daemon that rotates the bot's status through the stored
+// entries, or a built-in set while none are stored
+type Rotator struct {
+	daemons.HealthReporter
+
+	// Interval between status changes; DefaultInterval if not positive
+	Interval time.Duration
+	// Order the entries are shown in
+	Order Order
+
+	session discord.Session
+	next    int   // position of the next entry in sequential order
+	last    Entry // shown last, so random order does not repeat it
+}
+
+// DefaultInterval is how often a rotator changes the status unless told otherwise
+const DefaultInterval = 30 * time.Second
+
+// New creates a status rotator that changes the status every DefaultInterval in random order
+func New(s discord.Session) *Rotator {
+	return &Rotator{Interval: DefaultInterval, Order: Random, session: s}
 }
 
-// Start begins the status rotation daemon
-func Start(s *discordgo.Session) {
-	// Initialize random seed
-	rand.Seed(time.Now().UnixNano())
-
-	go func() {
-		ticker := time.NewTicker(30 * time.Second)
-		defer ticker.Stop()
-
-		for range ticker.C {
-			status := statuses[rand.Intn(len(statuses))]
-			err := s.UpdateGameStatus(0, status)
-			if err != nil {
-				logger.Warn("Failed to update status", "error", err, "status", status)
-			}
+func (r *Rotator) Name() string { return "status" }
+
+// Start shows a status right away and then rotates it every Interval until
+// ctx is cancelled. Entries added or removed apply from the next rotation.
+func (r *Rotator) Start(ctx context.Context) error {
+	interval := r.Interval
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		err := r.Rotate()
+		if err != nil {
+			logger.Warn("Failed to update status", "error", err)
+		}
+		r.Report(err)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
 		}
-	}()
+	}
+}
+
+func (r *Rotator) Stop() error { return nil }
+
+// Rotate shows the next status
+func (r *Rotator) Rotate() error {
+	entries, err := List()
+	if err != nil {
+		return fmt.Errorf("failed to load statuses: %w", err)
+	}
+	if len(entries) == 0 {
+		entries = defaultEntries
+	}
 
-	logger.Info("Status rotator started")
+	entry := r.pick(entries)
+	text, err := entry.Render(CurrentValues())
+	if err != nil {
+		return fmt.Errorf("failed to render status #%d: %w", entry.ID, err)
+	}
+	if err := r.session.UpdateStatusComplex(entry.presence(text)); err != nil {
+		return err
+	}
+	logger.Debug("Updated status", "id", entry.ID, "type", entry.Type, "text", text)
+	return nil
+}
+
+// pick chooses the next entry in the rotator's order
+func (r *Rotator) pick(entries []Entry) Entry {
+	var entry Entry
+	switch r.Order {
+	case Sequential:
+		entry = entries[r.next%len(entries)]
+		r.next = (r.next + 1) % len(entries)
+	default:
+		idx := rand.Intn(len(entries))
+		if len(entries) > 1 && entries[idx] == r.last {
+			idx = (idx + 1) % len(entries)
+		}
+		entry = entries[idx]
+	}
+	r.last = entry
+	return entry
 }
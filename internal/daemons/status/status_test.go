@@ -0,0 +1,130 @@
+package status_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/leeineian/minder/internal/daemons/status"
+	"github.com/leeineian/minder/internal/database"
+	"github.com/leeineian/minder/internal/database/dbtest"
+	"github.com/leeineian/minder/internal/discord/discordtest"
+	"github.com/leeineian/minder/internal/logger"
+)
+
+func TestMain(m *testing.M) {
+	logger.Init("error")
+	os.Exit(m.Run())
+}
+
+// shown returns the activities the session was asked to show
+func shown(s *discordtest.Session) []*discordgo.Activity {
+	var out []*discordgo.Activity
+	for _, c := range s.CallsTo("UpdateStatusComplex") {
+		out = append(out, c.Presence.Activities[0])
+	}
+	return out
+}
+
+func TestDefaultsWhileEmpty(t *testing.T) {
+	dbtest.Setup(t)
+	s := discordtest.NewSession()
+
+	if err := status.New(s).Rotate(); err != nil {
+		t.Fatalf("Rotate failed: %v", err)
+	}
+	activities := shown(s)
+	if len(activities) != 1 || activities[0].Type != discordgo.ActivityTypeGame || activities[0].Name == "" {
+		t.Errorf("Expected a built-in playing status, got %+v", activities)
+	}
+}
+
+func TestStartWithoutInterval(t *testing.T) {
+	dbtest.Setup(t)
+	s := discordtest.NewSession()
+	r := status.New(s)
+	r.Interval = 0
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := r.Start(ctx); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if n := len(shown(s)); n != 1 {
+		t.Errorf("Expected a status to be shown before stopping, got %d", n)
+	}
+}
+
+func TestSequentialTemplates(t *testing.T) {
+	dbtest.Setup(t)
+	status.GuildCount = func() int { return 3 }
+	defer func() { status.GuildCount = nil }()
+	database.DB.Exec("INSERT INTO reminders (userId, message, time, active) VALUES ('u1', 'Stretch', 0, 1)")
+
+	for _, e := range []struct {
+		typ  status.ActivityType
+		text string
+	}{
+		{status.Watching, "{{.Guilds}} servers"},
+		{status.Custom, "{{.ActiveReminders}} reminders, {{.RunningLoops}} loops"},
+	} {
+		if _, err := status.Add(e.typ, e.text); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+	}
+
+	s := discordtest.NewSession()
+	r := status.New(s)
+	r.Order = status.Sequential
+	for range 3 {
+		if err := r.Rotate(); err != nil {
+			t.Fatalf("Rotate failed: %v", err)
+		}
+	}
+
+	activities := shown(s)
+	if len(activities) != 3 {
+		t.Fatalf("Expected three updates, got %d", len(activities))
+	}
+	if a := activities[0]; a.Type != discordgo.ActivityTypeWatching || a.Name != "3 servers" {
+		t.Errorf("Expected Watching 3 servers, got %+v", a)
+	}
+	if a := activities[1]; a.Type != discordgo.ActivityTypeCustom || a.State != "1 reminders, 0 loops" {
+		t.Errorf("Expected a custom status with live values, got %+v", a)
+	}
+	if a := activities[2]; a.Name != "3 servers" {
+		t.Errorf("Expected the rotation to wrap around, got %+v", a)
+	}
+}
+
+func TestAddRejectsBadTemplates(t *testing.T) {
+	dbtest.Setup(t)
+
+	for _, text := range []string{"{{.Guilds", "{{.Members}} members", "{{/* nothing */}}"} {
+		if _, err := status.Add(status.Playing, text); err == nil {
+			t.Errorf("Expected %q to be rejected", text)
+		}
+	}
+	if _, err := status.Add("dancing", "to music"); err == nil {
+		t.Error("Expected unknown activity types to be rejected")
+	}
+	if entries, _ := status.List(); len(entries) != 0 {
+		t.Errorf("Expected nothing to be stored, got %+v", entries)
+	}
+}
+
+func TestRemove(t *testing.T) {
+	dbtest.Setup(t)
+	entry, err := status.Add(status.Listening, "the rain")
+	if err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	if removed, err := status.Remove(entry.ID); err != nil || !removed {
+		t.Fatalf("Expected the entry to be removed, got %v %v", removed, err)
+	}
+	if removed, _ := status.Remove(entry.ID); removed {
+		t.Error("Expected a second remove to find nothing")
+	}
+}
@@ -0,0 +1,154 @@
+package status
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/leeineian/minder/internal/database"
+)
+
+// ActivityType is how Discord introduces a status, e.g. "Watching ..."
+type ActivityType string
+
+const (
+	Playing   ActivityType = "playing"
+	Watching  ActivityType = "watching"
+	Listening ActivityType = "listening"
+	Competing ActivityType = "competing"
+	Custom    ActivityType = "custom" // Shown as is, without a verb
+)
+
+// activityTypes maps each ActivityType to Discord's and the label it shows with
+var activityTypes = map[ActivityType]struct {
+	discord discordgo.ActivityType
+	label   string
+}{
+	Playing:   {discordgo.ActivityTypeGame, "Playing"},
+	Watching:  {discordgo.ActivityTypeWatching, "Watching"},
+	Listening: {discordgo.ActivityTypeListening, "Listening to"},
+	Competing: {discordgo.ActivityTypeCompeting, "Competing in"},
+	Custom:    {discordgo.ActivityTypeCustom, ""},
+}
+
+// MaxTextLength is the longest status Discord shows
+const MaxTextLength = 128
+
+// Entry is a status the rotator shows. Text is a template over Values.
+type Entry struct {
+	ID        int
+	Type      ActivityType
+	Text      string
+	CreatedAt time.Time
+}
+
+// defaultEntries are shown while no entries are stored
+var defaultEntries = []Entry{
+	{Type: Playing, Text: "with webhooks"},
+	{Type: Playing, Text: "Go routines"},
+	{Type: Playing, Text: "/reminder | /cat"},
+	{Type: Playing, Text: "stress testing Discord"},
+}
+
+// Render fills in the entry's template
+func (e Entry) Render(v Values) (string, error) {
+	tmpl, err := template.New("status").Option("missingkey=error").Parse(e.Text)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, v); err != nil {
+		return "", err
+	}
+	text := strings.TrimSpace(b.String())
+	if r := []rune(text); len(r) > MaxTextLength {
+		text = string(r[:MaxTextLength])
+	}
+	return text, nil
+}
+
+// Preview renders the entry the way the Discord client shows it
+func (e Entry) Preview(v Values) (string, error) {
+	text, err := e.Render(v)
+	if err != nil {
+		return "", err
+	}
+	if label := activityTypes[e.Type].label; label != "" {
+		return label + " " + text, nil
+	}
+	return text, nil
+}
+
+// presence builds the presence update showing text
+func (e Entry) presence(text string) discordgo.UpdateStatusData {
+	activity := &discordgo.Activity{Name: text, Type: activityTypes[e.Type].discord}
+	if e.Type == Custom {
+		// Custom statuses show their state; the name is required but hidden
+		activity.Name = "Custom Status"
+		activity.State = text
+	}
+	return discordgo.UpdateStatusData{
+		Activities: []*discordgo.Activity{activity},
+		Status:     string(discordgo.StatusOnline),
+	}
+}
+
+// Add validates and stores a new entry
+func Add(typ ActivityType, text string) (Entry, error) {
+	if _, ok := activityTypes[typ]; !ok {
+		return Entry{}, fmt.Errorf("unknown activity type %q", typ)
+	}
+	entry := Entry{Type: typ, Text: text, CreatedAt: time.Now()}
+	rendered, err := entry.Render(Values{})
+	if err != nil {
+		return Entry{}, fmt.Errorf("invalid template: %w", err)
+	}
+	if rendered == "" {
+		return Entry{}, errors.New("status renders as empty text")
+	}
+
+	result, err := database.DB.Exec(
+		"INSERT INTO status_entries (type, text, createdAt) VALUES (?, ?, ?)",
+		string(typ), text, entry.CreatedAt.Unix(),
+	)
+	if err != nil {
+		return Entry{}, err
+	}
+	id, _ := result.LastInsertId()
+	entry.ID = int(id)
+	return entry, nil
+}
+
+// Remove deletes an entry, reporting whether it existed
+func Remove(id int) (bool, error) {
+	result, err := database.DB.Exec("DELETE FROM status_entries WHERE id = ?", id)
+	if err != nil {
+		return false, err
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
+
+// List returns the stored entries in the order they were added
+func List() ([]Entry, error) {
+	rows, err := database.DB.Query("SELECT id, type, text, createdAt FROM status_entries ORDER BY id ASC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []Entry
+	for rows.Next() {
+		var e Entry
+		var createdAt int64
+		if err := rows.Scan(&e.ID, &e.Type, &e.Text, &createdAt); err != nil {
+			return nil, err
+		}
+		e.CreatedAt = time.Unix(createdAt, 0)
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
@@ -58,11 +58,107 @@ func ExecuteMigration() error {
 		threads TEXT
 	);
 
+	CREATE TABLE IF NOT EXISTS webhook_loops_quarantine (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		channelId TEXT,
+		config TEXT,
+		threads TEXT,
+		hooks TEXT,
+		reason TEXT,
+		quarantinedAt INTEGER
+	);
+
+	CREATE TABLE IF NOT EXISTS looper_webhooks (
+		webhookId TEXT PRIMARY KEY,
+		guildId TEXT,
+		channelId TEXT,
+		token TEXT,
+		createdAt INTEGER
+	);
+
+	CREATE TABLE IF NOT EXISTS looper_presets (
+		name TEXT PRIMARY KEY,
+		payload TEXT,
+		updatedAt INTEGER
+	);
+
+	CREATE TABLE IF NOT EXISTS permission_audit (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		userId TEXT,
+		guildId TEXT,
+		channelId TEXT,
+		command TEXT,
+		reason TEXT,
+		createdAt INTEGER
+	);
+
+	CREATE TABLE IF NOT EXISTS command_cooldowns (
+		key TEXT PRIMARY KEY,
+		fullAt INTEGER
+	);
+
+	CREATE TABLE IF NOT EXISTS status_entries (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		type TEXT NOT NULL,
+		text TEXT NOT NULL,
+		createdAt INTEGER NOT NULL
+	);
+
     CREATE TABLE IF NOT EXISTS kv_store (
         key TEXT PRIMARY KEY,
         value TEXT
     );
 	`
-	_, err := DB.Exec(query)
-	return err
+	if _, err := DB.Exec(query); err != nil {
+		return err
+	}
+
+	// Columns added after the initial schema
+	columns := []struct{ table, name, decl string }{
+		{"webhook_loops", "hooks", "TEXT"},
+		{"webhook_loops", "running", "BOOLEAN DEFAULT 0"},
+		{"webhook_loops", "paused", "BOOLEAN DEFAULT 0"},
+		{"reminders", "deliveredAt", "INTEGER"},
+	}
+	for _, c := range columns {
+		if err := ensureColumn(c.table, c.name, c.decl); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ensureColumn adds a column to an existing table unless it is already present
+func ensureColumn(table, column, decl string) error {
+	rows, err := DB.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return fmt.Errorf("inspect table %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid       int
+			name      string
+			colType   string
+			notNull   int
+			dfltValue sql.NullString
+			pk        int
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dfltValue, &pk); err != nil {
+			return fmt.Errorf("inspect table %s: %w", table, err)
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("inspect table %s: %w", table, err)
+	}
+	rows.Close()
+
+	if _, err := DB.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, decl)); err != nil {
+		return fmt.Errorf("add column %s.%s: %w", table, column, err)
+	}
+	return nil
 }
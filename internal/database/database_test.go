@@ -54,7 +54,7 @@ func TestExecuteMigration(t *testing.T) {
 	}
 
 	// Verify tables exist
-	tables := []string{"reminders", "webhook_loops", "kv_store"}
+	tables := []string{"reminders", "webhook_loops", "webhook_loops_quarantine", "looper_presets", "looper_webhooks", "permission_audit", "command_cooldowns", "status_entries", "kv_store"}
 	for _, table := range tables {
 		var name string
 		query := "SELECT name FROM sqlite_master WHERE type='table' AND name=?"
@@ -0,0 +1,21 @@
+// Package dbtest gives tests a migrated database of their own
+package dbtest
+
+import (
+	"testing"
+
+	"github.com/leeineian/minder/internal/database"
+)
+
+// Setup opens a fresh migrated database in a temporary directory as
+// database.DB and closes it when the test ends
+func Setup(t testing.TB) {
+	t.Helper()
+	if err := database.Init(t.TempDir() + "/test.db"); err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	t.Cleanup(database.Close)
+	if err := database.ExecuteMigration(); err != nil {
+		t.Fatalf("Failed to execute migration: %v", err)
+	}
+}
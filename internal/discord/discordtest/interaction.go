@@ -0,0 +1,240 @@
+package discordtest
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Defaults for synthetic interactions; options override them
+const (
+	AppID     = "app"
+	GuildID   = "g1"
+	ChannelID = "c1"
+	UserID    = "u1"
+)
+
+var interactions atomic.Int64
+
+// Option customizes a synthetic interaction
+type Option func(*discordgo.Interaction)
+
+// Command builds a slash command invocation. Options become the command's
+// arguments; subcommands are nested with Sub and Group.
+func Command(name string, args []*discordgo.ApplicationCommandInteractionDataOption, opts ...Option) *discordgo.InteractionCreate {
+	return build(discordgo.InteractionApplicationCommand, discordgo.ApplicationCommandInteractionData{
+		Name:        name,
+		CommandType: discordgo.ChatApplicationCommand,
+		Options:     args,
+	}, opts)
+}
+
+// Autocomplete builds an autocomplete request; mark the option being typed with Focused
+func Autocomplete(name string, args []*discordgo.ApplicationCommandInteractionDataOption, opts ...Option) *discordgo.InteractionCreate {
+	return build(discordgo.InteractionApplicationCommandAutocomplete, discordgo.ApplicationCommandInteractionData{
+		Name:        name,
+		CommandType: discordgo.ChatApplicationCommand,
+		Options:     args,
+	}, opts)
+}
+
+// MessageCommand builds a message context menu invocation on target
+func MessageCommand(name string, target *discordgo.Message, opts ...Option) *discordgo.InteractionCreate {
+	return build(discordgo.InteractionApplicationCommand, discordgo.ApplicationCommandInteractionData{
+		Name:        name,
+		CommandType: discordgo.MessageApplicationCommand,
+		TargetID:    target.ID,
+		Resolved: &discordgo.ApplicationCommandInteractionDataResolved{
+			Messages: map[string]*discordgo.Message{target.ID: target},
+		},
+	}, opts)
+}
+
+// UserCommand builds a user context menu invocation on target
+func UserCommand(name string, target *discordgo.User, opts ...Option) *discordgo.InteractionCreate {
+	return build(discordgo.InteractionApplicationCommand, discordgo.ApplicationCommandInteractionData{
+		Name:        name,
+		CommandType: discordgo.UserApplicationCommand,
+		TargetID:    target.ID,
+		Resolved: &discordgo.ApplicationCommandInteractionDataResolved{
+			Users: map[string]*discordgo.User{target.ID: target},
+		},
+	}, opts)
+}
+
+// Button builds a button click on a component with customID
+func Button(customID string, opts ...Option) *discordgo.InteractionCreate {
+	return build(discordgo.InteractionMessageComponent, discordgo.MessageComponentInteractionData{
+		CustomID:      customID,
+		ComponentType: discordgo.ButtonComponent,
+	}, opts)
+}
+
+// Select builds a string select menu choice on a component with customID
+func Select(customID string, values []string, opts ...Option) *discordgo.InteractionCreate {
+	return build(discordgo.InteractionMessageComponent, discordgo.MessageComponentInteractionData{
+		CustomID:      customID,
+		ComponentType: discordgo.SelectMenuComponent,
+		Values:        values,
+	}, opts)
+}
+
+// ModalSubmit builds a modal submission; each field becomes a text input in its own row
+func ModalSubmit(customID string, fields map[string]string, opts ...Option) *discordgo.InteractionCreate {
+	data := discordgo.ModalSubmitInteractionData{CustomID: customID}
+	for id, value := range fields {
+		data.Components = append(data.Components, &discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{&discordgo.TextInput{CustomID: id, Value: value}},
+		})
+	}
+	return build(discordgo.InteractionModalSubmit, data, opts)
+}
+
+func build(typ discordgo.InteractionType, data discordgo.InteractionData, opts []Option) *discordgo.InteractionCreate {
+	n := interactions.Add(1)
+	i := &discordgo.Interaction{
+		ID:        fmt.Sprint("i", n),
+		AppID:     AppID,
+		Token:     fmt.Sprint("tok", n),
+		Type:      typ,
+		Data:      data,
+		GuildID:   GuildID,
+		ChannelID: ChannelID,
+		Member: &discordgo.Member{
+			User:        &discordgo.User{ID: UserID, Username: UserID},
+			Permissions: discordgo.PermissionSendMessages | discordgo.PermissionViewChannel,
+		},
+		Locale: discordgo.EnglishUS,
+	}
+	for _, opt := range opts {
+		opt(i)
+	}
+	return &discordgo.InteractionCreate{Interaction: i}
+}
+
+// From sets the invoking user
+func From(userID string) Option {
+	return func(i *discordgo.Interaction) {
+		if i.Member != nil {
+			i.Member.User = &discordgo.User{ID: userID, Username: userID}
+		} else {
+			i.User = &discordgo.User{ID: userID, Username: userID}
+		}
+	}
+}
+
+// InGuild sets the guild and channel the interaction happened in
+func InGuild(guildID, channelID string) Option {
+	return func(i *discordgo.Interaction) {
+		i.GuildID = guildID
+		i.ChannelID = channelID
+	}
+}
+
+// InDM moves the interaction to a direct message with the invoking user
+func InDM() Option {
+	return func(i *discordgo.Interaction) {
+		if i.Member != nil {
+			i.User = i.Member.User
+			i.Member = nil
+		}
+		i.GuildID = ""
+		i.ChannelID = "dm-" + i.User.ID
+	}
+}
+
+// WithPermissions sets the invoking member's permissions in the channel
+func WithPermissions(perms int64) Option {
+	return func(i *discordgo.Interaction) {
+		if i.Member != nil {
+			i.Member.Permissions = perms
+		}
+	}
+}
+
+// WithRoles sets the invoking member's roles
+func WithRoles(roleIDs ...string) Option {
+	return func(i *discordgo.Interaction) {
+		if i.Member != nil {
+			i.Member.Roles = roleIDs
+		}
+	}
+}
+
+// WithLocale sets the invoking user's client locale
+func WithLocale(locale discordgo.Locale) Option {
+	return func(i *discordgo.Interaction) {
+		i.Locale = locale
+	}
+}
+
+// OnMessage sets the message a component was attached to
+func OnMessage(msg *discordgo.Message) Option {
+	return func(i *discordgo.Interaction) {
+		i.Message = msg
+	}
+}
+
+// Resolve adds channels to the resolved data of a command, as Discord does
+// for channel options
+func Resolve(channels ...*discordgo.Channel) Option {
+	return func(i *discordgo.Interaction) {
+		data, ok := i.Data.(discordgo.ApplicationCommandInteractionData)
+		if !ok {
+			return
+		}
+		if data.Resolved == nil {
+			data.Resolved = &discordgo.ApplicationCommandInteractionDataResolved{}
+		}
+		if data.Resolved.Channels == nil {
+			data.Resolved.Channels = make(map[string]*discordgo.Channel)
+		}
+		for _, ch := range channels {
+			data.Resolved.Channels[ch.ID] = ch
+		}
+		i.Data = data
+	}
+}
+
+// Args is shorthand for a list of command arguments
+func Args(args ...*discordgo.ApplicationCommandInteractionDataOption) []*discordgo.ApplicationCommandInteractionDataOption {
+	return args
+}
+
+// Sub builds a subcommand with its arguments
+func Sub(name string, args ...*discordgo.ApplicationCommandInteractionDataOption) *discordgo.ApplicationCommandInteractionDataOption {
+	return &discordgo.ApplicationCommandInteractionDataOption{Name: name, Type: discordgo.ApplicationCommandOptionSubCommand, Options: args}
+}
+
+// Group builds a subcommand group
+func Group(name string, subs ...*discordgo.ApplicationCommandInteractionDataOption) *discordgo.ApplicationCommandInteractionDataOption {
+	return &discordgo.ApplicationCommandInteractionDataOption{Name: name, Type: discordgo.ApplicationCommandOptionSubCommandGroup, Options: subs}
+}
+
+// String builds a string argument
+func String(name, value string) *discordgo.ApplicationCommandInteractionDataOption {
+	return &discordgo.ApplicationCommandInteractionDataOption{Name: name, Type: discordgo.ApplicationCommandOptionString, Value: value}
+}
+
+// Int builds an integer argument; Discord sends integers as JSON numbers
+func Int(name string, value int) *discordgo.ApplicationCommandInteractionDataOption {
+	return &discordgo.ApplicationCommandInteractionDataOption{Name: name, Type: discordgo.ApplicationCommandOptionInteger, Value: float64(value)}
+}
+
+// Bool builds a boolean argument
+func Bool(name string, value bool) *discordgo.ApplicationCommandInteractionDataOption {
+	return &discordgo.ApplicationCommandInteractionDataOption{Name: name, Type: discordgo.ApplicationCommandOptionBoolean, Value: value}
+}
+
+// Channel builds a channel argument; pair it with Resolve for handlers that read the channel
+func Channel(name, channelID string) *discordgo.ApplicationCommandInteractionDataOption {
+	return &discordgo.ApplicationCommandInteractionDataOption{Name: name, Type: discordgo.ApplicationCommandOptionChannel, Value: channelID}
+}
+
+// Focused marks a string argument as the one being typed in an autocomplete request
+func Focused(name, value string) *discordgo.ApplicationCommandInteractionDataOption {
+	opt := String(name, value)
+	opt.Focused = true
+	return opt
+}
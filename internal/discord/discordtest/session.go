@@ -0,0 +1,196 @@
+// Package discordtest fakes the Discord API so command handlers and daemons
+// can be tested end to end without a network
+package discordtest
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/leeineian/minder/internal/discord"
+)
+
+// Call is one recorded API call; only the fields of its method are set
+type Call struct {
+	Method string
+
+	Interaction *discordgo.Interaction
+	Response    *discordgo.InteractionResponse // InteractionRespond
+	Edit        *discordgo.WebhookEdit         // InteractionResponseEdit
+	Followup    *discordgo.WebhookParams       // FollowupMessageCreate
+
+	ChannelID string                 // ChannelMessageSend, ChannelMessageSendComplex
+	Message   *discordgo.MessageSend // ChannelMessageSend, ChannelMessageSendComplex
+	UserID    string                 // UserChannelCreate
+
+	GuildID string                // GuildRoleEdit
+	RoleID  string                // GuildRoleEdit
+	Role    *discordgo.RoleParams // GuildRoleEdit
+
+	Presence *discordgo.UpdateStatusData // UpdateStatusComplex
+
+	Err error // injected with FailOn
+}
+
+// Session is a discord.Session that records calls instead of making them
+type Session struct {
+	mu     sync.Mutex
+	calls  []Call
+	errs   map[string]error
+	nextID int
+}
+
+var _ discord.Session = (*Session)(nil)
+
+// NewSession returns a fake session whose calls all succeed
+func NewSession() *Session {
+	return &Session{errs: make(map[string]error)}
+}
+
+// FailOn makes every later call to method return err
+func (s *Session) FailOn(method string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.errs[method] = err
+}
+
+// Calls returns the recorded calls in order
+func (s *Session) Calls() []Call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Call(nil), s.calls...)
+}
+
+// CallsTo returns the recorded calls to method in order
+func (s *Session) CallsTo(method string) []Call {
+	var out []Call
+	for _, c := range s.Calls() {
+		if c.Method == method {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// Texts returns the content of every message the calls would show, whether
+// sent as an interaction response, edit, followup or channel message. Failed
+// calls show nothing.
+func (s *Session) Texts() []string {
+	var out []string
+	for _, c := range s.Calls() {
+		switch {
+		case c.Err != nil:
+		case c.Response != nil && c.Response.Data != nil && c.Response.Data.Content != "":
+			out = append(out, c.Response.Data.Content)
+		case c.Edit != nil && c.Edit.Content != nil:
+			out = append(out, *c.Edit.Content)
+		case c.Followup != nil:
+			out = append(out, c.Followup.Content)
+		case c.Message != nil:
+			out = append(out, c.Message.Content)
+		}
+	}
+	return out
+}
+
+// Reply dispatches i on a fresh session and returns the one message it shows,
+// failing the test if it shows none or several
+func Reply(t testing.TB, dispatch func(discord.Session, *discordgo.InteractionCreate) bool, i *discordgo.InteractionCreate) string {
+	t.Helper()
+	s := NewSession()
+	dispatch(s, i)
+	texts := s.Texts()
+	if len(texts) != 1 {
+		t.Fatalf("Expected one reply, got %q", texts)
+	}
+	return texts[0]
+}
+
+// Reset forgets the recorded calls
+func (s *Session) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls = nil
+}
+
+// record stores c and returns the error configured for its method and a fresh snowflake
+func (s *Session) record(c Call) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c.Err = s.errs[c.Method]
+	s.calls = append(s.calls, c)
+	s.nextID++
+	return fmt.Sprint(1000 + s.nextID), c.Err
+}
+
+func (s *Session) InteractionRespond(interaction *discordgo.Interaction, resp *discordgo.InteractionResponse, _ ...discordgo.RequestOption) error {
+	_, err := s.record(Call{Method: "InteractionRespond", Interaction: interaction, Response: resp})
+	return err
+}
+
+func (s *Session) InteractionResponseEdit(interaction *discordgo.Interaction, newresp *discordgo.WebhookEdit, _ ...discordgo.RequestOption) (*discordgo.Message, error) {
+	id, err := s.record(Call{Method: "InteractionResponseEdit", Interaction: interaction, Edit: newresp})
+	if err != nil {
+		return nil, err
+	}
+	msg := &discordgo.Message{ID: id, ChannelID: interaction.ChannelID}
+	if newresp.Content != nil {
+		msg.Content = *newresp.Content
+	}
+	return msg, nil
+}
+
+func (s *Session) InteractionResponseDelete(interaction *discordgo.Interaction, _ ...discordgo.RequestOption) error {
+	_, err := s.record(Call{Method: "InteractionResponseDelete", Interaction: interaction})
+	return err
+}
+
+func (s *Session) FollowupMessageCreate(interaction *discordgo.Interaction, _ bool, data *discordgo.WebhookParams, _ ...discordgo.RequestOption) (*discordgo.Message, error) {
+	id, err := s.record(Call{Method: "FollowupMessageCreate", Interaction: interaction, Followup: data})
+	if err != nil {
+		return nil, err
+	}
+	return &discordgo.Message{ID: id, ChannelID: interaction.ChannelID, Content: data.Content}, nil
+}
+
+func (s *Session) ChannelMessageSend(channelID string, content string, options ...discordgo.RequestOption) (*discordgo.Message, error) {
+	return s.send("ChannelMessageSend", channelID, &discordgo.MessageSend{Content: content})
+}
+
+func (s *Session) ChannelMessageSendComplex(channelID string, data *discordgo.MessageSend, _ ...discordgo.RequestOption) (*discordgo.Message, error) {
+	return s.send("ChannelMessageSendComplex", channelID, data)
+}
+
+func (s *Session) send(method, channelID string, data *discordgo.MessageSend) (*discordgo.Message, error) {
+	id, err := s.record(Call{Method: method, ChannelID: channelID, Message: data})
+	if err != nil {
+		return nil, err
+	}
+	return &discordgo.Message{ID: id, ChannelID: channelID, Content: data.Content}, nil
+}
+
+func (s *Session) UserChannelCreate(recipientID string, _ ...discordgo.RequestOption) (*discordgo.Channel, error) {
+	_, err := s.record(Call{Method: "UserChannelCreate", UserID: recipientID})
+	if err != nil {
+		return nil, err
+	}
+	return &discordgo.Channel{ID: "dm-" + recipientID, Type: discordgo.ChannelTypeDM}, nil
+}
+
+func (s *Session) GuildRoleEdit(guildID, roleID string, data *discordgo.RoleParams, _ ...discordgo.RequestOption) (*discordgo.Role, error) {
+	_, err := s.record(Call{Method: "GuildRoleEdit", GuildID: guildID, RoleID: roleID, Role: data})
+	if err != nil {
+		return nil, err
+	}
+	role := &discordgo.Role{ID: roleID}
+	if data.Color != nil {
+		role.Color = *data.Color
+	}
+	return role, nil
+}
+
+func (s *Session) UpdateStatusComplex(usd discordgo.UpdateStatusData) error {
+	_, err := s.record(Call{Method: "UpdateStatusComplex", Presence: &usd})
+	return err
+}
@@ -0,0 +1,171 @@
+// Package i18n renders user-facing text in the interaction's language from
+// message catalogs embedded in the binary, one JSON file per Discord locale
+// in locales/. Messages are fmt format strings; translations that reorder
+// arguments use explicit indexes such as %[2]s.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Fallback is the locale used for missing locales and keys
+const Fallback = discordgo.EnglishUS
+
+//go:embed locales/*.json
+var files embed.FS
+
+// catalogs maps each shipped locale to its messages by key
+var catalogs = mustLoad()
+
+func mustLoad() map[discordgo.Locale]map[string]string {
+	entries, err := files.ReadDir("locales")
+	if err != nil {
+		panic(err)
+	}
+	loaded := make(map[discordgo.Locale]map[string]string, len(entries))
+	for _, e := range entries {
+		raw, err := files.ReadFile(path.Join("locales", e.Name()))
+		if err != nil {
+			panic(err)
+		}
+		var messages map[string]string
+		if err := json.Unmarshal(raw, &messages); err != nil {
+			panic(fmt.Sprintf("i18n: parse %s: %v", e.Name(), err))
+		}
+		loaded[discordgo.Locale(strings.TrimSuffix(e.Name(), ".json"))] = messages
+	}
+	if _, ok := loaded[Fallback]; !ok {
+		panic("i18n: missing catalog for " + string(Fallback))
+	}
+	return loaded
+}
+
+// T renders the message for key in locale, falling back to the same
+// language in another region, then to Fallback, then to the key itself
+func T(locale discordgo.Locale, key string, args ...any) string {
+	msg, ok := Find(locale, key)
+	if !ok {
+		return key
+	}
+	if len(args) == 0 {
+		return msg
+	}
+	return fmt.Sprintf(msg, localizeArgs(locale, args)...)
+}
+
+// Find returns the message for key in locale with the same fallbacks as T,
+// reporting false if no catalog has it
+func Find(locale discordgo.Locale, key string) (string, bool) {
+	if msg, ok := catalogs[resolve(locale)][key]; ok {
+		return msg, true
+	}
+	msg, ok := catalogs[Fallback][key]
+	return msg, ok
+}
+
+// Lookup returns the message for key in exactly locale, without fallbacks
+func Lookup(locale discordgo.Locale, key string) (string, bool) {
+	msg, ok := catalogs[locale][key]
+	return msg, ok
+}
+
+// Localizations returns the translations of key for Discord's
+// NameLocalizations and DescriptionLocalizations, leaving out Fallback and
+// translations identical to it. It returns nil if there are none.
+func Localizations(key string) map[discordgo.Locale]string {
+	base, ok := catalogs[Fallback][key]
+	if !ok {
+		return nil
+	}
+	var out map[discordgo.Locale]string
+	for locale, messages := range catalogs {
+		msg, ok := messages[key]
+		if locale == Fallback || !ok || msg == base {
+			continue
+		}
+		if out == nil {
+			out = make(map[discordgo.Locale]string)
+		}
+		out[locale] = msg
+	}
+	return out
+}
+
+// Locales returns the shipped locales, sorted
+func Locales() []discordgo.Locale {
+	locales := make([]discordgo.Locale, 0, len(catalogs))
+	for locale := range catalogs {
+		locales = append(locales, locale)
+	}
+	sort.Slice(locales, func(a, b int) bool { return locales[a] < locales[b] })
+	return locales
+}
+
+// Keys returns the keys of a shipped locale's catalog, sorted
+func Keys(locale discordgo.Locale) []string {
+	keys := make([]string, 0, len(catalogs[locale]))
+	for key := range catalogs[locale] {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// resolve maps a Discord locale to a shipped one, e.g. es-419 to es-ES
+func resolve(locale discordgo.Locale) discordgo.Locale {
+	if _, ok := catalogs[locale]; ok {
+		return locale
+	}
+	lang, _, _ := strings.Cut(string(locale), "-")
+	for _, shipped := range Locales() {
+		if l, _, _ := strings.Cut(string(shipped), "-"); l == lang {
+			return shipped
+		}
+	}
+	return Fallback
+}
+
+// Localizer is implemented by errors that can describe themselves in a locale
+type Localizer interface {
+	Localize(locale discordgo.Locale) string
+}
+
+// Error is an error whose message comes from the catalogs. Error returns the
+// Fallback text, for logs; Localize renders it for the user.
+type Error struct {
+	Key  string
+	Args []any
+}
+
+// Errorf returns an error rendering key with args
+func Errorf(key string, args ...any) *Error {
+	return &Error{Key: key, Args: args}
+}
+
+func (e *Error) Error() string {
+	return T(Fallback, e.Key, e.Args...)
+}
+
+// Localize renders the error in locale
+func (e *Error) Localize(locale discordgo.Locale) string {
+	return T(locale, e.Key, e.Args...)
+}
+
+// localizeArgs renders arguments that are themselves localizable in locale
+func localizeArgs(locale discordgo.Locale, args []any) []any {
+	out := make([]any, len(args))
+	for idx, arg := range args {
+		if l, ok := arg.(Localizer); ok {
+			arg = l.Localize(locale)
+		}
+		out[idx] = arg
+	}
+	return out
+}
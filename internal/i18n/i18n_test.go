@@ -0,0 +1,100 @@
+package i18n_test
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/leeineian/minder/internal/i18n"
+)
+
+// verb matches fmt verbs, including explicit argument indexes
+var verb = regexp.MustCompile(`%(\[\d+\])?[-+# 0]*\d*(\.\d+)?[a-zA-Z]`)
+
+// verbs returns the verbs of a message, sorted so reordered arguments compare equal
+func verbs(msg string) []string {
+	found := verb.FindAllString(msg, -1)
+	slices.Sort(found)
+	return found
+}
+
+func TestCatalogCoverage(t *testing.T) {
+	base := i18n.Keys(i18n.Fallback)
+	if len(base) == 0 {
+		t.Fatal("Expected the fallback catalog to have messages")
+	}
+
+	for _, locale := range i18n.Locales() {
+		t.Run(string(locale), func(t *testing.T) {
+			for _, key := range base {
+				msg, ok := i18n.Lookup(locale, key)
+				if !ok {
+					t.Errorf("Missing %s", key)
+					continue
+				}
+				want, _ := i18n.Lookup(i18n.Fallback, key)
+				if fmt.Sprint(verbs(msg)) != fmt.Sprint(verbs(want)) {
+					t.Errorf("%s has verbs %v, want %v", key, verbs(msg), verbs(want))
+				}
+			}
+			for _, key := range i18n.Keys(locale) {
+				if _, ok := i18n.Lookup(i18n.Fallback, key); !ok {
+					t.Errorf("%s is not in the %s catalog", key, i18n.Fallback)
+				}
+			}
+		})
+	}
+}
+
+func TestT(t *testing.T) {
+	tests := []struct {
+		name   string
+		locale discordgo.Locale
+		key    string
+		want   string
+	}{
+		{"exact", discordgo.German, "reminder.deleted", "🗑️ Erinnerung #3 gelöscht"},
+		{"same language", discordgo.SpanishLATAM, "reminder.deleted", "🗑️ Recordatorio #3 eliminado"},
+		{"unshipped locale", discordgo.Japanese, "reminder.deleted", "🗑️ Deleted reminder #3"},
+		{"no locale", "", "reminder.deleted", "🗑️ Deleted reminder #3"},
+		{"unknown key", discordgo.German, "no.such.key", "no.such.key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := i18n.T(tt.locale, tt.key, 3); got != tt.want {
+				t.Errorf("T() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocalizations(t *testing.T) {
+	names := i18n.Localizations("cmd.reminder.name")
+	if names[discordgo.German] != "erinnerung" {
+		t.Errorf("Expected the German name, got %v", names)
+	}
+	if _, ok := names[i18n.Fallback]; ok {
+		t.Error("Expected the fallback locale to be left out")
+	}
+	if l := i18n.Localizations("no.such.key"); l != nil {
+		t.Errorf("Expected nil for unknown keys, got %v", l)
+	}
+}
+
+func TestError(t *testing.T) {
+	err := i18n.Errorf("reminder.bad_time", i18n.Errorf("reminder.time_format"))
+	if err.Error() != "could not parse time: use duration format like '30m', '2h', '1h30m'" {
+		t.Errorf("Unexpected fallback message %q", err.Error())
+	}
+	if got := err.Localize(discordgo.German); got != "Zeit konnte nicht gelesen werden: verwende eine Dauer wie '30m', '2h', '1h30m'" {
+		t.Errorf("Expected nested errors to be localized too, got %q", got)
+	}
+
+	var l i18n.Localizer
+	if !errors.As(fmt.Errorf("wrapped: %w", err), &l) {
+		t.Error("Expected wrapped errors to expose the Localizer")
+	}
+}